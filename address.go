@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/base58"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// stakeScripts maps the stake output tags accepted by the commands to the
+// functions creating the tagged scripts.
+var stakeScripts = map[string]func(dcrutil.Address) ([]byte, error){
+	"sstx":       txscript.PayToSStx,
+	"ssgen":      txscript.PayToSSGen,
+	"ssrtx":      txscript.PayToSSRtx,
+	"sstxchange": txscript.PayToSStxChange,
+}
+
+// decodeAddress decodes an address of the provided network.  Ed25519
+// pay-to-pubkey addresses are decoded here since dcrutil includes their
+// signature suite byte in the public key and rejects them.
+func decodeAddress(s string, params dcrutil.AddressParams) (dcrutil.Address, error) {
+	decoded, netID, err := base58.CheckDecode(s)
+	if err == nil && netID == params.AddrIDPubKeyV0() &&
+		len(decoded) == 33 && decoded[0] == byte(dcrec.STEd25519) {
+
+		return dcrutil.NewAddressEdwardsPubKey(decoded[1:], params)
+	}
+	return dcrutil.DecodeAddress(s, params)
+}
+
+// addrKind returns a description of the kind of script the provided address
+// pays to along with its signature type.
+func addrKind(addr dcrutil.Address) string {
+	switch a := addr.(type) {
+	case *dcrutil.AddressPubKeyHash:
+		return "p2pkh " + sigTypeName(a.DSA())
+	case *dcrutil.AddressScriptHash:
+		return "p2sh"
+	case *dcrutil.AddressSecpPubKey:
+		return "p2pk " + sigTypeName(a.DSA())
+	case *dcrutil.AddressEdwardsPubKey:
+		return "p2pk " + sigTypeName(a.DSA())
+	case *dcrutil.AddressSecSchnorrPubKey:
+		return "p2pk " + sigTypeName(a.DSA())
+	}
+	return fmt.Sprintf("%T", addr)
+}
+
+// cmdAddrToScript prints the public key script paying to the provided
+// address, optionally tagged as a stake output.
+func cmdAddrToScript(args []string) error {
+	fs := flag.NewFlagSet("addr2script", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the address")
+	stake := fs.String("stake", "", "stake tag of the script (sstx, ssgen, "+
+		"ssrtx or sstxchange)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	addr, err := decodeAddress(fs.Arg(0), params)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", fs.Arg(0), err)
+	}
+
+	var script []byte
+	if *stake != "" {
+		stakeScript, ok := stakeScripts[*stake]
+		if !ok {
+			return fmt.Errorf("unknown stake tag %q", *stake)
+		}
+		script, err = stakeScript(addr)
+	} else {
+		script, err = txscript.PayToAddrScript(addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address: %s (%s)\n", addr, addrKind(addr))
+	fmt.Printf("Script: %x\n", script)
+	fmt.Printf("Disassembly: %s\n", disasm(script))
+	return nil
+}
+
+// cmdScriptToAddr prints the class of the provided public key script and the
+// addresses it pays to.
+func cmdScriptToAddr(args []string) error {
+	fs := flag.NewFlagSet("script2addr", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the addresses")
+	version := fs.Uint("version", 0, "script version")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	script, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	treasuryEnabled := txscript.ConsensusScriptFlags&txscript.ScriptVerifyTreasury != 0
+	class, addrs, requiredSigs, err := txscript.ExtractPkScriptAddrs(
+		uint16(*version), script, params, treasuryEnabled)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Class: %v\n", class)
+	if len(addrs) == 0 {
+		return fmt.Errorf("script of class %v does not pay to an address",
+			class)
+	}
+	fmt.Printf("Required sigs: %d\n", requiredSigs)
+	for _, addr := range addrs {
+		fmt.Printf("Address: %s (%s)\n", addr, addrKind(addr))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// voteBitsApproveParent is the vote bit that indicates the regular transaction
+// tree of the voted block is approved.
+const voteBitsApproveParent = 0x0001
+
+// agendaFile is the format of the files that define the agendas used to
+// interpret vote bits.  Agendas may be listed under either key so the output
+// of the getvoteinfo RPC can be used as is.
+type agendaFile struct {
+	Deployments []chaincfg.Vote `json:"deployments"`
+	Agendas     []chaincfg.Vote `json:"agendas"`
+}
+
+// loadAgendas loads the agenda definitions from the provided JSON file.
+func loadAgendas(path string) ([]chaincfg.Vote, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f agendaFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("invalid agenda file %s: %v", path, err)
+	}
+	return append(f.Deployments, f.Agendas...), nil
+}
+
+// describeVoteBits returns one line per vote described by the provided vote
+// bits.  The choice of every provided agenda is named according to its
+// definition and any bits not covered by the agendas are shown as is.
+func describeVoteBits(vb *txscript.SSGenVoteBits, agendas []chaincfg.Vote) []string {
+	approval := "no"
+	if vb.Bits&voteBitsApproveParent != 0 {
+		approval = "yes"
+	}
+	lines := []string{"approve parent: " + approval}
+
+	covered := uint16(voteBitsApproveParent)
+	for i := range agendas {
+		agenda := &agendas[i]
+		covered |= agenda.Mask
+		choice := fmt.Sprintf("invalid choice 0x%04x", vb.Bits&agenda.Mask)
+		if idx := agenda.VoteIndex(vb.Bits); idx >= 0 {
+			choice = agenda.Choices[idx].Id
+		}
+		lines = append(lines, fmt.Sprintf("agenda %s: %s", agenda.Id, choice))
+	}
+	if unknown := vb.Bits &^ covered; unknown != 0 {
+		lines = append(lines, fmt.Sprintf("unknown bits: 0x%04x", unknown))
+	}
+	if len(vb.Extended) > 0 {
+		lines = append(lines, fmt.Sprintf("extended: %x", vb.Extended))
+	}
+	return lines
+}
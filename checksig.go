@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// sigTypes maps the names of the signature types accepted by the commands to
+// the signature types.
+var sigTypes = map[string]dcrec.SignatureType{
+	"ecdsa":   dcrec.STEcdsaSecp256k1,
+	"ed25519": dcrec.STEd25519,
+	"schnorr": dcrec.STSchnorrSecp256k1,
+}
+
+// parseSigType returns the signature type with the provided name.
+func parseSigType(s string) (dcrec.SignatureType, error) {
+	sigType, ok := sigTypes[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown signature type %q", s)
+	}
+	return sigType, nil
+}
+
+// cmdCheckSig verifies a signature, including its hash type byte, for a
+// public key against either the provided hash or the signature hash of an
+// input of a transaction executing a subscript and prints the result.
+func cmdCheckSig(args []string) error {
+	fs := flag.NewFlagSet("checksig", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	sigTypeStr := fs.String("type", "ecdsa", "signature type (ecdsa, "+
+		"ed25519 or schnorr)")
+	hashStr := fs.String("hash", "", "hex-encoded hash instead of a "+
+		"transaction, input and subscript")
+	if err := fs.Parse(args); err != nil ||
+		(*hashStr != "" && fs.NArg() != 2) ||
+		(*hashStr == "" && fs.NArg() != 5) {
+
+		return errUsage
+	}
+	sigType, err := parseSigType(*sigTypeStr)
+	if err != nil {
+		return err
+	}
+	sig, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	pubKey, err := decodeScript(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	var hash []byte
+	if *hashStr != "" {
+		hash, err = decodeScript(*hashStr)
+		if err != nil {
+			return err
+		}
+	} else {
+		tx, err := decodeTx(fs.Arg(2))
+		if err != nil {
+			return err
+		}
+		idx, err := strconv.Atoi(fs.Arg(3))
+		if err != nil || idx < 0 || idx >= len(tx.TxIn) {
+			return fmt.Errorf("invalid input index %q", fs.Arg(3))
+		}
+		subScript, err := decodeScript(fs.Arg(4))
+		if err != nil {
+			return err
+		}
+		subScript, hash, err = txscript.CalcCheckSigHash(tx, idx,
+			subScript, sig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Subscript: %s\n", disasm(subScript))
+	}
+	if len(sig) != 0 {
+		fmt.Printf("Hash type: 0x%02x\n", sig[len(sig)-1])
+	}
+	fmt.Printf("Hash: %x\n", hash)
+
+	valid, err := txscript.VerifySignature(sigType, sig, pubKey, hash)
+	switch {
+	case err != nil:
+		fmt.Printf("Result: invalid: %v\n", err)
+	case !valid:
+		fmt.Printf("Result: invalid\n")
+	default:
+		fmt.Printf("Result: valid\n")
+	}
+	return nil
+}
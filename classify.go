@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// formatContractKey returns a description of the key that must sign for a
+// contract.
+func formatContractKey(key txscript.ContractKey) string {
+	if key.PubKey != nil {
+		return fmt.Sprintf("pubkey %x", key.PubKey)
+	}
+	return fmt.Sprintf("pubkey hash %x", key.Hash160)
+}
+
+// formatTimeLock returns a description of a lock time enforced by a contract.
+func formatTimeLock(lock txscript.TimeLock) string {
+	if lock.Relative {
+		return fmt.Sprintf("%d (relative)", lock.LockTime)
+	}
+	return fmt.Sprintf("%d (absolute)", lock.LockTime)
+}
+
+// printContract prints the parameters of a recognized contract.
+func printContract(contract txscript.Contract) {
+	fmt.Printf("Contract: %v\n", contract.Class())
+	switch c := contract.(type) {
+	case *txscript.AtomicSwapContract:
+		fmt.Printf("  Secret hash: %s %x\n", disasm([]byte{c.HashOp}),
+			c.SecretHash[:])
+		fmt.Printf("  Secret size: %d\n", c.SecretSize)
+		fmt.Printf("  Recipient: pubkey hash %x\n", c.RecipientHash160[:])
+		fmt.Printf("  Refund: pubkey hash %x\n", c.RefundHash160[:])
+		fmt.Printf("  Lock time: %d\n", c.LockTime)
+
+	case *txscript.HTLCContract:
+		fmt.Printf("  Secret hash: %s %x\n", disasm([]byte{c.HashOp}),
+			c.SecretHash)
+		if c.SecretSize != 0 {
+			fmt.Printf("  Secret size: %d\n", c.SecretSize)
+		}
+		fmt.Printf("  Recipient: %s\n", formatContractKey(c.Recipient))
+		fmt.Printf("  Refund: %s\n", formatContractKey(c.Refund))
+		fmt.Printf("  Lock time: %s\n", formatTimeLock(c.Lock))
+
+	case *txscript.TimeLockedSigContract:
+		fmt.Printf("  Key: %s\n", formatContractKey(c.Key))
+		fmt.Printf("  Lock time: %s\n", formatTimeLock(c.Lock))
+
+	case *txscript.TimeLockedMultiSigContract:
+		fmt.Printf("  Required sigs: %d of %d\n", c.RequiredSigs,
+			len(c.PubKeys))
+		for _, pubKey := range c.PubKeys {
+			fmt.Printf("  Key: pubkey %x\n", pubKey)
+		}
+		fmt.Printf("  Recovery: %s\n", formatContractKey(c.Recovery))
+		fmt.Printf("  Lock time: %s\n", formatTimeLock(c.Lock))
+
+	case *txscript.HashLockedContract:
+		fmt.Printf("  Secret hash: %s %x\n", disasm([]byte{c.HashOp}),
+			c.SecretHash)
+		if c.SecretSize != 0 {
+			fmt.Printf("  Secret size: %d\n", c.SecretSize)
+		}
+		if c.Key != nil {
+			fmt.Printf("  Key: %s\n", formatContractKey(*c.Key))
+		}
+	}
+}
+
+// loadTemplates loads the script templates from the provided file.
+func loadTemplates(path string) ([]*txscript.ScriptTemplate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return txscript.LoadScriptTemplates(f)
+}
+
+// printTemplateMatch prints the name of a matched template along with the
+// values it captured.
+func printTemplateMatch(match *txscript.TemplateMatch) {
+	fmt.Printf("Template: %s\n", match.Template.Name())
+	for _, c := range match.Captures {
+		switch c.Type {
+		case txscript.TemplateInt:
+			fmt.Printf("  %s: %d\n", c.Name, c.Int)
+		case txscript.TemplateOpcode:
+			fmt.Printf("  %s: %s\n", c.Name, disasm([]byte{c.Opcode}))
+		default:
+			fmt.Printf("  %s: %x\n", c.Name, c.Data)
+		}
+	}
+}
+
+// cmdClassify prints the standard script class of the script provided as hex
+// along with the parameters of the contract template it matches, if any.  When
+// a templates file is provided, the first user-defined template that matches
+// the script is printed as well.
+func cmdClassify(args []string) error {
+	fs := flag.NewFlagSet("classify", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	version := fs.Uint("version", 0, "script version")
+	templatesFile := fs.String("templates", "", "file with script templates")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	script, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var templates []*txscript.ScriptTemplate
+	if *templatesFile != "" {
+		templates, err = loadTemplates(*templatesFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	treasuryEnabled := txscript.ConsensusScriptFlags&txscript.ScriptVerifyTreasury != 0
+	class := txscript.GetScriptClass(uint16(*version), script, treasuryEnabled)
+	fmt.Printf("Class: %v\n", class)
+
+	contract, err := txscript.ExtractContract(uint16(*version), script)
+	if err != nil {
+		return err
+	}
+	if contract == nil {
+		fmt.Printf("Contract: %v\n", txscript.NonContractTy)
+	} else {
+		printContract(contract)
+	}
+
+	if len(templates) == 0 {
+		return nil
+	}
+	match, err := txscript.MatchScriptTemplates(templates, uint16(*version),
+		script)
+	if err != nil {
+		return err
+	}
+	if match == nil {
+		fmt.Printf("Template: none\n")
+		return nil
+	}
+	printTemplateMatch(match)
+	return nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+	"gopkg.in/yaml.v2"
+)
+
+// contractSpec describes a contract and the scenarios that spend it as read
+// from a spec file.
+type contractSpec struct {
+	// Script is the redeem script in the syntax accepted by the repl and
+	// ScriptHex is the hex redeem script.  Exactly one must be set.
+	Script    string `json:"script" yaml:"script"`
+	ScriptHex string `json:"scriptHex" yaml:"scriptHex"`
+
+	// Bare executes the script as the public key script instead of
+	// redeeming it through a pay-to-script-hash output.
+	Bare bool `json:"bare" yaml:"bare"`
+
+	// NamedKeys considers signatures sig:<name> valid for public keys
+	// pk:<name> instead of verifying them.
+	NamedKeys bool `json:"namedKeys" yaml:"namedKeys"`
+
+	// Flags are the names of the script flags.  The consensus flags are
+	// used when it is not set.
+	Flags []string `json:"flags" yaml:"flags"`
+
+	Scenarios []contractScenario `json:"scenarios" yaml:"scenarios"`
+}
+
+// contractScenario describes a spend of a contract and its expected result.
+type contractScenario struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Pushes are the items pushed by the signature script before the
+	// redeem script in the syntax accepted by the repl.
+	Pushes []string `json:"pushes" yaml:"pushes"`
+
+	// TxVersion, LockTime and Sequence configure the spending transaction.
+	// The sequence defaults to the maximum sequence number.
+	TxVersion *uint16 `json:"txVersion" yaml:"txVersion"`
+	LockTime  uint32  `json:"lockTime" yaml:"lockTime"`
+	Sequence  *uint32 `json:"sequence" yaml:"sequence"`
+
+	// Flags overrides the script flags of the spec when set.
+	Flags []string `json:"flags" yaml:"flags"`
+
+	// Expect is either OK or the name of the expected error kind, such as
+	// ErrEvalFalse.
+	Expect string `json:"expect" yaml:"expect"`
+}
+
+// loadContractSpec reads a spec file.  Files with the .json extension are
+// decoded as JSON and all others as YAML.  Unknown fields and expected results
+// that are neither OK nor a known error kind are rejected.
+func loadContractSpec(path string) (*contractSpec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec contractSpec
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&spec)
+	} else {
+		err = yaml.UnmarshalStrict(b, &spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := range spec.Scenarios {
+		sc := &spec.Scenarios[i]
+		switch sc.Expect {
+		case "OK":
+		case "":
+			err = errors.New("no expected result")
+		default:
+			_, err = txscript.ParseErrorKind(sc.Expect)
+		}
+		if err != nil {
+			name := sc.Name
+			if name == "" {
+				name = fmt.Sprintf("scenario %d", i)
+			}
+			return nil, fmt.Errorf("%s: %s: %v", path, name, err)
+		}
+	}
+	return &spec, nil
+}
+
+// runScenario executes the provided scenario of the spec and returns the
+// resulting error, if any.
+func runScenario(spec *contractSpec, script []byte, sc *contractScenario) error {
+	var pkScript []byte
+	b := txscript.NewScriptBuilder()
+	for _, push := range sc.Pushes {
+		pushScript, err := assembleScript(strings.Fields(push))
+		if err != nil {
+			return err
+		}
+		b.AddOps(pushScript)
+	}
+	if spec.Bare {
+		pkScript = script
+	} else {
+		var err error
+		pkScript, err = txscript.PayToScriptHashScript(
+			dcrutil.Hash160(script))
+		if err != nil {
+			return err
+		}
+		b.AddData(script)
+	}
+	sigScript, err := b.Script()
+	if err != nil {
+		return err
+	}
+
+	flagNames := spec.Flags
+	if sc.Flags != nil {
+		flagNames = sc.Flags
+	}
+	flags := txscript.ConsensusScriptFlags
+	if flagNames != nil {
+		flags, err = txscript.ParseScriptFlags(
+			strings.Join(flagNames, ","))
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg := &txscript.SpendingTxConfig{
+		Version:  wire.TxVersion,
+		LockTime: sc.LockTime,
+		Sequence: wire.MaxTxInSequenceNum,
+	}
+	if sc.TxVersion != nil {
+		cfg.Version = *sc.TxVersion
+	}
+	if sc.Sequence != nil {
+		cfg.Sequence = *sc.Sequence
+	}
+	tx, err := txscript.CreateSpendingTx(sigScript, pkScript, cfg)
+	if err != nil {
+		return err
+	}
+	vm, err := txscript.NewEngine(pkScript, tx, 0, flags, 0, nil)
+	if err != nil {
+		return err
+	}
+	if spec.NamedKeys {
+		vm.SetSigVerifier(txscript.NamedKeySigVerifier{})
+	}
+	return vm.Execute()
+}
+
+// runContractSpec runs all scenarios of the spec file at the provided path
+// and returns the number of scenarios that did not have the expected result.
+func runContractSpec(path string, verbose bool) (int, error) {
+	spec, err := loadContractSpec(path)
+	if err != nil {
+		return 0, err
+	}
+	var script []byte
+	switch {
+	case spec.Script != "" && spec.ScriptHex == "":
+		script, err = assembleScript(strings.Fields(spec.Script))
+	case spec.ScriptHex != "" && spec.Script == "":
+		script, err = hex.DecodeString(spec.ScriptHex)
+	default:
+		err = errors.New("exactly one of script and scriptHex must be set")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var failed int
+	for i := range spec.Scenarios {
+		sc := &spec.Scenarios[i]
+		name := sc.Name
+		if name == "" {
+			name = fmt.Sprintf("scenario %d", i)
+		}
+
+		err := runScenario(spec, script, sc)
+		var pass bool
+		if sc.Expect == "OK" {
+			pass = err == nil
+		} else {
+			pass = errors.Is(err, txscript.ErrorKind(sc.Expect))
+		}
+
+		result := "OK"
+		if err != nil {
+			result = err.Error()
+		}
+		switch {
+		case !pass:
+			failed++
+			fmt.Printf("FAIL %s: %s: got %s, want %s\n", path, name, result,
+				sc.Expect)
+		case verbose:
+			fmt.Printf("PASS %s: %s: %s\n", path, name, result)
+		}
+	}
+	return failed, nil
+}
+
+// cmdTest runs the scenarios of the provided contract spec files and reports
+// those that do not have the expected result.
+func cmdTest(args []string) error {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	verbose := fs.Bool("v", false, "report passing scenarios as well")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+
+	var failed int
+	for _, path := range fs.Args() {
+		n, err := runContractSpec(path, *verbose)
+		if err != nil {
+			return err
+		}
+		failed += n
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d scenarios failed", failed)
+	}
+	fmt.Printf("ok\n")
+	return nil
+}
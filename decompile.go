@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// cmdDecompile prints pseudocode describing the conditions required to spend
+// the script provided as hex.
+func cmdDecompile(args []string) error {
+	fs := flag.NewFlagSet("decompile", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	version := fs.Uint("version", 0, "script version")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	script, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	code, err := txscript.Decompile(script, uint16(*version),
+		txscript.ConsensusScriptFlags)
+	if err != nil {
+		return err
+	}
+	fmt.Print(code)
+	return nil
+}
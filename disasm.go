@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// cmdDisasm disassembles the script provided as hex.  Null data scripts which
+// carry a ticket commitment are decoded as well.
+func cmdDisasm(args []string) error {
+	fs := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network used to decode addresses")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+
+	version := uint16(0)
+	script, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	compress := false
+
+	var out strings.Builder
+	tkn := txscript.MakeScriptTokenizer(version, script)
+	for tkn.Next() {
+		err := txscript.DisasmOpcode(&out, tkn.Opcode(), tkn.Data(), compress)
+		if err != nil {
+			fmt.Printf("Error disasming opcode: %v\n", err)
+		}
+		out.WriteString(" ")
+	}
+
+	if err := tkn.Err(); err != nil {
+		fmt.Printf("Error parsing script: %v\n", err)
+	}
+
+	fmt.Printf("Output:\n%s\n", out.String())
+
+	commitment, err := txscript.ExtractSStxCommitment(version, script, params)
+	if err != nil {
+		return err
+	}
+	if commitment != nil {
+		fmt.Printf("Decoded:\n%s\n", describeCommitment(commitment))
+	}
+	return nil
+}
@@ -5,6 +5,15 @@ go 1.14
 replace github.com/decred/dcrd/txscript/v3 => ./txscript_vendored
 
 require (
+	github.com/decred/base58 v1.0.2
+	github.com/decred/dcrd/chaincfg/chainhash v1.0.2
+	github.com/decred/dcrd/chaincfg/v3 v3.0.0-20200215031403-6b2ce76f0986
+	github.com/decred/dcrd/dcrec v1.0.0
+	github.com/decred/dcrd/dcrec/edwards/v2 v2.0.0
 	github.com/decred/dcrd/dcrec/secp256k1/v3 v3.0.0-20200818052744-5bb9f3e87ff3 // indirect
+	github.com/decred/dcrd/dcrutil/v3 v3.0.0-20200215031403-6b2ce76f0986
 	github.com/decred/dcrd/txscript/v3 v3.0.0-00010101000000-000000000000
+	github.com/decred/dcrd/wire v1.3.0
+	github.com/decred/slog v1.0.0
+	gopkg.in/yaml.v2 v2.4.0
 )
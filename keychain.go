@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// defaultKeychainSeed is the seed of the test keychain used when none is
+// provided.
+const defaultKeychainSeed = "dcr-disasm"
+
+// deriveKeys derives the keys given as name[:type] arguments, where the type
+// defaults to ecdsa, with the provided keychain.
+func deriveKeys(kc *txscript.TestKeychain, args []string) ([]*txscript.TestKey, error) {
+	keys := make([]*txscript.TestKey, 0, len(args))
+	for _, arg := range args {
+		name, sigType := arg, dcrec.STEcdsaSecp256k1
+		if i := strings.LastIndex(arg, ":"); i >= 0 {
+			var err error
+			name = arg[:i]
+			sigType, err = parseSigType(arg[i+1:])
+			if err != nil {
+				return nil, err
+			}
+		}
+		key, err := kc.Key(name, sigType)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// cmdKeychain prints the keys with the provided names and signature types
+// derived from the seed of a test keychain along with their addresses.
+func cmdKeychain(args []string) error {
+	fs := flag.NewFlagSet("keychain", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys and addresses")
+	seed := fs.String("seed", defaultKeychainSeed, "seed of the keys")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	keys, err := deriveKeys(txscript.NewTestKeychain([]byte(*seed)),
+		fs.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		wif, err := key.WIF(params.PrivateKeyID)
+		if err != nil {
+			return err
+		}
+		p2pkh, err := key.PubKeyHashAddress(params)
+		if err != nil {
+			return err
+		}
+		p2pk, err := key.PubKeyAddress(params)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s (%s):\n", key.Name, sigTypeName(key.SigType))
+		fmt.Printf("  Private key: %s\n", wif)
+		fmt.Printf("  Signing key: %x\n", key.PrivKey)
+		fmt.Printf("  Public key: %x\n", key.PubKey)
+		fmt.Printf("  P2PKH address: %s\n", p2pkh)
+		fmt.Printf("  P2PK address: %s\n", p2pk)
+	}
+	return nil
+}
+
+// sigTypeName returns the name accepted by the commands of the provided
+// signature type.
+func sigTypeName(sigType dcrec.SignatureType) string {
+	for name, t := range sigTypes {
+		if t == sigType {
+			return name
+		}
+	}
+	return fmt.Sprintf("type %d", sigType)
+}
+
+// cmdFixture creates a funding transaction paying to a script of the provided
+// class with keys derived from the seed of a test keychain and a transaction
+// spending it signed by those keys, and prints the pair as a vector of the
+// reference valid transaction tests.
+func cmdFixture(args []string) error {
+	fs := flag.NewFlagSet("fixture", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys and addresses")
+	seed := fs.String("seed", defaultKeychainSeed, "seed of the keys")
+	class := fs.String("class", "p2pkh", "script class paying to the keys "+
+		"(p2pkh, p2pk or multisig)")
+	required := fs.Int("required", 0, "number of required signatures of "+
+		"a multisig script (defaults to all keys)")
+	p2sh := fs.Bool("p2sh", false, "pay to the script hash of the script")
+	stake := fs.String("stake", "", "stake tag of the output (sstx, ssgen, "+
+		"ssrtx or sstxchange)")
+	amount := fs.Int64("amount", 1e8, "amount of the funding output in atoms")
+	hashTypeStr := fs.String("hashtype", "all", "signature hash type")
+	flagStr := fs.String("flags", "", "script flags in the vector format "+
+		"(defaults to the consensus flags)")
+	comment := fs.String("comment", "", "comment printed before the vector")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	hashType, err := parseSigHashType(*hashTypeStr)
+	if err != nil {
+		return err
+	}
+	flags := txscript.ConsensusScriptFlags
+	if *flagStr != "" {
+		flags, err = txscript.ParseVectorFlags(*flagStr)
+		if err != nil {
+			return err
+		}
+	}
+	stakeScript, ok := stakeScripts[*stake]
+	if *stake != "" && !ok {
+		return fmt.Errorf("unknown stake tag %q", *stake)
+	}
+	kc := txscript.NewTestKeychain([]byte(*seed))
+	keys, err := deriveKeys(kc, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// Create the script paying to the keys, which is either paid to by
+	// its address or used as is.
+	var addr dcrutil.Address
+	var script []byte
+	switch *class {
+	case "p2pkh", "p2pk":
+		if len(keys) != 1 {
+			return fmt.Errorf("%s scripts pay to a single key", *class)
+		}
+		if *class == "p2pkh" {
+			addr, err = keys[0].PubKeyHashAddress(params)
+		} else {
+			addr, err = keys[0].PubKeyAddress(params)
+		}
+	case "multisig":
+		pubKeys := make([]*dcrutil.AddressSecpPubKey, 0, len(keys))
+		for _, key := range keys {
+			if key.SigType != dcrec.STEcdsaSecp256k1 {
+				return fmt.Errorf("multisig key %s is not an "+
+					"ecdsa key", key.Name)
+			}
+			pubKey, err := dcrutil.NewAddressSecpPubKey(key.PubKey, params)
+			if err != nil {
+				return err
+			}
+			pubKeys = append(pubKeys, pubKey)
+		}
+		nrequired := *required
+		if nrequired == 0 {
+			nrequired = len(pubKeys)
+		}
+		script, err = txscript.MultiSigScript(pubKeys, nrequired)
+	default:
+		return fmt.Errorf("unknown script class %q", *class)
+	}
+	if err != nil {
+		return err
+	}
+	if *p2sh {
+		if script == nil {
+			script, err = txscript.PayToAddrScript(addr)
+			if err != nil {
+				return err
+			}
+		}
+		kc.AddScript(script)
+		addr, err = dcrutil.NewAddressScriptHash(script, params)
+		if err != nil {
+			return err
+		}
+		script = nil
+	}
+	switch {
+	case stakeScript != nil && addr == nil:
+		return fmt.Errorf("stake outputs can not pay to a bare %s "+
+			"script", *class)
+	case stakeScript != nil:
+		script, err = stakeScript(addr)
+	case script == nil:
+		script, err = txscript.PayToAddrScript(addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	fixture, err := kc.Fixture(params, script, *amount, hashType, flags)
+	if err != nil {
+		return err
+	}
+	vector, err := fixture.TxValidVector()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(vector)
+	if err != nil {
+		return err
+	}
+
+	// Report the transactions on stderr so the vector alone is written to
+	// stdout.
+	fundingTx, err := encodeTx(fixture.FundingTx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Funding tx: %s\n", fundingTx)
+	fmt.Fprintf(os.Stderr, "Public key script: %x\n", script)
+	if *comment != "" {
+		c, err := json.Marshal([]string{*comment})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s,\n", c)
+	}
+	fmt.Printf("%s,\n", b)
+	return nil
+}
@@ -1,46 +1,212 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
 )
 
+// errUsage is returned by commands when they are invoked with invalid
+// arguments.
+var errUsage = errors.New("invalid usage")
+
+// command describes one of the subcommands supported by the tool.
+type command struct {
+	name  string
+	args  string
+	descr string
+	run   func(args []string) error
+}
+
+// commands lists all of the supported subcommands.
+var commands = []command{
+	{"disasm", "[-net name] [hex-script]", "disassemble a script", cmdDisasm},
+	{"symexec", "[-version n] [hex-script]",
+		"list the conditions required to spend a script", cmdSymExec},
+	{"decompile", "[-version n] [hex-script]",
+		"render a script as pseudocode", cmdDecompile},
+	{"classify", "[-version n] [-templates file] [hex-script]",
+		"identify the script class and contract template", cmdClassify},
+	{"timelock", "[-version n] [-tx hex-tx] [-input n] [hex-script]",
+		"interpret the lock times enforced by a script", cmdTimeLock},
+	{"tx", "[-net name] [-agendas file] [hex-tx]", "decode a transaction",
+		cmdTx},
+	{"stake", "[-net name] [-agendas file] [hex-tx]",
+		"classify a stake transaction and validate its layout", cmdStake},
+	{"tspend-build", "[-net name] [-fee dcr] -expiry n [addr:dcr...]",
+		"create an unsigned treasury spend", cmdTSpendBuild},
+	{"tspend-sign", "-key file [hex-tx]",
+		"sign a treasury spend with a Pi key", cmdTSpendSign},
+	{"tspend-inspect", "[-net name] [-pikey hex] [hex-tx]",
+		"decode a treasury spend and verify its signature", cmdTSpendInspect},
+	{"run", "[-version n] [-txversion n] [-locktime n] [-expiry n] " +
+		"[-sequence n] [-amount atoms] [-input n] [-inputs n] " +
+		"[-outputs n] [-flags flags] [-namedkeys] [-trace] [hex-sigscript] " +
+		"[hex-pkscript]",
+		"execute scripts against a synthetic transaction", cmdRun},
+	{"repl", "[-sigscript hex-script]",
+		"execute opcodes interactively", cmdRepl},
+	{"test", "[-v] [spec-file...]",
+		"run the contract test scenarios of spec files", cmdTest},
+	{"vectors", "[-kind kind] [-filter regexp] [-v] [vectors-file...]",
+		"run reference test vector files", cmdVectors},
+	{"vector-record", "[-flags flags] [-comment text] [-shortform] " +
+		"[sigscript] [pkscript]",
+		"record a script execution as a reference test vector",
+		cmdVectorRecord},
+	{"sign", "[-net name] [-keys files] [-scripts files] [-hashtype type] " +
+		"[hex-tx] [index:hex-pkscript...]",
+		"sign inputs of a transaction with keys and scripts from files",
+		cmdSign},
+	{"ptx-create", "[-hashtype type] [hex-tx] " +
+		"[index:hex-pkscript[:hex-redeemscript]...]",
+		"create a partially signed transaction", cmdPtxCreate},
+	{"ptx-sign", "[-net name] [-keys files] [ptx-file]",
+		"add signatures to a partially signed transaction", cmdPtxSign},
+	{"ptx-combine", "[ptx-file...]",
+		"merge the signatures of partially signed transactions",
+		cmdPtxCombine},
+	{"ptx-finalize", "[-flags flags] [ptx-file]",
+		"verify and print the transaction of a partially signed one",
+		cmdPtxFinalize},
+	{"satisfy", "[-net name] [-keys files] [-preimages hex,...] " +
+		"[-branches bool,...] [-flags flags] [hex-tx] [index] " +
+		"[hex-pkscript] [hex-redeemscript]",
+		"build the signature script of an input spending any contract",
+		cmdSatisfy},
+	{"swap-contract", "[-net name] [-hash sha256|blake256] " +
+		"[-secret hex|-secret-hash hex] [-secret-size n] -locktime n " +
+		"[recipient-addr] [refund-addr]",
+		"build an atomic swap contract", cmdSwapContract},
+	{"swap-redeem", "[-net name] -keys files [-hashtype type] [hex-tx] " +
+		"[index] [hex-contract] [hex-secret]",
+		"redeem an atomic swap contract with its secret", cmdSwapRedeem},
+	{"swap-refund", "[-net name] -keys files [-hashtype type] [hex-tx] " +
+		"[index] [hex-contract]",
+		"refund an atomic swap contract after its lock time", cmdSwapRefund},
+	{"swap-secret", "[hex-sigscript | hex-tx index]",
+		"extract the secret from an atomic swap redeem", cmdSwapSecret},
+	{"swap-audit", "[-net name] [-hash sha256|blake256] -secret-hash hex " +
+		"[-secret-size n] -recipient addr [-refund addr] [-locktime n] " +
+		"[hex-contract] [hex-pkscript]",
+		"check an atomic swap contract of the counterparty", cmdSwapAudit},
+	{"multisig", "[-net name] [-required n hex-pubkey...] [hex-script]",
+		"build a sorted multisig script or analyze one", cmdMultiSig},
+	{"verify", "[-version n] [-flags flags] [-trace] [hex-tx] [index] " +
+		"[hex-pkscript]",
+		"verify an input of a transaction and explain signature checks",
+		cmdVerify},
+	{"checksig", "[-type type] [-hash hex-hash] [hex-sig] [hex-pubkey] " +
+		"[hex-tx index hex-subscript]",
+		"verify a signature against a hash or a transaction input",
+		cmdCheckSig},
+	{"keychain", "[-net name] [-seed text] [name[:type]...]",
+		"derive deterministic test keys from a seed", cmdKeychain},
+	{"fixture", "[-net name] [-seed text] [-class class] [-required n] " +
+		"[-p2sh] [-stake tag] [-amount atoms] [-hashtype type] " +
+		"[-flags flags] [-comment text] [name[:type]...]",
+		"create a signed spend of test keys as a reference test vector",
+		cmdFixture},
+	{"addr2script", "[-net name] [-stake tag] [address]",
+		"print the script paying to an address", cmdAddrToScript},
+	{"script2addr", "[-net name] [-version n] [hex-script]",
+		"print the addresses a script pays to", cmdScriptToAddr},
+}
+
 func exitUsage() {
-	fmt.Printf(("Usage: %s [hex-script]\n"), filepath.Base(os.Args[0]))
+	name := filepath.Base(os.Args[0])
+	fmt.Printf("Usage: %s [command] [args]\n\n", name)
+	fmt.Printf("Commands:\n")
+	for _, cmd := range commands {
+		fmt.Printf("  %s %s\n", cmd.name, cmd.args)
+		fmt.Printf("        %s\n", cmd.descr)
+	}
+	fmt.Printf("\nWhen no command is given, the arguments are passed to disasm.\n")
 	os.Exit(1)
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		exitUsage()
+// netParams returns the chain parameters of the network with the provided
+// name.
+func netParams(name string) (*chaincfg.Params, error) {
+	switch name {
+	case "mainnet":
+		return chaincfg.MainNetParams(), nil
+	case "testnet", "testnet3":
+		return chaincfg.TestNet3Params(), nil
+	case "simnet":
+		return chaincfg.SimNetParams(), nil
+	case "regnet":
+		return chaincfg.RegNetParams(), nil
 	}
+	return nil, fmt.Errorf("unknown network %q", name)
+}
 
-	version := uint16(0)
-	script, err := hex.DecodeString(os.Args[1])
+// decodeScript decodes a hex-encoded script provided on the command line.
+func decodeScript(s string) ([]byte, error) {
+	script, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
 	if err != nil {
-		exitUsage()
+		return nil, fmt.Errorf("invalid hex script: %v", err)
 	}
+	return script, nil
+}
 
-	compress := false
+// decodeTx decodes a hex-encoded serialized transaction provided on the command
+// line.
+func decodeTx(s string) (*wire.MsgTx, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex transaction: %v", err)
+	}
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	return tx, nil
+}
 
+// disasm returns the one-line disassembly of the provided script.  Scripts that
+// fail to parse are disassembled up to the point of failure.
+func disasm(script []byte) string {
 	var out strings.Builder
-	tkn := txscript.MakeScriptTokenizer(version, script)
+	tkn := txscript.MakeScriptTokenizer(0, script)
 	for tkn.Next() {
-		err := txscript.DisasmOpcode(&out, tkn.Opcode(), tkn.Data(), compress)
-		if err != nil {
-			fmt.Printf("Error disasming opcode: %v\n", err)
+		if out.Len() > 0 {
+			out.WriteString(" ")
 		}
-		out.WriteString(" ")
+		txscript.DisasmOpcode(&out, tkn.Opcode(), tkn.Data(), false)
 	}
-
 	if tkn.Err() != nil {
-		fmt.Printf("Error parsing script: %v\n", err)
+		out.WriteString(" [error]")
+	}
+	return out.String()
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		exitUsage()
 	}
 
-	fmt.Printf("Output:\n%s\n", out.String())
+	run, args := cmdDisasm, os.Args[1:]
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			run, args = cmd.run, os.Args[2:]
+			break
+		}
+	}
+
+	if err := run(args); err != nil {
+		if err == errUsage {
+			exitUsage()
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 }
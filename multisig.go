@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// cmdMultiSig builds a multisig redeem script with the provided public keys
+// sorted when -required is set and analyzes the provided multisig script
+// otherwise.  Both print the keys of the script, whether they are sorted or
+// duplicated and the pay-to-script-hash address of the script.
+func cmdMultiSig(args []string) error {
+	fs := flag.NewFlagSet("multisig", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the address")
+	required := fs.Int("required", 0, "number of required signatures of "+
+		"the script to build")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 ||
+		(*required == 0 && fs.NArg() != 1) {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+
+	var script []byte
+	if *required != 0 {
+		var pubKeys []*dcrutil.AddressSecpPubKey
+		for _, arg := range fs.Args() {
+			pubKey, err := decodeScript(arg)
+			if err != nil {
+				return err
+			}
+			addr, err := dcrutil.NewAddressSecpPubKey(pubKey, params)
+			if err != nil {
+				return fmt.Errorf("invalid public key %s: %v", arg, err)
+			}
+			pubKeys = append(pubKeys, addr)
+		}
+		script, err = txscript.SortedMultiSigScript(pubKeys, *required)
+		if err != nil {
+			return err
+		}
+	} else {
+		script, err = decodeScript(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+	}
+
+	analysis, err := txscript.AnalyzeMultiSigScript(script, params)
+	if err != nil {
+		return err
+	}
+	pkScript, err := txscript.PayToAddrScript(analysis.Address)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Required sigs: %d of %d\n", analysis.RequiredSigs,
+		len(analysis.PubKeys))
+	for i, key := range analysis.PubKeys {
+		encoding := "compressed"
+		if !key.Compressed {
+			encoding = "uncompressed"
+		}
+		if key.Duplicate {
+			encoding += ", duplicate"
+		}
+		fmt.Printf("  Key %d: %x (%s)\n", i, key.PubKey, encoding)
+	}
+	fmt.Printf("Sorted: %v\n", analysis.Sorted)
+	fmt.Printf("Duplicates: %v\n", analysis.Duplicates)
+	fmt.Printf("Redeem script: %x\n", script)
+	fmt.Printf("Address: %s\n", analysis.Address)
+	fmt.Printf("Output script: %x\n", pkScript)
+	return nil
+}
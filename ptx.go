@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// loadPartialTx reads a partially signed transaction from a JSON file.
+func loadPartialTx(path string) (*txscript.PartialTx, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ptx txscript.PartialTx
+	if err := json.Unmarshal(b, &ptx); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &ptx, nil
+}
+
+// printPartialTx prints a partially signed transaction as indented JSON.
+func printPartialTx(ptx *txscript.PartialTx) error {
+	b, err := json.MarshalIndent(ptx, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// cmdPtxCreate prints a partially signed transaction for the provided unsigned
+// transaction.  Each input is described as index:hex-pkscript with the script
+// of the output it spends, followed by :hex-redeemscript for
+// pay-to-script-hash outputs.
+func cmdPtxCreate(args []string) error {
+	fs := flag.NewFlagSet("ptx-create", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	hashTypeStr := fs.String("hashtype", "all", "signature hash type")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 2 {
+		return errUsage
+	}
+	hashType, err := parseSigHashType(*hashTypeStr)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ptx := txscript.NewPartialTx(tx)
+	for _, arg := range fs.Args()[1:] {
+		parts := strings.Split(arg, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return fmt.Errorf("input %q is not in the form "+
+				"index:hex-pkscript[:hex-redeemscript]", arg)
+		}
+		idx, err := strconv.Atoi(parts[0])
+		if err != nil || idx < 0 || idx >= len(tx.TxIn) {
+			return fmt.Errorf("invalid input index %q", parts[0])
+		}
+		in := &ptx.Inputs[idx]
+		in.HashType = hashType
+		in.PkScript, err = decodeScript(parts[1])
+		if err != nil {
+			return err
+		}
+		if len(parts) == 3 {
+			in.RedeemScript, err = decodeScript(parts[2])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return printPartialTx(ptx)
+}
+
+// cmdPtxSign adds the signatures of the private keys read from files to all
+// inputs of a partially signed transaction and prints the result.
+func cmdPtxSign(args []string) error {
+	fs := flag.NewFlagSet("ptx-sign", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys")
+	keyFiles := fs.String("keys", "", "comma-separated files with one WIF "+
+		"private key per line")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 ||
+		*keyFiles == "" {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	kdb, err := txscript.LoadKeyDB(params.PrivateKeyID,
+		splitList(*keyFiles)...)
+	if err != nil {
+		return err
+	}
+	ptx, err := loadPartialTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var signed int
+	for idx := range ptx.Inputs {
+		n, err := ptx.Sign(idx, kdb, params)
+		if err != nil {
+			return err
+		}
+		signed += n
+	}
+	if signed == 0 {
+		return fmt.Errorf("none of the keys sign the transaction")
+	}
+	return printPartialTx(ptx)
+}
+
+// cmdPtxCombine merges the signatures of copies of a partially signed
+// transaction signed by different co-signers and prints the result.
+func cmdPtxCombine(args []string) error {
+	fs := flag.NewFlagSet("ptx-combine", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse(args); err != nil || fs.NArg() < 2 {
+		return errUsage
+	}
+
+	ptx, err := loadPartialTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, path := range fs.Args()[1:] {
+		other, err := loadPartialTx(path)
+		if err != nil {
+			return err
+		}
+		if err := ptx.Combine(other); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return printPartialTx(ptx)
+}
+
+// cmdPtxFinalize prints the fully signed transaction of a partially signed
+// transaction once every input verifies.
+func cmdPtxFinalize(args []string) error {
+	fs := flag.NewFlagSet("ptx-finalize", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	flagStr := fs.String("flags", "standard", "script flags or presets "+
+		"used to verify the inputs")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	flags, err := txscript.ParseScriptFlags(*flagStr)
+	if err != nil {
+		return err
+	}
+	ptx, err := loadPartialTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tx, err := ptx.Finalize(flags)
+	if err != nil {
+		return err
+	}
+	txHex, err := encodeTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(txHex)
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// assembleScript returns the script formed by the provided tokens, which are
+// opcode names with or without the OP_ prefix, decimal numbers, hex data
+// prefixed by 0x or text data enclosed in single quotes.
+func assembleScript(tokens []string) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	for _, tok := range tokens {
+		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			builder.AddInt64(num)
+			continue
+		}
+		if strings.HasPrefix(tok, "0x") {
+			data, err := hex.DecodeString(tok[2:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex data %q: %v", tok, err)
+			}
+			builder.AddData(data)
+			continue
+		}
+		if len(tok) >= 2 && tok[0] == '\'' && tok[len(tok)-1] == '\'' {
+			builder.AddData([]byte(tok[1 : len(tok)-1]))
+			continue
+		}
+
+		name := strings.ToUpper(tok)
+		if !strings.HasPrefix(name, "OP_") {
+			name = "OP_" + name
+		}
+		op, ok := txscript.OpcodeByName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown opcode %q", tok)
+		}
+		builder.AddOp(op)
+	}
+	return builder.Script()
+}
+
+// replHelp describes the commands of the repl.
+const replHelp = `Enter opcodes (DUP or OP_DUP), numbers (42), hex data (0xab) or text
+data ('abc') to execute them.  Commands:
+  .load hex-script   clear and execute a signature script
+  .undo              revert the last line
+  .clear             revert all lines except the signature script
+  .flags [name...]   show or set the script flags or presets (none to
+                     clear them)
+  .script            disassemble the executed script
+  .sigchecks         show the details of the executed signature checks
+  .help              show this help
+  .quit              exit
+`
+
+// cmdRepl runs an interactive shell where opcodes are executed as they are
+// entered and the resulting stacks are printed.
+func cmdRepl(args []string) error {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	sigScriptHex := fs.String("sigscript", "", "hex signature script "+
+		"executed first")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	shell := txscript.NewScriptShell(txscript.ConsensusScriptFlags)
+	if *sigScriptHex != "" {
+		sigScript, err := decodeScript(*sigScriptHex)
+		if err != nil {
+			return err
+		}
+		if err := shell.LoadSigScript(sigScript); err != nil {
+			return err
+		}
+		printStack("Stack", shell.Stack())
+	}
+
+	fmt.Printf("Flags: %s\n", shell.Flags())
+	fmt.Printf("Type .help for help.\n")
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if shell.IsBranchExecuting() {
+			fmt.Printf("> ")
+		} else {
+			fmt.Printf("(not executing) > ")
+		}
+		if !scanner.Scan() {
+			fmt.Println()
+			return scanner.Err()
+		}
+		tokens := strings.Fields(scanner.Text())
+		if len(tokens) == 0 {
+			continue
+		}
+
+		var err error
+		switch tokens[0] {
+		case ".quit", ".exit":
+			return nil
+
+		case ".help":
+			fmt.Print(replHelp)
+			continue
+
+		case ".script":
+			fmt.Printf("Signature script: %s\n", disasm(shell.SigScript()))
+			fmt.Printf("Script: %s\n", disasm(shell.Script()))
+			continue
+
+		case ".sigchecks":
+			printSigChecks(shell.SigChecks(), nil)
+			continue
+
+		case ".flags":
+			if len(tokens) > 1 {
+				var flags txscript.ScriptFlags
+				flags, err = txscript.ParseScriptFlags(
+					strings.Join(tokens[1:], ","))
+				if err == nil {
+					err = shell.SetFlags(flags)
+				}
+			}
+			if err == nil {
+				fmt.Printf("Flags: %s\n", shell.Flags())
+			}
+
+		case ".undo":
+			if !shell.Undo() {
+				fmt.Printf("Nothing to undo\n")
+				continue
+			}
+
+		case ".clear":
+			shell.Clear()
+
+		case ".load":
+			if len(tokens) != 2 {
+				err = fmt.Errorf("usage: .load hex-script")
+				break
+			}
+			var sigScript []byte
+			sigScript, err = decodeScript(tokens[1])
+			if err == nil {
+				err = shell.LoadSigScript(sigScript)
+			}
+
+		default:
+			// Show the signature checks of the line, which are also
+			// recorded when it fails.
+			prev := shell.SigChecks()
+			var script []byte
+			script, err = assembleScript(tokens)
+			if err == nil {
+				err = shell.Exec(script)
+				printSigChecks(shell.SigChecks(), prev)
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		printStack("Stack", shell.Stack())
+		printStack("Alt stack", shell.AltStack())
+	}
+}
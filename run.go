@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// printStack prints the items of the provided stack from bottom to top along
+// with their interpretation as numbers and booleans.
+func printStack(name string, stack [][]byte) {
+	fmt.Printf("%s:", name)
+	if len(stack) == 0 {
+		fmt.Printf(" (empty)")
+	}
+	fmt.Println()
+	for i, data := range stack {
+		item := txscript.InterpretStackItem(data)
+		num := "not a number"
+		if item.NumErr == nil {
+			num = fmt.Sprintf("num %d", item.Num)
+		}
+		fmt.Printf("  %d: %x (%s, %v)\n", i, data, num, item.Bool)
+	}
+}
+
+// cmdRun executes the signature and public key scripts provided as hex against
+// a synthetic spending transaction and prints the final stacks and result.
+func cmdRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	version := fs.Uint("version", 0, "script version")
+	txVersion := fs.Uint("txversion", uint(wire.TxVersion),
+		"transaction version")
+	lockTime := fs.Uint("locktime", 0, "transaction lock time")
+	expiry := fs.Uint("expiry", 0, "transaction expiry")
+	sequence := fs.Uint("sequence", uint(wire.MaxTxInSequenceNum),
+		"sequence number of the spending input")
+	amount := fs.Int64("amount", 0, "atoms paid by the spent output")
+	inputIdx := fs.Int("input", 0, "index of the spending input")
+	numInputs := fs.Int("inputs", 0, "total number of inputs")
+	numOutputs := fs.Int("outputs", 1, "number of empty outputs (at least 1)")
+	flagStr := fs.String("flags", "consensus-current", "script flags or "+
+		"presets separated by commas")
+	namedKeys := fs.Bool("namedkeys", false, "consider signatures "+
+		"sig:<name> valid for public keys pk:<name>")
+	trace := fs.Bool("trace", false, "log the execution to stderr")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 ||
+		*numOutputs < 1 {
+
+		return errUsage
+	}
+	sigScript, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	pkScript, err := decodeScript(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	flags, err := txscript.ParseScriptFlags(*flagStr)
+	if err != nil {
+		return err
+	}
+
+	cfg := &txscript.SpendingTxConfig{
+		Version:    uint16(*txVersion),
+		LockTime:   uint32(*lockTime),
+		Expiry:     uint32(*expiry),
+		Sequence:   uint32(*sequence),
+		Amount:     *amount,
+		InputIndex: *inputIdx,
+		NumInputs:  *numInputs,
+	}
+	for i := 0; i < *numOutputs; i++ {
+		cfg.Outputs = append(cfg.Outputs, wire.NewTxOut(0, nil))
+	}
+	tx, err := txscript.CreateSpendingTx(sigScript, pkScript, cfg)
+	if err != nil {
+		return err
+	}
+
+	if *trace {
+		enableTrace()
+	}
+	vm, err := txscript.NewEngine(pkScript, tx, *inputIdx,
+		flags, uint16(*version), nil)
+	if err != nil {
+		return err
+	}
+	if *namedKeys {
+		vm.SetSigVerifier(txscript.NamedKeySigVerifier{})
+	}
+	var rec txscript.SigCheckRecorder
+	vm.SetSigCheckObserver(&rec)
+	if *version != 0 {
+		fmt.Printf("Result: OK (scripts of version %d are not executed)\n",
+			*version)
+		return nil
+	}
+
+	// Step through the scripts instead of using Execute so the final stacks
+	// are printed before the final stack item is popped to determine the
+	// result.
+	var done bool
+	for !done && err == nil {
+		done, err = vm.Step()
+	}
+	printStack("Stack", vm.GetStack())
+	printStack("Alt stack", vm.GetAltStack())
+	printSigChecks(&rec, nil)
+	if err == nil {
+		err = vm.CheckErrorCondition(true)
+	}
+	if err != nil {
+		fmt.Printf("Result: %v\n", err)
+		return nil
+	}
+	fmt.Printf("Result: OK\n")
+	return nil
+}
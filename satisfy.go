@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// cmdSatisfy builds the signature script of an input spending an arbitrary
+// contract from the private keys, hash preimages and branch choices provided,
+// then prints the resulting transaction.
+func cmdSatisfy(args []string) error {
+	fs := flag.NewFlagSet("satisfy", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys")
+	keyFiles := fs.String("keys", "", "comma-separated files with one WIF "+
+		"private key per line")
+	preimages := fs.String("preimages", "", "comma-separated hex hash "+
+		"preimages")
+	branches := fs.String("branches", "", "comma-separated outcomes "+
+		"(true or false) of the conditionals to take")
+	flagStr := fs.String("flags", "consensus-current", "script flags or "+
+		"presets separated by commas")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 3 ||
+		fs.NArg() > 4 {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	flags, err := txscript.ParseScriptFlags(*flagStr)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	idx, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("invalid input index %q", fs.Arg(1))
+	}
+	pkScript, err := decodeScript(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	var redeemScript []byte
+	if fs.NArg() == 4 {
+		redeemScript, err = decodeScript(fs.Arg(3))
+		if err != nil {
+			return err
+		}
+	}
+
+	s := txscript.NewSatisfier()
+	kdb, err := txscript.LoadKeyDB(params.PrivateKeyID,
+		splitList(*keyFiles)...)
+	if err != nil {
+		return err
+	}
+	for _, wif := range kdb.Keys() {
+		if err := s.AddKey(wif.PrivKey(), wif.DSA()); err != nil {
+			return err
+		}
+	}
+	for _, preimageHex := range splitList(*preimages) {
+		preimage, err := hex.DecodeString(preimageHex)
+		if err != nil {
+			return fmt.Errorf("invalid preimage %q: %v", preimageHex, err)
+		}
+		s.AddPreimage(preimage)
+	}
+	if *branches != "" {
+		var outcomes []bool
+		for _, branch := range strings.Split(*branches, ",") {
+			outcome, err := strconv.ParseBool(branch)
+			if err != nil {
+				return fmt.Errorf("invalid branch outcome %q", branch)
+			}
+			outcomes = append(outcomes, outcome)
+		}
+		s.SetBranches(outcomes)
+	}
+
+	sat, err := s.Satisfy(tx, idx, pkScript, redeemScript, flags)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Branches: %v\n", sat.Path.Branches)
+	fmt.Fprintf(os.Stderr, "Signature script: %s\n", disasm(sat.SigScript))
+	txHex, err := encodeTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(txHex)
+	return nil
+}
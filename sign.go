@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// parseSigHashType parses a signature hash type given as all, none or single
+// optionally followed by ,anyonecanpay.
+func parseSigHashType(s string) (txscript.SigHashType, error) {
+	parts := strings.Split(s, ",")
+	var hashType txscript.SigHashType
+	switch parts[0] {
+	case "all":
+		hashType = txscript.SigHashAll
+	case "none":
+		hashType = txscript.SigHashNone
+	case "single":
+		hashType = txscript.SigHashSingle
+	default:
+		return 0, fmt.Errorf("unknown signature hash type %q", s)
+	}
+	switch {
+	case len(parts) == 2 && parts[1] == "anyonecanpay":
+		hashType |= txscript.SigHashAnyOneCanPay
+	case len(parts) != 1:
+		return 0, fmt.Errorf("unknown signature hash type %q", s)
+	}
+	return hashType, nil
+}
+
+// splitList returns the elements of a comma-separated list, such as the paths
+// of files, and no elements for an empty list.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// cmdSign signs inputs of a raw transaction with the private keys and redeem
+// scripts read from files and prints the resulting transaction.  Each input to
+// sign is given as index:hex-pkscript with the script of the output it spends,
+// and existing signature scripts are merged with the new signatures so the
+// co-signers of multisig inputs can sign in turn.
+func cmdSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys and addresses")
+	keyFiles := fs.String("keys", "", "comma-separated files with one WIF "+
+		"private key per line")
+	scriptFiles := fs.String("scripts", "", "comma-separated files with "+
+		"one hex redeem script per line")
+	hashTypeStr := fs.String("hashtype", "all", "signature hash type")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 2 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	hashType, err := parseSigHashType(*hashTypeStr)
+	if err != nil {
+		return err
+	}
+	kdb, err := txscript.LoadKeyDB(params.PrivateKeyID,
+		splitList(*keyFiles)...)
+	if err != nil {
+		return err
+	}
+	sdb, err := txscript.LoadScriptDB(splitList(*scriptFiles)...)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	treasuryEnabled := txscript.ConsensusScriptFlags&txscript.ScriptVerifyTreasury != 0
+	for _, arg := range fs.Args()[1:] {
+		i := strings.Index(arg, ":")
+		if i < 0 {
+			return fmt.Errorf("input %q is not in the form "+
+				"index:hex-pkscript", arg)
+		}
+		idx, err := strconv.Atoi(arg[:i])
+		if err != nil || idx < 0 || idx >= len(tx.TxIn) {
+			return fmt.Errorf("invalid input index %q", arg[:i])
+		}
+		pkScript, err := decodeScript(arg[i+1:])
+		if err != nil {
+			return err
+		}
+
+		txIn := tx.TxIn[idx]
+		sigScript, err := txscript.SignTxOutput(params, tx, idx, pkScript,
+			hashType, kdb, sdb, txIn.SignatureScript, treasuryEnabled)
+		if err != nil {
+			return fmt.Errorf("input %d: %v", idx, err)
+		}
+		txIn.SignatureScript = sigScript
+
+		// Report whether the input is fully signed on stderr so the
+		// transaction alone is written to stdout.
+		vm, err := txscript.NewEngine(pkScript, tx, idx,
+			txscript.ConsensusScriptFlags, 0, nil)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "input %d: incomplete: %v\n", idx, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "input %d: complete\n", idx)
+		}
+	}
+
+	txHex, err := encodeTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(txHex)
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// cmdStake prints the type of the stake transaction provided as hex along with
+// the role and details of each of its outputs and whether its layout is valid.
+func cmdStake(args []string) error {
+	fs := flag.NewFlagSet("stake", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network used to decode addresses")
+	agendasFile := fs.String("agendas", "", "JSON file with vote agendas")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+
+	var agendas []chaincfg.Vote
+	if *agendasFile != "" {
+		agendas, err = loadAgendas(*agendasFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	treasuryEnabled := txscript.ConsensusScriptFlags&txscript.ScriptVerifyTreasury != 0
+	info := txscript.AnalyzeStakeTx(tx, params, treasuryEnabled)
+	fmt.Printf("Type: %v\n", info.Type)
+	if info.Type == txscript.TxTypeRegular {
+		return nil
+	}
+	if info.Err != nil {
+		fmt.Printf("Layout: %v\n", info.Err)
+	} else {
+		fmt.Printf("Layout: valid\n")
+	}
+
+	for i, out := range info.Outputs {
+		fmt.Printf("Output %d (%v): %v", i, out.Role, out.Amount)
+		for _, addr := range out.Addresses {
+			fmt.Printf(" to %v", addr)
+		}
+		fmt.Println()
+		if out.Commitment != nil {
+			fmt.Printf("  %s\n", describeCommitment(out.Commitment))
+		}
+	}
+
+	if info.BlockRef != nil && info.VoteBits != nil {
+		printVoteDetails(info.BlockRef, info.VoteBits, agendas)
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// parseSwapHashOp parses the name of the secret hash function of an atomic
+// swap contract.
+func parseSwapHashOp(name string) (byte, error) {
+	switch name {
+	case "sha256":
+		return txscript.OP_SHA256, nil
+	case "blake256":
+		return txscript.OP_BLAKE256, nil
+	}
+	return 0, fmt.Errorf("unknown secret hash function %q", name)
+}
+
+// parseSwapKeyHash returns the hash160 of the public key of a secp256k1
+// pay-to-pubkey-hash address, which is the only kind atomic swap contracts pay
+// to.
+func parseSwapKeyHash(s string, params *chaincfg.Params) ([20]byte, error) {
+	var hash [20]byte
+	addr, err := dcrutil.DecodeAddress(s, params)
+	if err != nil {
+		return hash, err
+	}
+	pkhAddr, ok := addr.(*dcrutil.AddressPubKeyHash)
+	if !ok || pkhAddr.DSA() != dcrec.STEcdsaSecp256k1 {
+		return hash, fmt.Errorf("address %s is not a secp256k1 "+
+			"pay-to-pubkey-hash address", s)
+	}
+	return *pkhAddr.Hash160(), nil
+}
+
+// parseSwapSecretHash decodes a hex-encoded 32-byte secret hash.
+func parseSwapSecretHash(s string) ([32]byte, error) {
+	var hash [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(hash) {
+		return hash, fmt.Errorf("invalid secret hash %q", s)
+	}
+	copy(hash[:], b)
+	return hash, nil
+}
+
+// verifySwapSpend sets the signature script of the input of a transaction
+// spending an atomic swap contract, verifies it with the engine and prints the
+// transaction.
+func verifySwapSpend(tx *wire.MsgTx, idx int, contract, sigScript []byte) error {
+	tx.TxIn[idx].SignatureScript = sigScript
+	pkScript, err := txscript.PayToScriptHashScript(dcrutil.Hash160(contract))
+	if err != nil {
+		return err
+	}
+	vm, err := txscript.NewEngine(pkScript, tx, idx,
+		txscript.StandardScriptFlags, 0, nil)
+	if err == nil {
+		err = vm.Execute()
+	}
+	if err != nil {
+		return fmt.Errorf("input %d does not verify: %v", idx, err)
+	}
+	txHex, err := encodeTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(txHex)
+	return nil
+}
+
+// swapSigningKey returns the private key of the pay-to-pubkey-hash address
+// with the provided hash160 from the key files.
+func swapSigningKey(keyFiles string, hash [20]byte, params *chaincfg.Params) ([]byte, error) {
+	kdb, err := txscript.LoadKeyDB(params.PrivateKeyID,
+		splitList(keyFiles)...)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := dcrutil.NewAddressPubKeyHash(hash[:], params,
+		dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	privKey, _, _, err := kdb.GetKey(addr)
+	return privKey, err
+}
+
+// parseSwapSpendArgs parses the transaction, input index and contract given to
+// the commands spending an atomic swap contract.
+func parseSwapSpendArgs(fs *flag.FlagSet) (*wire.MsgTx, int, *txscript.AtomicSwapContract, []byte, error) {
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	idx, err := strconv.Atoi(fs.Arg(1))
+	if err != nil || idx < 0 || idx >= len(tx.TxIn) {
+		return nil, 0, nil, nil, fmt.Errorf("invalid input index %q",
+			fs.Arg(1))
+	}
+	contract, err := decodeScript(fs.Arg(2))
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	c, err := txscript.ExtractContract(0, contract)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	swap, ok := c.(*txscript.AtomicSwapContract)
+	if !ok {
+		return nil, 0, nil, nil, fmt.Errorf("script is not an atomic " +
+			"swap contract")
+	}
+	return tx, idx, swap, contract, nil
+}
+
+// cmdSwapContract builds an atomic swap contract paying to the recipient with
+// the secret or refunding after the lock time.  The secret is generated unless
+// it or its hash, when the counterparty holds the secret, is provided.
+func cmdSwapContract(args []string) error {
+	fs := flag.NewFlagSet("swap-contract", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the addresses")
+	hashName := fs.String("hash", "sha256", "secret hash function "+
+		"(sha256 or blake256)")
+	secretHex := fs.String("secret", "", "hex secret")
+	secretHashHex := fs.String("secret-hash", "", "hex hash of a secret "+
+		"held by the counterparty")
+	secretSize := fs.Int64("secret-size", 32, "size of the secret")
+	lockTime := fs.Int64("locktime", 0, "block height or unix timestamp "+
+		"after which the contract can be refunded")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 ||
+		*lockTime == 0 || (*secretHex != "" && *secretHashHex != "") {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	hashOp, err := parseSwapHashOp(*hashName)
+	if err != nil {
+		return err
+	}
+	c := &txscript.AtomicSwapContract{
+		HashOp:     hashOp,
+		SecretSize: *secretSize,
+		LockTime:   *lockTime,
+	}
+	c.RecipientHash160, err = parseSwapKeyHash(fs.Arg(0), params)
+	if err != nil {
+		return err
+	}
+	c.RefundHash160, err = parseSwapKeyHash(fs.Arg(1), params)
+	if err != nil {
+		return err
+	}
+
+	var secret []byte
+	switch {
+	case *secretHashHex != "":
+		c.SecretHash, err = parseSwapSecretHash(*secretHashHex)
+		if err != nil {
+			return err
+		}
+	case *secretHex != "":
+		secret, err = hex.DecodeString(*secretHex)
+		if err != nil {
+			return fmt.Errorf("invalid secret: %v", err)
+		}
+		c.SecretSize = int64(len(secret))
+	default:
+		if c.SecretSize <= 0 || c.SecretSize > txscript.MaxScriptElementSize {
+			return fmt.Errorf("invalid secret size %d", c.SecretSize)
+		}
+		secret = make([]byte, c.SecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+	}
+	if secret != nil {
+		c.SecretHash, err = txscript.AtomicSwapSecretHash(hashOp, secret)
+		if err != nil {
+			return err
+		}
+	}
+
+	contract, err := txscript.AtomicSwapContractScript(c)
+	if err != nil {
+		return err
+	}
+	p2shAddr, err := dcrutil.NewAddressScriptHash(contract, params)
+	if err != nil {
+		return err
+	}
+	pkScript, err := txscript.PayToAddrScript(p2shAddr)
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		fmt.Printf("Secret: %x\n", secret)
+	}
+	fmt.Printf("Secret hash: %x\n", c.SecretHash[:])
+	fmt.Printf("Contract: %x\n", contract)
+	fmt.Printf("Contract address: %s\n", p2shAddr)
+	fmt.Printf("Output script: %x\n", pkScript)
+	return nil
+}
+
+// cmdSwapRedeem signs the input of a transaction spending an atomic swap
+// contract with the secret and the key of the recipient, verifies it and
+// prints the transaction.
+func cmdSwapRedeem(args []string) error {
+	fs := flag.NewFlagSet("swap-redeem", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys")
+	keyFiles := fs.String("keys", "", "comma-separated files with one WIF "+
+		"private key per line")
+	hashTypeStr := fs.String("hashtype", "all", "signature hash type")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 4 ||
+		*keyFiles == "" {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	hashType, err := parseSigHashType(*hashTypeStr)
+	if err != nil {
+		return err
+	}
+	tx, idx, swap, contract, err := parseSwapSpendArgs(fs)
+	if err != nil {
+		return err
+	}
+	secret, err := hex.DecodeString(fs.Arg(3))
+	if err != nil {
+		return fmt.Errorf("invalid secret: %v", err)
+	}
+	privKey, err := swapSigningKey(*keyFiles, swap.RecipientHash160, params)
+	if err != nil {
+		return err
+	}
+
+	sigScript, err := txscript.AtomicSwapRedeemSigScript(tx, idx, contract,
+		hashType, privKey, secret)
+	if err != nil {
+		return err
+	}
+	return verifySwapSpend(tx, idx, contract, sigScript)
+}
+
+// cmdSwapRefund signs the input of a transaction spending an atomic swap
+// contract with the key of the refund address, verifies it and prints the
+// transaction.  The lock time of the transaction and the sequence number of
+// the input are adjusted to the lock time of the contract when needed.
+func cmdSwapRefund(args []string) error {
+	fs := flag.NewFlagSet("swap-refund", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the keys")
+	keyFiles := fs.String("keys", "", "comma-separated files with one WIF "+
+		"private key per line")
+	hashTypeStr := fs.String("hashtype", "all", "signature hash type")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 3 ||
+		*keyFiles == "" {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	hashType, err := parseSigHashType(*hashTypeStr)
+	if err != nil {
+		return err
+	}
+	tx, idx, swap, contract, err := parseSwapSpendArgs(fs)
+	if err != nil {
+		return err
+	}
+	privKey, err := swapSigningKey(*keyFiles, swap.RefundHash160, params)
+	if err != nil {
+		return err
+	}
+
+	if int64(tx.LockTime) < swap.LockTime {
+		tx.LockTime = uint32(swap.LockTime)
+	}
+	if tx.TxIn[idx].Sequence == wire.MaxTxInSequenceNum {
+		tx.TxIn[idx].Sequence = wire.MaxTxInSequenceNum - 1
+	}
+	sigScript, err := txscript.AtomicSwapRefundSigScript(tx, idx, contract,
+		hashType, privKey)
+	if err != nil {
+		return err
+	}
+	return verifySwapSpend(tx, idx, contract, sigScript)
+}
+
+// cmdSwapSecret prints the secret revealed by the signature script of a
+// transaction input redeeming an atomic swap contract.  The signature script
+// is given either directly or as a transaction and the index of the input.
+func cmdSwapSecret(args []string) error {
+	fs := flag.NewFlagSet("swap-secret", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 || fs.NArg() > 2 {
+		return errUsage
+	}
+	var sigScript []byte
+	if fs.NArg() == 2 {
+		tx, err := decodeTx(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		idx, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || idx < 0 || idx >= len(tx.TxIn) {
+			return fmt.Errorf("invalid input index %q", fs.Arg(1))
+		}
+		sigScript = tx.TxIn[idx].SignatureScript
+	} else {
+		var err error
+		sigScript, err = decodeScript(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+	}
+	secret, err := txscript.ExtractAtomicSwapSecret(0, sigScript)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%x\n", secret)
+	return nil
+}
+
+// cmdSwapAudit checks an atomic swap contract created by the counterparty and,
+// optionally, the output paying to it against the expected parameters.
+func cmdSwapAudit(args []string) error {
+	fs := flag.NewFlagSet("swap-audit", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network of the addresses")
+	hashName := fs.String("hash", "sha256", "secret hash function "+
+		"(sha256 or blake256)")
+	secretHashHex := fs.String("secret-hash", "", "hex secret hash")
+	secretSize := fs.Int64("secret-size", 32, "size of the secret")
+	recipient := fs.String("recipient", "", "address the contract must "+
+		"pay to with the secret")
+	refund := fs.String("refund", "", "address the contract must refund to")
+	lockTime := fs.Int64("locktime", 0, "earliest accepted refund lock time")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 ||
+		fs.NArg() > 2 || *secretHashHex == "" || *recipient == "" {
+
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	expected := &txscript.AtomicSwapContract{
+		SecretSize: *secretSize,
+		LockTime:   *lockTime,
+	}
+	expected.HashOp, err = parseSwapHashOp(*hashName)
+	if err != nil {
+		return err
+	}
+	expected.SecretHash, err = parseSwapSecretHash(*secretHashHex)
+	if err != nil {
+		return err
+	}
+	expected.RecipientHash160, err = parseSwapKeyHash(*recipient, params)
+	if err != nil {
+		return err
+	}
+	if *refund != "" {
+		expected.RefundHash160, err = parseSwapKeyHash(*refund, params)
+		if err != nil {
+			return err
+		}
+	}
+	contract, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var pkScript []byte
+	if fs.NArg() == 2 {
+		pkScript, err = decodeScript(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+	}
+
+	swap, err := txscript.AuditAtomicSwap(pkScript, contract, expected)
+	if err != nil {
+		return err
+	}
+	printContract(swap)
+	fmt.Fprintln(os.Stderr, "Contract matches the expected parameters")
+	return nil
+}
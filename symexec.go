@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+// cmdSymExec symbolically executes the script provided as hex and prints the
+// conditions required by each of its execution paths.
+func cmdSymExec(args []string) error {
+	fs := flag.NewFlagSet("symexec", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	version := fs.Uint("version", 0, "script version")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	script, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	paths, err := txscript.SymbolicExecute(script, uint16(*version),
+		txscript.ConsensusScriptFlags)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Script: %s\n", disasm(script))
+	fmt.Printf("Signature script items are named x0, x1, ... in push order.\n")
+	for i, path := range paths {
+		fmt.Printf("\nPath %d", i+1)
+		if len(path.Branches) > 0 {
+			fmt.Printf(" (branches: %v)", path.Branches)
+		}
+		fmt.Printf("\n")
+		fmt.Printf("  sigScript items: %d\n", path.NumInputs)
+		for _, c := range path.Constraints {
+			fmt.Printf("  require %s\n", c)
+		}
+		if path.Err != nil {
+			fmt.Printf("  result: never succeeds: %v\n", path.Err)
+		} else {
+			fmt.Printf("  result: succeeds when all requirements hold\n")
+		}
+	}
+	return nil
+}
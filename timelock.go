@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// cmdTimeLock prints the interpretation of every lock time enforced by the
+// script provided as hex.  When a transaction is provided, it also reports
+// whether the given input of the transaction satisfies each of them.
+func cmdTimeLock(args []string) error {
+	fs := flag.NewFlagSet("timelock", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	version := fs.Uint("version", 0, "script version")
+	txHex := fs.String("tx", "", "spending transaction")
+	txIdx := fs.Int("input", 0, "index of the spending transaction input")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	script, err := decodeScript(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var tx *wire.MsgTx
+	if *txHex != "" {
+		tx, err = decodeTx(*txHex)
+		if err != nil {
+			return err
+		}
+	}
+
+	reqs, err := txscript.ExtractLockTimes(uint16(*version), script)
+	if err != nil {
+		return err
+	}
+	if len(reqs) == 0 {
+		fmt.Println("No lock times")
+		return nil
+	}
+
+	for _, req := range reqs {
+		fmt.Printf("%s at offset %d: %v\n", disasm([]byte{req.Opcode}),
+			req.Offset, &req)
+		if tx == nil {
+			continue
+		}
+		if err := req.Check(tx, *txIdx); err != nil {
+			fmt.Printf("  not satisfied: %v\n", err)
+			continue
+		}
+		fmt.Printf("  satisfied\n")
+	}
+	return nil
+}
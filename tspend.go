@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// parseTSpendPayout parses a treasury spend payout in the form address:amount
+// where the amount is expressed in DCR.
+func parseTSpendPayout(s string, params dcrutil.AddressParams) (txscript.TSpendPayout, error) {
+	var payout txscript.TSpendPayout
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return payout, fmt.Errorf("payout %q is not in the form "+
+			"address:amount", s)
+	}
+	addr, err := dcrutil.DecodeAddress(s[:i], params)
+	if err != nil {
+		return payout, fmt.Errorf("invalid payout address %q: %v", s[:i],
+			err)
+	}
+	amount, err := parseAmount(s[i+1:])
+	if err != nil {
+		return payout, err
+	}
+	payout.Address, payout.Amount = addr, amount
+	return payout, nil
+}
+
+// parseAmount parses an amount expressed in DCR.
+func parseAmount(s string) (dcrutil.Amount, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", s, err)
+	}
+	amount, err := dcrutil.NewAmount(f)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", s, err)
+	}
+	return amount, nil
+}
+
+// encodeTx returns the hex encoding of the serialized transaction.
+func encodeTx(tx *wire.MsgTx) (string, error) {
+	b, err := tx.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// cmdTSpendBuild prints an unsigned treasury spend transaction paying the
+// payouts provided as address:amount arguments.
+func cmdTSpendBuild(args []string) error {
+	fs := flag.NewFlagSet("tspend-build", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "simnet", "network used to decode addresses")
+	expiry := fs.Uint("expiry", 0, "expiry height of the transaction")
+	fee := fs.String("fee", "0", "fee paid by the transaction in DCR")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 || *expiry == 0 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	feeAmount, err := parseAmount(*fee)
+	if err != nil {
+		return err
+	}
+
+	payouts := make([]txscript.TSpendPayout, 0, fs.NArg())
+	for _, arg := range fs.Args() {
+		payout, err := parseTSpendPayout(arg, params)
+		if err != nil {
+			return err
+		}
+		payouts = append(payouts, payout)
+	}
+
+	tx, err := txscript.NewTSpendTx(payouts, feeAmount, uint32(*expiry))
+	if err != nil {
+		return err
+	}
+	txHex, err := encodeTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(txHex)
+	return nil
+}
+
+// cmdTSpendSign prints the treasury spend transaction provided as hex signed
+// by the Pi key read from a local file.
+func cmdTSpendSign(args []string) error {
+	fs := flag.NewFlagSet("tspend-sign", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	keyFile := fs.String("key", "", "file with the hex-encoded Pi private key")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *keyFile == "" {
+		return errUsage
+	}
+
+	keyHex, err := ioutil.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	privKey, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil || len(privKey) != 32 {
+		return fmt.Errorf("%s does not contain a hex-encoded 32-byte "+
+			"private key", *keyFile)
+	}
+
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(tx.TxIn) != 1 {
+		return fmt.Errorf("treasury spend has %d inputs instead of 1",
+			len(tx.TxIn))
+	}
+	sigScript, err := txscript.TSpendSignatureScript(tx, privKey)
+	if err != nil {
+		return err
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+
+	txHex, err := encodeTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(txHex)
+	return nil
+}
+
+// cmdTSpendInspect prints the value, payouts and signer of the treasury spend
+// transaction provided as hex and whether its signature is valid.
+func cmdTSpendInspect(args []string) error {
+	fs := flag.NewFlagSet("tspend-inspect", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "simnet", "network used to decode addresses")
+	piKey := fs.String("pikey", "", "hex-encoded Pi public key expected to "+
+		"sign the transaction")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+	var wantPubKey []byte
+	if *piKey != "" {
+		wantPubKey, err = hex.DecodeString(*piKey)
+		if err != nil {
+			return fmt.Errorf("invalid hex Pi key: %v", err)
+		}
+	}
+
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	details, err := txscript.ExtractTSpendDetails(tx, params)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Hash: %v\n", tx.TxHash())
+	fmt.Printf("Version: %d\n", tx.Version)
+	fmt.Printf("Expiry: %d\n", tx.Expiry)
+	fmt.Printf("Value: %v\n", details.Value)
+	fmt.Printf("Fee: %v\n", details.Fee())
+	for i, payout := range details.Payouts {
+		fmt.Printf("Payout %d: %v to %v\n", i, payout.Amount, payout.Address)
+	}
+
+	if details.PubKey == nil {
+		fmt.Printf("Signature: none\n")
+		return nil
+	}
+	fmt.Printf("Signer: %x\n", details.PubKey)
+	if err := txscript.VerifyTSpendSignature(tx); err != nil {
+		fmt.Printf("Signature: %v\n", err)
+	} else {
+		fmt.Printf("Signature: valid\n")
+	}
+	if wantPubKey != nil {
+		if bytes.Equal(details.PubKey, wantPubKey) {
+			fmt.Printf("Pi key: matches\n")
+		} else {
+			fmt.Printf("Pi key: does not match %x\n", wantPubKey)
+		}
+	}
+	if tx.Version != txscript.TSpendTxVersion {
+		fmt.Printf("Warning: version %d is not the treasury spend version "+
+			"%d\n", tx.Version, txscript.TSpendTxVersion)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// describeFeeLimit returns a description of a ticket commitment fee limit.
+func describeFeeLimit(limit dcrutil.Amount, ok bool) string {
+	if !ok {
+		return "none"
+	}
+	return limit.String()
+}
+
+// describeCommitment returns a description of a decoded ticket commitment.
+func describeCommitment(c *txscript.SStxCommitment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ticket commitment to %v of %v", c.Address, c.Amount)
+	fmt.Fprintf(&b, " (vote fee limit %s,",
+		describeFeeLimit(c.VoteFeeLimit()))
+	fmt.Fprintf(&b, " revocation fee limit %s)",
+		describeFeeLimit(c.RevocationFeeLimit()))
+	return b.String()
+}
+
+// printVote prints the block reference and vote bits of the provided
+// transaction when its first two outputs are of the form used by votes.
+func printVote(tx *wire.MsgTx, agendas []chaincfg.Vote) {
+	if len(tx.TxOut) < 2 {
+		return
+	}
+	ref := txscript.ExtractSSGenBlockRef(tx.TxOut[0].Version,
+		tx.TxOut[0].PkScript)
+	vb := txscript.ExtractSSGenVoteBits(tx.TxOut[1].Version,
+		tx.TxOut[1].PkScript)
+	if ref == nil || vb == nil {
+		return
+	}
+	printVoteDetails(ref, vb, agendas)
+}
+
+// printVoteDetails prints the voted block and the interpretation of the vote
+// bits of a vote.
+func printVoteDetails(ref *txscript.SSGenBlockRef, vb *txscript.SSGenVoteBits, agendas []chaincfg.Vote) {
+	fmt.Printf("Vote on block %v (height %d)\n", ref.Hash, ref.Height)
+	fmt.Printf("  Vote bits: 0x%04x\n", vb.Bits)
+	for _, line := range describeVoteBits(vb, agendas) {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// cmdTx prints the decoded form of the transaction provided as hex.
+func cmdTx(args []string) error {
+	fs := flag.NewFlagSet("tx", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	net := fs.String("net", "mainnet", "network used to decode addresses")
+	agendasFile := fs.String("agendas", "", "JSON file with vote agendas")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	params, err := netParams(*net)
+	if err != nil {
+		return err
+	}
+
+	var agendas []chaincfg.Vote
+	if *agendasFile != "" {
+		agendas, err = loadAgendas(*agendasFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Hash: %v\n", tx.TxHash())
+	fmt.Printf("Version: %d\n", tx.Version)
+	fmt.Printf("Lock time: %d\n", tx.LockTime)
+	fmt.Printf("Expiry: %d\n", tx.Expiry)
+
+	for i, txIn := range tx.TxIn {
+		fmt.Printf("Input %d: %v (tree %d)\n", i, txIn.PreviousOutPoint.Hash,
+			txIn.PreviousOutPoint.Tree)
+		fmt.Printf("  Index: %d\n", txIn.PreviousOutPoint.Index)
+		fmt.Printf("  Sequence: %d\n", txIn.Sequence)
+		fmt.Printf("  Value in: %v\n", dcrutil.Amount(txIn.ValueIn))
+		fmt.Printf("  Signature script: %s\n", disasm(txIn.SignatureScript))
+	}
+
+	// Only tickets carry commitments, so null data outputs of other
+	// transactions are not decoded as such.
+	treasuryEnabled := txscript.ConsensusScriptFlags&txscript.ScriptVerifyTreasury != 0
+	isTicket := txscript.AnalyzeStakeTx(tx, params, treasuryEnabled).Type ==
+		txscript.TxTypeTicket
+
+	for i, txOut := range tx.TxOut {
+		fmt.Printf("Output %d: %v\n", i, dcrutil.Amount(txOut.Value))
+		fmt.Printf("  Script version: %d\n", txOut.Version)
+		fmt.Printf("  Script: %s\n", disasm(txOut.PkScript))
+		if !isTicket {
+			continue
+		}
+		commitment, err := txscript.ExtractSStxCommitment(txOut.Version,
+			txOut.PkScript, params)
+		if err != nil {
+			return err
+		}
+		if commitment != nil {
+			fmt.Printf("  Decoded: %s\n", describeCommitment(commitment))
+		}
+	}
+
+	printVote(tx, agendas)
+	return nil
+}
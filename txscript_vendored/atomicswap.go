@@ -0,0 +1,281 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// AtomicSwapSecretHash returns the hash of the secret of an atomic swap
+// contract computed with the hash function of the provided opcode, which must
+// be either OP_SHA256 or OP_BLAKE256.
+func AtomicSwapSecretHash(hashOp byte, secret []byte) ([32]byte, error) {
+	switch hashOp {
+	case OP_SHA256:
+		return sha256.Sum256(secret), nil
+	case OP_BLAKE256:
+		return chainhash.HashH(secret), nil
+	}
+	str := fmt.Sprintf("unsupported atomic swap secret hash opcode %s",
+		opcodeArray[hashOp].name)
+	return [32]byte{}, scriptError(ErrInvalidAtomicSwap, str)
+}
+
+// AtomicSwapContractScript returns the atomic swap contract script for the
+// provided parameters.  See AtomicSwapContract for the form of the script.
+//
+// The secret hash must be computed with OP_SHA256 or OP_BLAKE256, the secret
+// size must be positive and the lock time, which is either a block height or a
+// unix timestamp depending on whether it is below LockTimeThreshold, must be
+// positive.  The contract is not a standard script type and is meant to be
+// used as the redeem script of a pay-to-script-hash output.
+func AtomicSwapContractScript(c *AtomicSwapContract) ([]byte, error) {
+	if c.HashOp != OP_SHA256 && c.HashOp != OP_BLAKE256 {
+		str := fmt.Sprintf("unsupported atomic swap secret hash opcode %s",
+			opcodeArray[c.HashOp].name)
+		return nil, scriptError(ErrInvalidAtomicSwap, str)
+	}
+	if c.SecretSize <= 0 || c.SecretSize > MaxScriptElementSize {
+		str := fmt.Sprintf("invalid atomic swap secret size %d",
+			c.SecretSize)
+		return nil, scriptError(ErrInvalidAtomicSwap, str)
+	}
+	if c.LockTime <= 0 || c.LockTime > int64(^uint32(0)) {
+		str := fmt.Sprintf("invalid atomic swap lock time %d", c.LockTime)
+		return nil, scriptError(ErrInvalidAtomicSwap, str)
+	}
+
+	return NewScriptBuilder().
+		AddOp(OP_IF).
+		AddOp(OP_SIZE).AddInt64(c.SecretSize).AddOp(OP_EQUALVERIFY).
+		AddOp(c.HashOp).AddData(c.SecretHash[:]).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_DUP).AddOp(OP_HASH160).AddData(c.RecipientHash160[:]).
+		AddOp(OP_ELSE).
+		AddInt64(c.LockTime).AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddOp(OP_DUP).AddOp(OP_HASH160).AddData(c.RefundHash160[:]).
+		AddOp(OP_ENDIF).
+		AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).
+		Script()
+}
+
+// extractAtomicSwap returns the parameters of the provided atomic swap
+// contract script or an error of kind ErrInvalidAtomicSwap when the script is
+// not one.
+func extractAtomicSwap(contract []byte) (*AtomicSwapContract, error) {
+	c, err := ExtractContract(0, contract)
+	if err != nil {
+		return nil, err
+	}
+	swap, ok := c.(*AtomicSwapContract)
+	if !ok {
+		str := "script is not an atomic swap contract"
+		return nil, scriptError(ErrInvalidAtomicSwap, str)
+	}
+	return swap, nil
+}
+
+// atomicSwapSignature returns the signature of the input idx of the provided
+// transaction spending an atomic swap contract and the compressed public key
+// of the secp256k1 private key that made it.
+func atomicSwapSignature(tx *wire.MsgTx, idx int, contract []byte,
+	hashType SigHashType, privKey []byte) ([]byte, []byte, error) {
+
+	if idx < 0 || idx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", idx, len(tx.TxIn))
+		return nil, nil, scriptError(ErrInvalidIndex, str)
+	}
+	sig, err := RawTxInSignature(tx, idx, contract, hashType, privKey,
+		dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubKey := secp256k1.PrivKeyFromBytes(privKey).PubKey().SerializeCompressed()
+	return sig, pubKey, nil
+}
+
+// AtomicSwapRedeemSigScript returns the signature script of the input idx of
+// the provided transaction which redeems an atomic swap contract, paid to a
+// pay-to-script-hash output, with its secret and the secp256k1 private key of
+// the recipient.  The script is of the form:
+//
+//  <sig> <pubkey> <secret> TRUE <contract>
+func AtomicSwapRedeemSigScript(tx *wire.MsgTx, idx int, contract []byte,
+	hashType SigHashType, privKey, secret []byte) ([]byte, error) {
+
+	swap, err := extractAtomicSwap(contract)
+	if err != nil {
+		return nil, err
+	}
+	secretHash, err := AtomicSwapSecretHash(swap.HashOp, secret)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(secret)) != swap.SecretSize || secretHash != swap.SecretHash {
+		str := "secret does not match the hash of the atomic swap contract"
+		return nil, scriptError(ErrInvalidAtomicSwap, str)
+	}
+
+	sig, pubKey, err := atomicSwapSignature(tx, idx, contract, hashType,
+		privKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewScriptBuilder().AddData(sig).AddData(pubKey).AddData(secret).
+		AddInt64(1).AddData(contract).Script()
+}
+
+// AtomicSwapRefundSigScript returns the signature script of the input idx of
+// the provided transaction which refunds an atomic swap contract, paid to a
+// pay-to-script-hash output, with the secp256k1 private key of the refund
+// address.  The script is of the form:
+//
+//  <sig> <pubkey> FALSE <contract>
+//
+// The lock time of the transaction must be set to at least the lock time of
+// the contract and the sequence number of the input must not be the maximum
+// one prior to calling this function since both are committed to by the
+// signature.
+func AtomicSwapRefundSigScript(tx *wire.MsgTx, idx int, contract []byte,
+	hashType SigHashType, privKey []byte) ([]byte, error) {
+
+	if _, err := extractAtomicSwap(contract); err != nil {
+		return nil, err
+	}
+	sig, pubKey, err := atomicSwapSignature(tx, idx, contract, hashType,
+		privKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewScriptBuilder().AddData(sig).AddData(pubKey).AddInt64(0).
+		AddData(contract).Script()
+}
+
+// ExtractAtomicSwapSecret returns the secret revealed by a signature script
+// redeeming an atomic swap contract as created by AtomicSwapRedeemSigScript.
+// The secret is checked against the size and hash committed to by the
+// contract.  An error of kind ErrNotAtomicSwapRedeem is returned when the
+// signature script does not redeem an atomic swap contract with its secret,
+// such as when it refunds it instead.
+func ExtractAtomicSwapSecret(scriptVersion uint16, sigScript []byte) ([]byte, error) {
+	if scriptVersion != 0 {
+		str := fmt.Sprintf("unsupported script version %d", scriptVersion)
+		return nil, scriptError(ErrNotAtomicSwapRedeem, str)
+	}
+
+	var pushes [][]byte
+	var ops []byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, sigScript)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		if op > OP_16 {
+			str := fmt.Sprintf("signature script is not push only: %s",
+				opcodeArray[op].name)
+			return nil, scriptError(ErrNotAtomicSwapRedeem, str)
+		}
+		pushes = append(pushes, tokenizer.Data())
+		ops = append(ops, op)
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+	if len(pushes) != 5 || ops[3] != OP_TRUE {
+		str := "signature script does not redeem an atomic swap contract"
+		return nil, scriptError(ErrNotAtomicSwapRedeem, str)
+	}
+
+	swap, err := extractAtomicSwap(pushes[4])
+	if err != nil {
+		str := "signature script does not redeem an atomic swap contract"
+		return nil, scriptError(ErrNotAtomicSwapRedeem, str)
+	}
+	secret := pushes[2]
+	secretHash, err := AtomicSwapSecretHash(swap.HashOp, secret)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(secret)) != swap.SecretSize || secretHash != swap.SecretHash {
+		str := "secret does not match the hash of the atomic swap contract"
+		return nil, scriptError(ErrNotAtomicSwapRedeem, str)
+	}
+	return secret, nil
+}
+
+// AuditAtomicSwap checks a contract created by the counterparty of an atomic
+// swap against the expected parameters and returns the parameters of the
+// contract.  The secret hash opcode, secret hash, secret size and recipient
+// must match exactly.  The refund hash is only checked when the expected one
+// is not zero.  When the expected lock time is not zero, the lock time of the
+// contract must be of the same kind, block height or timestamp, and at least
+// the expected one, so the counterparty cannot refund before the expected
+// time.
+//
+// When pkScript is not nil it must be the pay-to-script-hash script of the
+// contract.  An error of kind ErrAtomicSwapMismatch is returned when any of
+// the checks fail.
+func AuditAtomicSwap(pkScript, contract []byte, expected *AtomicSwapContract) (*AtomicSwapContract, error) {
+	swap, err := extractAtomicSwap(contract)
+	if err != nil {
+		return nil, scriptError(ErrAtomicSwapMismatch, err.Error())
+	}
+	if pkScript != nil {
+		want, err := PayToScriptHashScript(dcrutil.Hash160(contract))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pkScript, want) {
+			str := "output script does not pay to the contract"
+			return nil, scriptError(ErrAtomicSwapMismatch, str)
+		}
+	}
+
+	mismatch := func(field string, got, want interface{}) error {
+		str := fmt.Sprintf("unexpected atomic swap %s -- got %v, want %v",
+			field, got, want)
+		return scriptError(ErrAtomicSwapMismatch, str)
+	}
+	switch {
+	case swap.HashOp != expected.HashOp:
+		return nil, mismatch("secret hash opcode",
+			opcodeArray[swap.HashOp].name,
+			opcodeArray[expected.HashOp].name)
+	case swap.SecretHash != expected.SecretHash:
+		return nil, mismatch("secret hash",
+			fmt.Sprintf("%x", swap.SecretHash),
+			fmt.Sprintf("%x", expected.SecretHash))
+	case swap.SecretSize != expected.SecretSize:
+		return nil, mismatch("secret size", swap.SecretSize,
+			expected.SecretSize)
+	case swap.RecipientHash160 != expected.RecipientHash160:
+		return nil, mismatch("recipient",
+			fmt.Sprintf("%x", swap.RecipientHash160),
+			fmt.Sprintf("%x", expected.RecipientHash160))
+	case expected.RefundHash160 != [20]byte{} &&
+		swap.RefundHash160 != expected.RefundHash160:
+
+		return nil, mismatch("refund",
+			fmt.Sprintf("%x", swap.RefundHash160),
+			fmt.Sprintf("%x", expected.RefundHash160))
+	case expected.LockTime != 0 &&
+		(swap.LockTime < LockTimeThreshold) !=
+			(expected.LockTime < LockTimeThreshold):
+
+		return nil, mismatch("lock time kind", swap.LockTime,
+			expected.LockTime)
+	case expected.LockTime != 0 && swap.LockTime < expected.LockTime:
+		str := fmt.Sprintf("atomic swap lock time %d is before the "+
+			"expected %d", swap.LockTime, expected.LockTime)
+		return nil, scriptError(ErrAtomicSwapMismatch, str)
+	}
+	return swap, nil
+}
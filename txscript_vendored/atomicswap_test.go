@@ -0,0 +1,213 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestAtomicSwap ensures atomic swap contracts built with configurable
+// parameters are recognized, redeemed with their secret, refunded after their
+// lock time, audited and reveal their secret once redeemed.
+func TestAtomicSwap(t *testing.T) {
+	t.Parallel()
+
+	keys := testSecpKeys(t, 2)
+	recipient, refund := keys[0], keys[1]
+
+	tests := []struct {
+		name     string
+		hashOp   byte
+		secret   []byte
+		lockTime int64
+	}{{
+		name:     "sha256 32-byte secret with height lock time",
+		hashOp:   OP_SHA256,
+		secret:   bytes.Repeat([]byte{0x55}, 32),
+		lockTime: 500,
+	}, {
+		name:     "blake256 16-byte secret with timestamp lock time",
+		hashOp:   OP_BLAKE256,
+		secret:   bytes.Repeat([]byte{0xaa}, 16),
+		lockTime: 1600000000,
+	}}
+
+	for _, test := range tests {
+		secretHash, err := AtomicSwapSecretHash(test.hashOp, test.secret)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		params := &AtomicSwapContract{
+			HashOp:           test.hashOp,
+			SecretHash:       secretHash,
+			SecretSize:       int64(len(test.secret)),
+			RecipientHash160: *recipient.addr.AddressPubKeyHash().Hash160(),
+			RefundHash160:    *refund.addr.AddressPubKeyHash().Hash160(),
+			LockTime:         test.lockTime,
+		}
+		contract, err := AtomicSwapContractScript(params)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		pkScript, err := PayToScriptHashScript(dcrutil.Hash160(contract))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+
+		// Ensure the contract is audited successfully against its own
+		// parameters, including an earlier expected lock time, and is
+		// rejected for other ones.
+		expected := *params
+		expected.RefundHash160 = [20]byte{}
+		expected.LockTime--
+		swap, err := AuditAtomicSwap(pkScript, contract, &expected)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if *swap != *params {
+			t.Fatalf("%q: unexpected audited contract -- got %+v, "+
+				"want %+v", test.name, swap, params)
+		}
+		expected.LockTime += 2
+		_, err = AuditAtomicSwap(pkScript, contract, &expected)
+		if !errors.Is(err, ErrAtomicSwapMismatch) {
+			t.Fatalf("%q: unexpected error -- got %v, want %v",
+				test.name, err, ErrAtomicSwapMismatch)
+		}
+
+		// Ensure a zero expected lock time accepts any lock time kind.
+		expected.LockTime = 0
+		if _, err := AuditAtomicSwap(pkScript, contract, &expected); err != nil {
+			t.Fatalf("%q: unexpected error with no expected lock "+
+				"time: %v", test.name, err)
+		}
+		expected.LockTime = params.LockTime
+		expected.SecretSize++
+		_, err = AuditAtomicSwap(pkScript, contract, &expected)
+		if !errors.Is(err, ErrAtomicSwapMismatch) {
+			t.Fatalf("%q: unexpected error -- got %v, want %v",
+				test.name, err, ErrAtomicSwapMismatch)
+		}
+		_, err = AuditAtomicSwap(contract, contract, params)
+		if !errors.Is(err, ErrAtomicSwapMismatch) {
+			t.Fatalf("%q: unexpected error -- got %v, want %v",
+				test.name, err, ErrAtomicSwapMismatch)
+		}
+
+		// Ensure the contract is redeemed with the secret and the secret
+		// is extracted from the signature script.
+		redeemTx, err := CreateSpendingTx(nil, pkScript, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		sigScript, err := AtomicSwapRedeemSigScript(redeemTx, 0, contract,
+			SigHashAll, recipient.privKey, test.secret)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		redeemTx.TxIn[0].SignatureScript = sigScript
+		vm, err := NewEngine(pkScript, redeemTx, 0, StandardScriptFlags, 0,
+			nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Fatalf("%q: unexpected redeem error: %v", test.name, err)
+		}
+		secret, err := ExtractAtomicSwapSecret(0, sigScript)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if !bytes.Equal(secret, test.secret) {
+			t.Fatalf("%q: unexpected secret -- got %x, want %x",
+				test.name, secret, test.secret)
+		}
+		_, err = AtomicSwapRedeemSigScript(redeemTx, 0, contract,
+			SigHashAll, recipient.privKey, test.secret[1:])
+		if !errors.Is(err, ErrInvalidAtomicSwap) {
+			t.Fatalf("%q: unexpected error -- got %v, want %v",
+				test.name, err, ErrInvalidAtomicSwap)
+		}
+
+		// Ensure the contract is refunded once the transaction is locked
+		// until the lock time of the contract and not before.
+		for _, lockTime := range []int64{test.lockTime - 1, test.lockTime} {
+			refundTx, err := CreateSpendingTx(nil, pkScript, nil)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+			refundTx.LockTime = uint32(lockTime)
+			refundTx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+			sigScript, err := AtomicSwapRefundSigScript(refundTx, 0,
+				contract, SigHashAll, refund.privKey)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+			refundTx.TxIn[0].SignatureScript = sigScript
+			vm, err := NewEngine(pkScript, refundTx, 0,
+				StandardScriptFlags, 0, nil)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+			err = vm.Execute()
+			if lockTime < test.lockTime {
+				if !errors.Is(err, ErrUnsatisfiedLockTime) {
+					t.Fatalf("%q: unexpected early refund error "+
+						"-- got %v, want %v", test.name, err,
+						ErrUnsatisfiedLockTime)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%q: unexpected refund error: %v", test.name,
+					err)
+			}
+
+			// Ensure no secret is extracted from the refund.
+			_, err = ExtractAtomicSwapSecret(0, sigScript)
+			if !errors.Is(err, ErrNotAtomicSwapRedeem) {
+				t.Fatalf("%q: unexpected error -- got %v, want %v",
+					test.name, err, ErrNotAtomicSwapRedeem)
+			}
+		}
+	}
+}
+
+// TestAtomicSwapContractScriptErrors ensures invalid atomic swap parameters
+// are rejected.
+func TestAtomicSwapContractScriptErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		contract AtomicSwapContract
+	}{{
+		name:     "unsupported hash opcode",
+		contract: AtomicSwapContract{HashOp: OP_HASH160, SecretSize: 32, LockTime: 1},
+	}, {
+		name:     "zero secret size",
+		contract: AtomicSwapContract{HashOp: OP_SHA256, LockTime: 1},
+	}, {
+		name:     "negative lock time",
+		contract: AtomicSwapContract{HashOp: OP_SHA256, SecretSize: 32, LockTime: -1},
+	}, {
+		name: "lock time overflow",
+		contract: AtomicSwapContract{HashOp: OP_BLAKE256, SecretSize: 32,
+			LockTime: 1 << 32},
+	}}
+
+	for _, test := range tests {
+		_, err := AtomicSwapContractScript(&test.contract)
+		if !errors.Is(err, ErrInvalidAtomicSwap) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, ErrInvalidAtomicSwap)
+		}
+	}
+}
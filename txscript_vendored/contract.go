@@ -0,0 +1,484 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+// ContractClass is an enumeration for the contract templates recognized by
+// ExtractContract.
+type ContractClass byte
+
+// Classes of contracts recognized by ExtractContract.
+const (
+	NonContractTy        ContractClass = iota // None of the recognized forms.
+	AtomicSwapTy                              // Atomic swap contract.
+	HTLCTy                                    // Hash time locked contract.
+	TimeLockedSigTy                           // Timelocked single signature.
+	TimeLockedMultiSigTy                      // Multisig with timelocked recovery.
+	HashLockedTy                              // Hash locked output.
+)
+
+// contractClassToName houses the human-readable strings which describe each
+// contract class.
+var contractClassToName = []string{
+	NonContractTy:        "noncontract",
+	AtomicSwapTy:         "atomicswap",
+	HTLCTy:               "htlc",
+	TimeLockedSigTy:      "timelockedsig",
+	TimeLockedMultiSigTy: "timelockedmultisig",
+	HashLockedTy:         "hashlocked",
+}
+
+// String implements the Stringer interface by returning the name of the enum
+// contract class.  If the enum is invalid then "Invalid" will be returned.
+func (t ContractClass) String() string {
+	if int(t) >= len(contractClassToName) {
+		return "Invalid"
+	}
+	return contractClassToName[t]
+}
+
+// Contract is implemented by the typed parameters of each contract template
+// recognized by ExtractContract.
+type Contract interface {
+	// Class returns the class of the contract.
+	Class() ContractClass
+}
+
+// ContractKey identifies a key that must sign to satisfy a contract or a
+// branch of it.  Depending on the form of the contract either the public key
+// itself or its hash160 is committed to by the script and thus set.
+type ContractKey struct {
+	PubKey  []byte
+	Hash160 []byte
+}
+
+// TimeLock houses a lock time enforced by a contract.  Absolute lock times are
+// enforced by OP_CHECKLOCKTIMEVERIFY while relative lock times are enforced by
+// OP_CHECKSEQUENCEVERIFY.
+type TimeLock struct {
+	LockTime int64
+	Relative bool
+}
+
+// AtomicSwapContract houses the parameters of an atomic swap contract of the
+// form:
+//
+//  IF
+//   SIZE <secret size> EQUALVERIFY <SHA256|BLAKE256> <32-byte secret hash>
+//   EQUALVERIFY DUP HASH160 <20-byte recipient hash>
+//  ELSE
+//   <locktime> CHECKLOCKTIMEVERIFY DROP DUP HASH160 <20-byte refund hash>
+//  ENDIF
+//  EQUALVERIFY CHECKSIG
+type AtomicSwapContract struct {
+	HashOp           byte
+	SecretHash       [32]byte
+	SecretSize       int64
+	RecipientHash160 [20]byte
+	RefundHash160    [20]byte
+	LockTime         int64
+}
+
+// Class returns the class of the contract.
+func (c *AtomicSwapContract) Class() ContractClass {
+	return AtomicSwapTy
+}
+
+// HTLCContract houses the parameters of a hash time locked contract of the
+// form:
+//
+//  IF
+//   [SIZE <secret size> EQUALVERIFY] <hash op> <secret hash> EQUALVERIFY
+//   <recipient key>
+//  ELSE
+//   <locktime> <CHECKLOCKTIMEVERIFY|CHECKSEQUENCEVERIFY> DROP <refund key>
+//  ENDIF
+//  CHECKSIG
+//
+// The keys are either public keys or, when each branch ends with
+// DUP HASH160 <20-byte hash> and the script with EQUALVERIFY CHECKSIG, public
+// key hashes.  The secret size is zero when the contract does not check it.
+type HTLCContract struct {
+	HashOp     byte
+	SecretHash []byte
+	SecretSize int64
+	Recipient  ContractKey
+	Refund     ContractKey
+	Lock       TimeLock
+}
+
+// Class returns the class of the contract.
+func (c *HTLCContract) Class() ContractClass {
+	return HTLCTy
+}
+
+// TimeLockedSigContract houses the parameters of a single signature contract
+// that can only be spent after a lock time of the form:
+//
+//  <locktime> <CHECKLOCKTIMEVERIFY|CHECKSEQUENCEVERIFY> DROP <key check>
+//
+// where the key check is either <pubkey> CHECKSIG or
+// DUP HASH160 <20-byte hash> EQUALVERIFY CHECKSIG.
+type TimeLockedSigContract struct {
+	Lock TimeLock
+	Key  ContractKey
+}
+
+// Class returns the class of the contract.
+func (c *TimeLockedSigContract) Class() ContractClass {
+	return TimeLockedSigTy
+}
+
+// TimeLockedMultiSigContract houses the parameters of a multisig contract with
+// a recovery key that can spend it after a lock time of the form:
+//
+//  IF
+//   <required sigs> <pubkey>... <num pubkeys> CHECKMULTISIG
+//  ELSE
+//   <locktime> <CHECKLOCKTIMEVERIFY|CHECKSEQUENCEVERIFY> DROP <key check>
+//  ENDIF
+type TimeLockedMultiSigContract struct {
+	RequiredSigs int
+	PubKeys      [][]byte
+	Lock         TimeLock
+	Recovery     ContractKey
+}
+
+// Class returns the class of the contract.
+func (c *TimeLockedMultiSigContract) Class() ContractClass {
+	return TimeLockedMultiSigTy
+}
+
+// HashLockedContract houses the parameters of a contract that requires the
+// preimage of a hash and optionally a signature of the form:
+//
+//  [SIZE <secret size> EQUALVERIFY] <hash op> <secret hash> EQUAL
+//
+// or
+//
+//  [SIZE <secret size> EQUALVERIFY] <hash op> <secret hash> EQUALVERIFY
+//  <key check>
+//
+// Key is nil when no signature is required.
+type HashLockedContract struct {
+	HashOp     byte
+	SecretHash []byte
+	SecretSize int64
+	Key        *ContractKey
+}
+
+// Class returns the class of the contract.
+func (c *HashLockedContract) Class() ContractClass {
+	return HashLockedTy
+}
+
+// contractHashSize returns the size of the digest produced by the passed
+// hashing opcode or zero when the opcode is not a hashing opcode.
+func contractHashSize(op byte) int {
+	switch op {
+	case OP_SHA256, OP_BLAKE256, OP_HASH256:
+		return 32
+	case OP_RIPEMD160, OP_SHA1, OP_HASH160:
+		return 20
+	}
+	return 0
+}
+
+// contractParser matches scripts against contract templates one opcode at a
+// time.  Once any of the expectations fail, the parser is marked as failed and
+// all further expectations fail as well, which allows the templates to be
+// expressed as straight-line code that only checks the result at the end.
+type contractParser struct {
+	tokenizer ScriptTokenizer
+	failed    bool
+}
+
+// next advances to the next opcode, marking the parser as failed when there
+// are none left.
+func (p *contractParser) next() bool {
+	if p.failed {
+		return false
+	}
+	if !p.tokenizer.Next() {
+		p.failed = true
+		return false
+	}
+	return true
+}
+
+// peek returns the next opcode without consuming it or OP_INVALIDOPCODE when
+// there are none left.
+func (p *contractParser) peek() byte {
+	peek := p.tokenizer
+	if p.failed || !peek.Next() {
+		return OP_INVALIDOPCODE
+	}
+	return peek.Opcode()
+}
+
+// op consumes the next opcode which must be one of the provided opcodes and
+// returns it.
+func (p *contractParser) op(ops ...byte) byte {
+	if !p.next() {
+		return OP_INVALIDOPCODE
+	}
+	for _, op := range ops {
+		if p.tokenizer.Opcode() == op {
+			return op
+		}
+	}
+	p.failed = true
+	return OP_INVALIDOPCODE
+}
+
+// int consumes the next opcode which must push a canonically-encoded integer
+// of at most the provided number of bytes and returns it.
+func (p *contractParser) int(maxIntBytes int) int64 {
+	if !p.next() {
+		return 0
+	}
+	op, data := p.tokenizer.Opcode(), p.tokenizer.Data()
+	if IsSmallInt(op) {
+		return int64(AsSmallInt(op))
+	}
+	if op > OP_PUSHDATA4 || !isCanonicalPush(op, data) {
+		p.failed = true
+		return 0
+	}
+	val, err := MakeScriptNum(data, maxIntBytes)
+	if err != nil {
+		p.failed = true
+		return 0
+	}
+	return int64(val)
+}
+
+// data consumes the next opcode which must canonically push data of the
+// provided size and returns the data.
+func (p *contractParser) data(size int) []byte {
+	if !p.next() {
+		return nil
+	}
+	op, data := p.tokenizer.Opcode(), p.tokenizer.Data()
+	if op > OP_PUSHDATA4 || len(data) != size || !isCanonicalPush(op, data) {
+		p.failed = true
+		return nil
+	}
+	return data
+}
+
+// pubKey consumes the next opcode which must canonically push a
+// strictly-encoded public key and returns it.
+func (p *contractParser) pubKey() []byte {
+	if !p.next() {
+		return nil
+	}
+	op, data := p.tokenizer.Opcode(), p.tokenizer.Data()
+	if op > OP_PUSHDATA4 || !isCanonicalPush(op, data) ||
+		!isStrictPubKeyEncoding(data) {
+
+		p.failed = true
+		return nil
+	}
+	return data
+}
+
+// lock consumes a lock time followed by the opcode that enforces it and the
+// drop of the lock time from the stack.
+func (p *contractParser) lock() TimeLock {
+	lockTime := p.int(CltvMaxScriptNumLen)
+	op := p.op(OP_CHECKLOCKTIMEVERIFY, OP_CHECKSEQUENCEVERIFY)
+	p.op(OP_DROP)
+	return TimeLock{LockTime: lockTime, Relative: op == OP_CHECKSEQUENCEVERIFY}
+}
+
+// keyHash consumes a DUP HASH160 <20-byte hash> sequence and returns the hash.
+func (p *contractParser) keyHash() []byte {
+	p.op(OP_DUP)
+	p.op(OP_HASH160)
+	return p.data(20)
+}
+
+// keyCheck consumes either <pubkey> CHECKSIG or
+// DUP HASH160 <20-byte hash> EQUALVERIFY CHECKSIG.
+func (p *contractParser) keyCheck() ContractKey {
+	if p.peek() == OP_DUP {
+		hash := p.keyHash()
+		p.op(OP_EQUALVERIFY)
+		p.op(OP_CHECKSIG)
+		return ContractKey{Hash160: hash}
+	}
+	pubKey := p.pubKey()
+	p.op(OP_CHECKSIG)
+	return ContractKey{PubKey: pubKey}
+}
+
+// secretCheck consumes an optional SIZE <secret size> EQUALVERIFY sequence
+// followed by <hash op> <secret hash> and returns the details.  The secret
+// size is zero when the size is not checked.
+func (p *contractParser) secretCheck() (byte, []byte, int64) {
+	var secretSize int64
+	if p.peek() == OP_SIZE {
+		p.op(OP_SIZE)
+		secretSize = p.int(MathOpCodeMaxScriptNumLen)
+		p.op(OP_EQUALVERIFY)
+	}
+	hashOp := p.op(OP_SHA256, OP_BLAKE256, OP_HASH256, OP_RIPEMD160,
+		OP_SHA1, OP_HASH160)
+	hash := p.data(contractHashSize(hashOp))
+	return hashOp, hash, secretSize
+}
+
+// done returns whether all expectations were met and the entire script was
+// consumed.
+func (p *contractParser) done() bool {
+	return !p.failed && p.tokenizer.Done() && p.tokenizer.Err() == nil
+}
+
+// matchAtomicSwap attempts to match the script as an atomic swap contract.
+func matchAtomicSwap(p *contractParser) Contract {
+	p.op(OP_IF)
+	p.op(OP_SIZE)
+	secretSize := p.int(MathOpCodeMaxScriptNumLen)
+	p.op(OP_EQUALVERIFY)
+	hashOp := p.op(OP_SHA256, OP_BLAKE256)
+	secretHash := p.data(32)
+	p.op(OP_EQUALVERIFY)
+	recipient := p.keyHash()
+	p.op(OP_ELSE)
+	lockTime := p.int(CltvMaxScriptNumLen)
+	p.op(OP_CHECKLOCKTIMEVERIFY)
+	p.op(OP_DROP)
+	refund := p.keyHash()
+	p.op(OP_ENDIF)
+	p.op(OP_EQUALVERIFY)
+	p.op(OP_CHECKSIG)
+	if !p.done() {
+		return nil
+	}
+
+	c := &AtomicSwapContract{
+		HashOp:     hashOp,
+		SecretSize: secretSize,
+		LockTime:   lockTime,
+	}
+	copy(c.SecretHash[:], secretHash)
+	copy(c.RecipientHash160[:], recipient)
+	copy(c.RefundHash160[:], refund)
+	return c
+}
+
+// matchHTLC attempts to match the script as a hash time locked contract.
+func matchHTLC(p *contractParser) Contract {
+	c := new(HTLCContract)
+	p.op(OP_IF)
+	c.HashOp, c.SecretHash, c.SecretSize = p.secretCheck()
+	p.op(OP_EQUALVERIFY)
+	if p.peek() == OP_DUP {
+		c.Recipient.Hash160 = p.keyHash()
+		p.op(OP_ELSE)
+		c.Lock = p.lock()
+		c.Refund.Hash160 = p.keyHash()
+		p.op(OP_ENDIF)
+		p.op(OP_EQUALVERIFY)
+	} else {
+		c.Recipient.PubKey = p.pubKey()
+		p.op(OP_ELSE)
+		c.Lock = p.lock()
+		c.Refund.PubKey = p.pubKey()
+		p.op(OP_ENDIF)
+	}
+	p.op(OP_CHECKSIG)
+	if !p.done() {
+		return nil
+	}
+	return c
+}
+
+// matchTimeLockedSig attempts to match the script as a timelocked single
+// signature contract.
+func matchTimeLockedSig(p *contractParser) Contract {
+	lock := p.lock()
+	key := p.keyCheck()
+	if !p.done() {
+		return nil
+	}
+	return &TimeLockedSigContract{Lock: lock, Key: key}
+}
+
+// matchTimeLockedMultiSig attempts to match the script as a multisig contract
+// with a timelocked recovery key.
+func matchTimeLockedMultiSig(p *contractParser) Contract {
+	c := new(TimeLockedMultiSigContract)
+	p.op(OP_IF)
+	c.RequiredSigs = int(p.int(1))
+	for !p.failed && !IsSmallInt(p.peek()) {
+		c.PubKeys = append(c.PubKeys, p.pubKey())
+	}
+	numPubKeys := int(p.int(1))
+	p.op(OP_CHECKMULTISIG)
+	p.op(OP_ELSE)
+	c.Lock = p.lock()
+	c.Recovery = p.keyCheck()
+	p.op(OP_ENDIF)
+	if !p.done() || numPubKeys != len(c.PubKeys) || c.RequiredSigs < 1 ||
+		c.RequiredSigs > numPubKeys {
+
+		return nil
+	}
+	return c
+}
+
+// matchHashLocked attempts to match the script as a hash locked contract.
+func matchHashLocked(p *contractParser) Contract {
+	c := new(HashLockedContract)
+	c.HashOp, c.SecretHash, c.SecretSize = p.secretCheck()
+	if p.op(OP_EQUAL, OP_EQUALVERIFY) == OP_EQUALVERIFY {
+		key := p.keyCheck()
+		c.Key = &key
+	}
+	if !p.done() {
+		return nil
+	}
+	return c
+}
+
+// contractMatchers houses the functions which attempt to match each of the
+// recognized contract templates.  The atomic swap contract is checked prior to
+// the more general hash time locked contract it is a special case of.
+var contractMatchers = []func(*contractParser) Contract{
+	matchAtomicSwap,
+	matchHTLC,
+	matchTimeLockedSig,
+	matchTimeLockedMultiSig,
+	matchHashLocked,
+}
+
+// ExtractContract returns the parameters of the contract template the passed
+// script matches.  The returned value is one of *AtomicSwapContract,
+// *HTLCContract, *TimeLockedSigContract, *TimeLockedMultiSigContract or
+// *HashLockedContract and its Class method identifies which.  If the script
+// does not match any of the templates, ExtractContract returns (nil, nil).
+// Non-nil errors are returned for unparsable scripts.
+//
+// These contracts are not considered standard script types by the dcrd mempool
+// policy and are therefore typically found as the redeem script of a
+// pay-to-script-hash output.
+func ExtractContract(scriptVersion uint16, script []byte) (Contract, error) {
+	if scriptVersion != 0 {
+		return nil, nil
+	}
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+
+	for _, match := range contractMatchers {
+		p := contractParser{tokenizer: MakeScriptTokenizer(scriptVersion,
+			script)}
+		if c := match(&p); c != nil {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
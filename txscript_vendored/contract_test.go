@@ -0,0 +1,201 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestExtractContract ensures the contract templates are recognized along with
+// their parameters and that scripts which deviate from the templates are not.
+func TestExtractContract(t *testing.T) {
+	t.Parallel()
+
+	// Convenience values used in the tests.
+	hash20 := func(b byte) []byte { return bytes.Repeat([]byte{b}, 20) }
+	hash32 := func(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+	pubKey := func(b byte) []byte {
+		return append([]byte{0x02}, bytes.Repeat([]byte{b}, 32)...)
+	}
+	array20 := func(b byte) (a [20]byte) { copy(a[:], hash20(b)); return }
+	array32 := func(b byte) (a [32]byte) { copy(a[:], hash32(b)); return }
+
+	tests := []struct {
+		name     string
+		script   string
+		contract Contract
+		err      error
+	}{{
+		name: "atomic swap sha256",
+		script: "IF SIZE 32 EQUALVERIFY SHA256 DATA_32 0x01{32} EQUALVERIFY " +
+			"DUP HASH160 DATA_20 0x02{20} ELSE 500000 CHECKLOCKTIMEVERIFY " +
+			"DROP DUP HASH160 DATA_20 0x03{20} ENDIF EQUALVERIFY CHECKSIG",
+		contract: &AtomicSwapContract{
+			HashOp:           OP_SHA256,
+			SecretHash:       array32(0x01),
+			SecretSize:       32,
+			RecipientHash160: array20(0x02),
+			RefundHash160:    array20(0x03),
+			LockTime:         500000,
+		},
+	}, {
+		name: "atomic swap blake256",
+		script: "IF SIZE 32 EQUALVERIFY BLAKE256 DATA_32 0x01{32} " +
+			"EQUALVERIFY DUP HASH160 DATA_20 0x02{20} ELSE 500000 " +
+			"CHECKLOCKTIMEVERIFY DROP DUP HASH160 DATA_20 0x03{20} ENDIF " +
+			"EQUALVERIFY CHECKSIG",
+		contract: &AtomicSwapContract{
+			HashOp:           OP_BLAKE256,
+			SecretHash:       array32(0x01),
+			SecretSize:       32,
+			RecipientHash160: array20(0x02),
+			RefundHash160:    array20(0x03),
+			LockTime:         500000,
+		},
+	}, {
+		name: "htlc with public keys",
+		script: "IF HASH160 DATA_20 0x01{20} EQUALVERIFY DATA_33 0x02{33} " +
+			"ELSE 144 CHECKSEQUENCEVERIFY DROP DATA_33 0x02 0x03{32} " +
+			"ENDIF CHECKSIG",
+		contract: &HTLCContract{
+			HashOp:     OP_HASH160,
+			SecretHash: hash20(0x01),
+			Recipient:  ContractKey{PubKey: pubKey(0x02)},
+			Refund:     ContractKey{PubKey: pubKey(0x03)},
+			Lock:       TimeLock{LockTime: 144, Relative: true},
+		},
+	}, {
+		name: "htlc with public key hashes",
+		script: "IF SHA256 DATA_32 0x01{32} EQUALVERIFY DUP HASH160 " +
+			"DATA_20 0x02{20} ELSE 500000 CHECKLOCKTIMEVERIFY DROP DUP " +
+			"HASH160 DATA_20 0x03{20} ENDIF EQUALVERIFY CHECKSIG",
+		contract: &HTLCContract{
+			HashOp:     OP_SHA256,
+			SecretHash: hash32(0x01),
+			Recipient:  ContractKey{Hash160: hash20(0x02)},
+			Refund:     ContractKey{Hash160: hash20(0x03)},
+			Lock:       TimeLock{LockTime: 500000},
+		},
+	}, {
+		name:   "absolute timelocked pubkey",
+		script: "500000 CHECKLOCKTIMEVERIFY DROP DATA_33 0x02{33} CHECKSIG",
+		contract: &TimeLockedSigContract{
+			Lock: TimeLock{LockTime: 500000},
+			Key:  ContractKey{PubKey: pubKey(0x02)},
+		},
+	}, {
+		name: "relative timelocked pubkey hash",
+		script: "144 CHECKSEQUENCEVERIFY DROP DUP HASH160 DATA_20 0x01{20} " +
+			"EQUALVERIFY CHECKSIG",
+		contract: &TimeLockedSigContract{
+			Lock: TimeLock{LockTime: 144, Relative: true},
+			Key:  ContractKey{Hash160: hash20(0x01)},
+		},
+	}, {
+		name: "2-of-3 multisig with timelocked recovery",
+		script: "IF 2 DATA_33 0x02{33} DATA_33 0x02 0x03{32} DATA_33 0x02 " +
+			"0x04{32} 3 CHECKMULTISIG ELSE 4320 CHECKSEQUENCEVERIFY DROP " +
+			"DATA_33 0x02 0x05{32} CHECKSIG ENDIF",
+		contract: &TimeLockedMultiSigContract{
+			RequiredSigs: 2,
+			PubKeys:      [][]byte{pubKey(0x02), pubKey(0x03), pubKey(0x04)},
+			Lock:         TimeLock{LockTime: 4320, Relative: true},
+			Recovery:     ContractKey{PubKey: pubKey(0x05)},
+		},
+	}, {
+		name:   "hash locked",
+		script: "SIZE 32 EQUALVERIFY SHA256 DATA_32 0x01{32} EQUAL",
+		contract: &HashLockedContract{
+			HashOp:     OP_SHA256,
+			SecretHash: hash32(0x01),
+			SecretSize: 32,
+		},
+	}, {
+		name: "hash locked with signature",
+		script: "RIPEMD160 DATA_20 0x01{20} EQUALVERIFY DATA_33 0x02{33} " +
+			"CHECKSIG",
+		contract: &HashLockedContract{
+			HashOp:     OP_RIPEMD160,
+			SecretHash: hash20(0x01),
+			Key:        &ContractKey{PubKey: pubKey(0x02)},
+		},
+	}, {
+		name:   "hash size does not match hash op",
+		script: "SHA256 DATA_20 0x01{20} EQUAL",
+	}, {
+		name:   "hash pushed non-canonically",
+		script: "SHA256 PUSHDATA1 0x20 0x01{32} EQUAL",
+	}, {
+		name: "multisig with mismatched number of keys",
+		script: "IF 1 DATA_33 0x02{33} 2 CHECKMULTISIG ELSE 144 " +
+			"CHECKSEQUENCEVERIFY DROP DATA_33 0x02{33} CHECKSIG ENDIF",
+	}, {
+		name: "public key pushed non-canonically",
+		script: "500000 CHECKLOCKTIMEVERIFY DROP PUSHDATA1 0x21 0x02{33} " +
+			"CHECKSIG",
+	}, {
+		name:   "trailing opcodes",
+		script: "500000 CHECKLOCKTIMEVERIFY DROP DATA_33 0x02{33} CHECKSIG 1",
+	}, {
+		name:   "p2pkh is not a contract",
+		script: "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG",
+	}, {
+		name:   "unparsable script",
+		script: "500000 CHECKLOCKTIMEVERIFY DROP 0x4c",
+		err:    ErrMalformedPush,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		contract, err := ExtractContract(0, script)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(contract, test.contract) {
+			t.Errorf("%q: unexpected contract -- got %+v, want %+v",
+				test.name, contract, test.contract)
+			continue
+		}
+		if test.contract == nil {
+			continue
+		}
+		if contract.Class() != test.contract.Class() {
+			t.Errorf("%q: unexpected class -- got %v, want %v", test.name,
+				contract.Class(), test.contract.Class())
+		}
+	}
+}
+
+// TestContractClassStringer tests the stringized output for the ContractClass
+// type.
+func TestContractClassStringer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   ContractClass
+		want string
+	}{
+		{NonContractTy, "noncontract"},
+		{AtomicSwapTy, "atomicswap"},
+		{HTLCTy, "htlc"},
+		{TimeLockedSigTy, "timelockedsig"},
+		{TimeLockedMultiSigTy, "timelockedmultisig"},
+		{HashLockedTy, "hashlocked"},
+		{0xff, "Invalid"},
+	}
+
+	for _, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String: unexpected result -- got %s, want %s", result,
+				test.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// decompileIndent is the string used to indent each nesting level of the
+// decompiled pseudocode.
+const decompileIndent = "    "
+
+// decompileWrappers maps the tag opcodes that prefix stake and treasury scripts
+// to the name of the typed wrapper used to describe them.
+var decompileWrappers = map[byte]string{
+	OP_SSTX:       "stake_submission",
+	OP_SSGEN:      "stake_gen",
+	OP_SSRTX:      "stake_revocation",
+	OP_SSTXCHANGE: "stake_change",
+	OP_TADD:       "treasury_add",
+	OP_TGEN:       "treasury_gen",
+}
+
+// isDecompileHashOp returns whether the passed symbolic operator name is one of
+// the hashing opcodes.
+func isDecompileHashOp(name string) bool {
+	switch name {
+	case "ripemd160", "sha1", "sha256", "blake256", "hash160", "hash256":
+		return true
+	}
+	return false
+}
+
+// decompileInputNames returns a function which names the signature script
+// inputs, identified by discovery order, according to the role they play in
+// the constraints of the provided paths.  For example, the first argument to a
+// signature check is named "sig".  All of the paths must share the same prefix
+// so the discovery indices refer to the same inputs.
+func decompileInputNames(paths []SymbolicPath, isP2SH bool) func(int) string {
+	roles := make(map[int]string)
+	var order []int
+	assign := func(v *SymbolicValue, role string) {
+		if v.Kind != SymInput {
+			return
+		}
+		if _, ok := roles[v.Index]; ok {
+			return
+		}
+		roles[v.Index] = role
+		order = append(order, v.Index)
+	}
+
+	// Signature checks are visited first since they identify the role of
+	// their inputs with more certainty than hashing does.  For example,
+	// pay-to-pubkey-hash hashes the public key prior to checking it.
+	var visit func(v *SymbolicValue, sigChecks bool)
+	visit = func(v *SymbolicValue, sigChecks bool) {
+		if v.Kind != SymOp {
+			return
+		}
+		switch {
+		case sigChecks && strings.HasPrefix(v.Name, "checksig") &&
+			len(v.Args) == 2:
+			assign(v.Args[0], "sig")
+			assign(v.Args[1], "pubkey")
+		case sigChecks && v.Name == "checkmultisig" && len(v.Args) == 2:
+			for _, arg := range v.Args[0].Args {
+				assign(arg, "sig")
+			}
+			for _, arg := range v.Args[1].Args {
+				assign(arg, "pubkey")
+			}
+		case !sigChecks && isDecompileHashOp(v.Name) && isP2SH:
+			assign(v.Args[0], "redeem_script")
+		case !sigChecks && (isDecompileHashOp(v.Name) || v.Name == "size"):
+			assign(v.Args[0], "secret")
+		}
+		for _, arg := range v.Args {
+			visit(arg, sigChecks)
+		}
+	}
+	for _, sigChecks := range []bool{true, false} {
+		for _, path := range paths {
+			for _, c := range path.Constraints {
+				if c.Branch && !sigChecks {
+					assign(c.Expr, "cond")
+				}
+				visit(c.Expr, sigChecks)
+			}
+		}
+	}
+
+	// Number the roles that are played by multiple inputs.
+	count := make(map[string]int)
+	for _, idx := range order {
+		count[roles[idx]]++
+	}
+	names := make(map[int]string)
+	seen := make(map[string]int)
+	for _, idx := range order {
+		role := roles[idx]
+		if count[role] == 1 {
+			names[idx] = role
+			continue
+		}
+		seen[role]++
+		names[idx] = fmt.Sprintf("%s%d", role, seen[role])
+	}
+
+	return func(idx int) string {
+		if name, ok := names[idx]; ok {
+			return name
+		}
+		return fmt.Sprintf("arg%d", idx)
+	}
+}
+
+// decompileStatement returns the pseudocode statement for a requirement or an
+// empty string when the requirement is implied by another one and need not be
+// shown.
+func decompileStatement(c SymbolicConstraint, name func(int) string) string {
+	expr := c.Expr
+	if c.Holds && expr.Kind == SymOp && len(expr.Args) == 2 &&
+		expr.Args[0].Kind == SymTxField {
+
+		field, arg := expr.Args[0].Name, expr.Args[1]
+		switch {
+		case field == SymFieldLockTime && expr.Name == ">=":
+			return fmt.Sprintf("require after(%s)", arg.format(name))
+
+		case field == SymFieldSequence && expr.Name == ">=":
+			return fmt.Sprintf("require older(%s)", arg.format(name))
+
+		// The remaining transaction requirements are implied by the
+		// absolute and relative lock times.
+		case field == SymFieldSequence && expr.Name == "!=",
+			field == SymFieldVersion:
+			return ""
+		}
+	}
+	return "require " + c.format(name)
+}
+
+// decompileBlock writes the pseudocode for the provided paths starting at the
+// given constraint position.  All of the paths must share the constraints
+// before that position.
+func decompileBlock(buf *strings.Builder, paths []SymbolicPath, pos int, depth int, isP2SH bool) {
+	indent := strings.Repeat(decompileIndent, depth)
+	name := decompileInputNames(paths, isP2SH)
+	for {
+		path := paths[0]
+		if pos >= len(path.Constraints) {
+			if path.Err != nil {
+				fmt.Fprintf(buf, "%sfail // %v\n", indent, path.Err)
+			}
+			return
+		}
+
+		c := path.Constraints[pos]
+		if !c.Branch {
+			if stmt := decompileStatement(c, name); stmt != "" {
+				fmt.Fprintf(buf, "%s%s\n", indent, stmt)
+			}
+			pos++
+			continue
+		}
+
+		// Split the paths according to the outcome of the conditional.
+		var onTrue, onFalse []SymbolicPath
+		for _, path := range paths {
+			if path.Constraints[pos].Holds {
+				onTrue = append(onTrue, path)
+			} else {
+				onFalse = append(onFalse, path)
+			}
+		}
+
+		fmt.Fprintf(buf, "%sif (%s) {\n", indent, c.Expr.format(name))
+		if len(onTrue) > 0 {
+			decompileBlock(buf, onTrue, pos+1, depth+1, isP2SH)
+		}
+		fmt.Fprintf(buf, "%s} else {\n", indent)
+		if len(onFalse) > 0 {
+			decompileBlock(buf, onFalse, pos+1, depth+1, isP2SH)
+		}
+		fmt.Fprintf(buf, "%s}\n", indent)
+		return
+	}
+}
+
+// decompilePushes returns the comma-separated hex representation of the data
+// pushed by the provided script.
+func decompilePushes(script []byte) string {
+	data, err := PushedData(script)
+	if err != nil {
+		return "<invalid>"
+	}
+	pushes := make([]string, 0, len(data))
+	for _, d := range data {
+		pushes = append(pushes, fmt.Sprintf("0x%x", d))
+	}
+	return strings.Join(pushes, ", ")
+}
+
+// Decompile returns pseudocode that describes the conditions required to spend
+// the provided script.
+//
+// Every conditional whose outcome depends on the signature script is rendered
+// as an if/else block and the code following the conditional is duplicated
+// into both branches so that each branch lists all of its requirements.  The
+// items provided by the signature script are named after the role they play,
+// such as sig, pubkey and secret.  For example, a hash-locked contract with a
+// timelocked refund decompiles to:
+//
+//  if (cond) {
+//      require sha256(secret) == 0x...
+//      require checksig(sig, 0x02...)
+//  } else {
+//      require after(500000)
+//      require checksig(sig, 0x03...)
+//  }
+//
+// Scripts tagged with the stake and treasury opcodes are shown as typed
+// wrappers around the decompiled script they tag, and null data scripts are
+// shown as the data they carry.
+func Decompile(script []byte, scriptVersion uint16, flags ScriptFlags) (string, error) {
+	if scriptVersion != 0 {
+		str := fmt.Sprintf("unsupported script version %d", scriptVersion)
+		return "", scriptError(ErrUnsupportedScriptVersion, str)
+	}
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if len(script) > 0 && script[0] == OP_RETURN {
+		fmt.Fprintf(&buf, "nulldata(%s)\n", decompilePushes(script[1:]))
+		return buf.String(), nil
+	}
+
+	// Treasury spends are identified by the signature script which pushes
+	// the signature and public key followed by the tag.
+	treasuryEnabled := flags&ScriptVerifyTreasury == ScriptVerifyTreasury
+	lastIdx := len(script) - 1
+	if treasuryEnabled && lastIdx >= 0 && script[lastIdx] == OP_TSPEND &&
+		IsPushOnlyScript(script[:lastIdx]) {
+
+		fmt.Fprintf(&buf, "treasury_spend(%s)\n",
+			decompilePushes(script[:lastIdx]))
+		return buf.String(), nil
+	}
+
+	depth := 0
+	body := script
+	wrapper, isWrapped := "", false
+	if len(script) > 0 {
+		wrapper, isWrapped = decompileWrappers[script[0]]
+		if isWrapped && isStakeOpcode(script[0], treasuryEnabled) {
+			body = script[1:]
+			depth = 1
+		} else {
+			isWrapped = false
+		}
+	}
+	if isWrapped {
+		fmt.Fprintf(&buf, "%s {\n", wrapper)
+	}
+
+	if len(body) > 0 {
+		paths, err := symbolicExecute(body, scriptVersion, flags, false)
+		if err != nil {
+			return "", err
+		}
+		decompileBlock(&buf, paths, 0, depth, isScriptHashScript(body))
+	}
+
+	if isWrapped {
+		buf.WriteString("}\n")
+	}
+	return buf.String(), nil
+}
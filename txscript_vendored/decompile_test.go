@@ -0,0 +1,92 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"testing"
+)
+
+// TestDecompile ensures scripts are decompiled to the expected pseudocode.
+func TestDecompile(t *testing.T) {
+	t.Parallel()
+
+	const flags = ScriptVerifyCheckLockTimeVerify |
+		ScriptVerifyCheckSequenceVerify | ScriptVerifySHA256 |
+		ScriptVerifyTreasury
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{{
+		name:   "p2pkh",
+		script: "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG",
+		want: "require hash160(pubkey) == " +
+			"0x0101010101010101010101010101010101010101\n" +
+			"require checksig(sig, pubkey)\n",
+	}, {
+		name: "hash locked with timelocked refund",
+		script: "IF SHA256 DATA_32 0x03{32} EQUALVERIFY DATA_33 0x02{33} " +
+			"ELSE 500000 CHECKLOCKTIMEVERIFY DROP DATA_33 0x03{33} ENDIF " +
+			"CHECKSIG",
+		want: "if (cond) {\n" +
+			"    require sha256(secret) == 0x" +
+			"0303030303030303030303030303030303030303030303030303030303030303\n" +
+			"    require checksig(sig, 0x" +
+			"020202020202020202020202020202020202020202020202020202020202020202)\n" +
+			"} else {\n" +
+			"    require after(500000)\n" +
+			"    require checksig(sig, 0x" +
+			"030303030303030303030303030303030303030303030303030303030303030303)\n" +
+			"}\n",
+	}, {
+		name: "computed condition with failing branch",
+		script: "SIZE 32 EQUAL IF BLAKE256 DATA_32 0x04{32} EQUAL ELSE " +
+			"RETURN ENDIF",
+		want: "if (size(secret) == 32) {\n" +
+			"    require blake256(secret) == 0x" +
+			"0404040404040404040404040404040404040404040404040404040404040404\n" +
+			"} else {\n" +
+			"    fail // script returned early\n" +
+			"}\n",
+	}, {
+		name:   "stake submission p2sh",
+		script: "SSTX HASH160 DATA_20 0x02{20} EQUAL",
+		want: "stake_submission {\n" +
+			"    require hash160(redeem_script) == " +
+			"0x0202020202020202020202020202020202020202\n" +
+			"}\n",
+	}, {
+		name:   "treasury add",
+		script: "TADD",
+		want:   "treasury_add {\n}\n",
+	}, {
+		name:   "treasury spend signature script",
+		script: "DATA_2 0x0102 DATA_1 0x03 TSPEND",
+		want:   "treasury_spend(0x0102, 0x03)\n",
+	}, {
+		name:   "null data",
+		script: "RETURN DATA_4 0x01020304",
+		want:   "nulldata(0x01020304)\n",
+	}, {
+		name:   "multisig",
+		script: "2 DATA_33 0x02{33} DATA_33 0x03{33} 2 CHECKMULTISIG",
+		want: "require checkmultisig([sig1, sig2], [0x" +
+			"020202020202020202020202020202020202020202020202020202020202020202, 0x" +
+			"030303030303030303030303030303030303030303030303030303030303030303])\n",
+	}}
+
+	for _, test := range tests {
+		got, err := Decompile(mustParseShortForm(test.script), 0, flags)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: unexpected result -- got:\n%s\nwant:\n%s",
+				test.name, got, test.want)
+		}
+	}
+}
@@ -100,12 +100,20 @@ type Engine struct {
 	// since transaction scripts are often executed more than once from various
 	// contexts (e.g. new block templates, when transactions are first seen
 	// prior to being mined, part of full block verification, etc).
-	flags    ScriptFlags
-	tx       wire.MsgTx
-	txIdx    int
-	version  uint16
-	isP2SH   bool
-	sigCache *SigCache
+	//
+	// sigVerifier, when set, verifies signatures in place of the signature
+	// checking opcodes.  See SetSigVerifier.
+	//
+	// sigCheckObserver, when set, is notified of the signature checks
+	// performed by the signature checking opcodes.  See SetSigCheckObserver.
+	flags            ScriptFlags
+	tx               wire.MsgTx
+	txIdx            int
+	version          uint16
+	isP2SH           bool
+	sigCache         *SigCache
+	sigVerifier      SigVerifier
+	sigCheckObserver SigCheckObserver
 
 	// The following fields handle keeping track of the current execution state
 	// of the engine.
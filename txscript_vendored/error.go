@@ -7,6 +7,7 @@ package txscript
 
 import (
 	"errors"
+	"fmt"
 )
 
 // ErrorKind identifies a kind of script error.
@@ -40,6 +41,10 @@ const (
 	// provided public keys.
 	ErrTooManyRequiredSigs = ErrorKind("ErrTooManyRequiredSigs")
 
+	// ErrDuplicatePubKey is returned from SortedMultiSigScript when the same
+	// public key is provided more than once.
+	ErrDuplicatePubKey = ErrorKind("ErrDuplicatePubKey")
+
 	// ErrTooMuchNullData is returned from NullDataScript when the length of
 	// the provided data exceeds MaxDataCarrierSize.
 	ErrTooMuchNullData = ErrorKind("ErrTooMuchNullData")
@@ -48,6 +53,74 @@ const (
 	// version is passed to a function which deals with script analysis.
 	ErrUnsupportedScriptVersion = ErrorKind("ErrUnsupportedScriptVersion")
 
+	// ErrUnsupportedSymbolicOp is returned by SymbolicExecute when a script
+	// performs an operation that requires a concrete value, such as the
+	// index for OP_PICK, with data provided by the signature script.
+	ErrUnsupportedSymbolicOp = ErrorKind("ErrUnsupportedSymbolicOp")
+
+	// ErrTooManyPaths is returned by SymbolicExecute when a script has more
+	// than MaxSymbolicPaths execution paths.
+	ErrTooManyPaths = ErrorKind("ErrTooManyPaths")
+
+	// ErrMalformedTemplate is returned when a script template pattern does
+	// not conform to the template syntax.
+	ErrMalformedTemplate = ErrorKind("ErrMalformedTemplate")
+
+	// ErrUnknownLockTime is returned when checking a lock time requirement
+	// whose lock time is not a number pushed by the script.
+	ErrUnknownLockTime = ErrorKind("ErrUnknownLockTime")
+
+	// ErrInvalidStakeLayout is reported by AnalyzeStakeTx when a stake
+	// transaction does not conform to the layout required by its type.
+	ErrInvalidStakeLayout = ErrorKind("ErrInvalidStakeLayout")
+
+	// ErrInvalidTSpendSignature is reported by VerifyTSpendSignature when a
+	// treasury spend does not provide a valid signature.
+	ErrInvalidTSpendSignature = ErrorKind("ErrInvalidTSpendSignature")
+
+	// ErrKeyNotFound is returned by FileKeyDB when it does not contain the
+	// private key for an address.
+	ErrKeyNotFound = ErrorKind("ErrKeyNotFound")
+
+	// ErrScriptNotFound is returned by FileScriptDB when it does not
+	// contain the redeem script for an address.
+	ErrScriptNotFound = ErrorKind("ErrScriptNotFound")
+
+	// ErrUnsupportedPartialTxScript is returned by PartialTx when an input
+	// spends a script it does not know how to sign.
+	ErrUnsupportedPartialTxScript = ErrorKind("ErrUnsupportedPartialTxScript")
+
+	// ErrIncompletePartialTx is returned by PartialTx when an input is
+	// missing the scripts or signatures needed to finalize it.
+	ErrIncompletePartialTx = ErrorKind("ErrIncompletePartialTx")
+
+	// ErrPartialTxMismatch is returned by PartialTx when the partially
+	// signed transactions to combine or the scripts of an input do not
+	// match.
+	ErrPartialTxMismatch = ErrorKind("ErrPartialTxMismatch")
+
+	// ErrUnsatisfiable is returned by Satisfier when none of the execution
+	// paths of a script can be satisfied with the available secrets.
+	ErrUnsatisfiable = ErrorKind("ErrUnsatisfiable")
+
+	// ErrInvalidAtomicSwap is returned when the parameters of an atomic swap
+	// contract are invalid, a script is not an atomic swap contract or a
+	// secret does not match the hash of a contract.
+	ErrInvalidAtomicSwap = ErrorKind("ErrInvalidAtomicSwap")
+
+	// ErrNotAtomicSwapRedeem is returned by ExtractAtomicSwapSecret when the
+	// signature script does not redeem an atomic swap contract with its
+	// secret.
+	ErrNotAtomicSwapRedeem = ErrorKind("ErrNotAtomicSwapRedeem")
+
+	// ErrAtomicSwapMismatch is returned by AuditAtomicSwap when a contract
+	// does not match the expected parameters.
+	ErrAtomicSwapMismatch = ErrorKind("ErrAtomicSwapMismatch")
+
+	// ErrUnsupportedSigType is returned by VerifySignature when the
+	// signature type is not supported by the signature checking opcodes.
+	ErrUnsupportedSigType = ErrorKind("ErrUnsupportedSigType")
+
 	// ------------------------------------------
 	// Failures related to final execution state.
 	// ------------------------------------------
@@ -329,6 +402,101 @@ func (e ErrorKind) Error() string {
 	return string(e)
 }
 
+// errorKinds lists all of the error kinds so they can be looked up by name.
+var errorKinds = []ErrorKind{
+	ErrInvalidIndex,
+	ErrInvalidSigHashSingleIndex,
+	ErrUnsupportedAddress,
+	ErrNotMultisigScript,
+	ErrTooManyRequiredSigs,
+	ErrDuplicatePubKey,
+	ErrTooMuchNullData,
+	ErrUnsupportedScriptVersion,
+	ErrUnsupportedSymbolicOp,
+	ErrTooManyPaths,
+	ErrMalformedTemplate,
+	ErrUnknownLockTime,
+	ErrInvalidStakeLayout,
+	ErrInvalidTSpendSignature,
+	ErrKeyNotFound,
+	ErrScriptNotFound,
+	ErrUnsupportedPartialTxScript,
+	ErrIncompletePartialTx,
+	ErrPartialTxMismatch,
+	ErrUnsatisfiable,
+	ErrInvalidAtomicSwap,
+	ErrNotAtomicSwapRedeem,
+	ErrAtomicSwapMismatch,
+	ErrUnsupportedSigType,
+	ErrEarlyReturn,
+	ErrEmptyStack,
+	ErrEvalFalse,
+	ErrScriptUnfinished,
+	ErrInvalidProgramCounter,
+	ErrScriptTooBig,
+	ErrElementTooBig,
+	ErrTooManyOperations,
+	ErrStackOverflow,
+	ErrInvalidPubKeyCount,
+	ErrInvalidSignatureCount,
+	ErrNumOutOfRange,
+	ErrVerify,
+	ErrEqualVerify,
+	ErrNumEqualVerify,
+	ErrCheckSigVerify,
+	ErrCheckMultiSigVerify,
+	ErrCheckSigAltVerify,
+	ErrP2SHStakeOpCodes,
+	ErrDisabledOpcode,
+	ErrReservedOpcode,
+	ErrMalformedPush,
+	ErrInvalidStackOperation,
+	ErrUnbalancedConditional,
+	ErrNegativeSubstrIdx,
+	ErrOverflowSubstrIdx,
+	ErrNegativeRotation,
+	ErrOverflowRotation,
+	ErrDivideByZero,
+	ErrNegativeShift,
+	ErrOverflowShift,
+	ErrP2SHTreasuryOpCodes,
+	ErrMinimalData,
+	ErrInvalidSigHashType,
+	ErrSigTooShort,
+	ErrSigTooLong,
+	ErrSigInvalidSeqID,
+	ErrSigInvalidDataLen,
+	ErrSigMissingSTypeID,
+	ErrSigMissingSLen,
+	ErrSigInvalidSLen,
+	ErrSigInvalidRIntID,
+	ErrSigZeroRLen,
+	ErrSigNegativeR,
+	ErrSigTooMuchRPadding,
+	ErrSigInvalidSIntID,
+	ErrSigZeroSLen,
+	ErrSigNegativeS,
+	ErrSigTooMuchSPadding,
+	ErrSigHighS,
+	ErrNotPushOnly,
+	ErrPubKeyType,
+	ErrCleanStack,
+	ErrDiscourageUpgradableNOPs,
+	ErrNegativeLockTime,
+	ErrUnsatisfiedLockTime,
+}
+
+// ParseErrorKind returns the error kind with the provided name, such as
+// "ErrEvalFalse".  An error is returned when no error kind has the name.
+func ParseErrorKind(name string) (ErrorKind, error) {
+	for _, kind := range errorKinds {
+		if string(kind) == name {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown error kind %q", name)
+}
+
 // Error identifies a script-related error.  It is used to indicate three
 // classes of errors:
 // 1) Script execution failures due to violating one of the many requirements
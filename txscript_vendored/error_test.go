@@ -21,9 +21,26 @@ func TestErrorKindStringer(t *testing.T) {
 		{ErrInvalidSigHashSingleIndex, "ErrInvalidSigHashSingleIndex"},
 		{ErrUnsupportedAddress, "ErrUnsupportedAddress"},
 		{ErrTooManyRequiredSigs, "ErrTooManyRequiredSigs"},
+		{ErrDuplicatePubKey, "ErrDuplicatePubKey"},
 		{ErrTooMuchNullData, "ErrTooMuchNullData"},
 		{ErrUnsupportedScriptVersion, "ErrUnsupportedScriptVersion"},
 		{ErrNotMultisigScript, "ErrNotMultisigScript"},
+		{ErrUnsupportedSymbolicOp, "ErrUnsupportedSymbolicOp"},
+		{ErrTooManyPaths, "ErrTooManyPaths"},
+		{ErrMalformedTemplate, "ErrMalformedTemplate"},
+		{ErrUnknownLockTime, "ErrUnknownLockTime"},
+		{ErrInvalidStakeLayout, "ErrInvalidStakeLayout"},
+		{ErrInvalidTSpendSignature, "ErrInvalidTSpendSignature"},
+		{ErrKeyNotFound, "ErrKeyNotFound"},
+		{ErrScriptNotFound, "ErrScriptNotFound"},
+		{ErrUnsupportedPartialTxScript, "ErrUnsupportedPartialTxScript"},
+		{ErrIncompletePartialTx, "ErrIncompletePartialTx"},
+		{ErrPartialTxMismatch, "ErrPartialTxMismatch"},
+		{ErrUnsatisfiable, "ErrUnsatisfiable"},
+		{ErrInvalidAtomicSwap, "ErrInvalidAtomicSwap"},
+		{ErrNotAtomicSwapRedeem, "ErrNotAtomicSwapRedeem"},
+		{ErrAtomicSwapMismatch, "ErrAtomicSwapMismatch"},
+		{ErrUnsupportedSigType, "ErrUnsupportedSigType"},
 		{ErrEarlyReturn, "ErrEarlyReturn"},
 		{ErrEmptyStack, "ErrEmptyStack"},
 		{ErrEvalFalse, "ErrEvalFalse"},
@@ -88,6 +105,14 @@ func TestErrorKindStringer(t *testing.T) {
 			t.Errorf("#%d: got: %s want: %s", i, result, test.want)
 			continue
 		}
+		kind, err := ParseErrorKind(test.want)
+		if err != nil || kind != test.in {
+			t.Errorf("#%d: unexpected parsed kind %v (%v)", i, kind, err)
+		}
+	}
+
+	if _, err := ParseErrorKind("ErrNoSuchKind"); err == nil {
+		t.Error("unexpected success parsing an unknown error kind")
 	}
 }
 
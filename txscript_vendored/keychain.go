@@ -0,0 +1,260 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestKey is a private key derived by a TestKeychain.
+type TestKey struct {
+	// Name and SigType are the name and signature type the key is derived
+	// for.
+	Name    string
+	SigType dcrec.SignatureType
+
+	// PrivKey is the private key in the form accepted by RawTxInSignature,
+	// which is the 32-byte secret followed by the public key for Ed25519
+	// keys.
+	PrivKey []byte
+
+	// PubKey is the serialized public key, which is compressed for
+	// secp256k1 keys.
+	PubKey []byte
+}
+
+// PubKeyAddress returns the pay-to-pubkey address of the key.
+func (k *TestKey) PubKeyAddress(params dcrutil.AddressParams) (dcrutil.Address, error) {
+	switch k.SigType {
+	case dcrec.STEcdsaSecp256k1:
+		return dcrutil.NewAddressSecpPubKey(k.PubKey, params)
+	case dcrec.STEd25519:
+		return dcrutil.NewAddressEdwardsPubKey(k.PubKey, params)
+	case dcrec.STSchnorrSecp256k1:
+		return dcrutil.NewAddressSecSchnorrPubKey(k.PubKey, params)
+	}
+	str := fmt.Sprintf("unsupported signature type %d", k.SigType)
+	return nil, scriptError(ErrUnsupportedSigType, str)
+}
+
+// PubKeyHashAddress returns the pay-to-pubkey-hash address of the key.
+func (k *TestKey) PubKeyHashAddress(params dcrutil.AddressParams) (*dcrutil.AddressPubKeyHash, error) {
+	return dcrutil.NewAddressPubKeyHash(dcrutil.Hash160(k.PubKey), params,
+		k.SigType)
+}
+
+// WIF returns the key encoded in the Wallet Import Format for the network
+// identified by privKeyID.  Note that Ed25519 keys are encoded as their private
+// scalar reduced modulo the group order, which has the same public key but
+// signs with a different nonce than PrivKey.
+func (k *TestKey) WIF(privKeyID [2]byte) (*dcrutil.WIF, error) {
+	privKey := k.PrivKey
+	if k.SigType == dcrec.STEd25519 {
+		// The scalar of a key created from a secret holds the bytes of
+		// the little endian scalar as a big endian number.
+		priv, _ := edwards.PrivKeyFromBytes(k.PrivKey)
+		privKey = make([]byte, edwards.PrivScalarSize)
+		d := priv.GetD().Bytes()
+		for i := range d {
+			privKey[i] = d[len(d)-1-i]
+		}
+		scalar := new(big.Int).SetBytes(privKey)
+		scalar.Mod(scalar, edwards.Edwards().N)
+		privKey = make([]byte, edwards.PrivScalarSize)
+		b := scalar.Bytes()
+		copy(privKey[len(privKey)-len(b):], b)
+	}
+	return dcrutil.NewWIF(privKey, privKeyID, k.SigType)
+}
+
+// TestKeychain derives named private keys of every signature type from a seed
+// so tests can sign with the same keys on every run.  It is a KeyDB for the
+// keys it derived and a ScriptDB for the redeem scripts added to it, so
+// transactions can be signed with SignTxOutput.
+//
+// The keys are not secret to anyone knowing the seed and must never be used
+// to hold real funds.
+type TestKeychain struct {
+	seed    []byte
+	keys    map[[20]byte]*TestKey
+	scripts *FileScriptDB
+}
+
+// Ensure TestKeychain implements the KeyDB and ScriptDB interfaces.
+var (
+	_ KeyDB    = (*TestKeychain)(nil)
+	_ ScriptDB = (*TestKeychain)(nil)
+)
+
+// NewTestKeychain returns a keychain deriving its keys from the provided seed.
+func NewTestKeychain(seed []byte) *TestKeychain {
+	return &TestKeychain{
+		seed:    seed,
+		keys:    make(map[[20]byte]*TestKey),
+		scripts: NewFileScriptDB(),
+	}
+}
+
+// Key derives the key with the provided name and signature type and adds it
+// to the keychain.  The same seed, name and signature type always result in
+// the same key, while keys of different names or signature types are
+// unrelated.
+//
+// The private key is derived from the BLAKE-256 hash of the seed followed by
+// the signature type byte and the name.  Secp256k1 keys hash the result again
+// until it is a valid private key, while Ed25519 keys use it as their secret.
+func (kc *TestKeychain) Key(name string, sigType dcrec.SignatureType) (*TestKey, error) {
+	preimage := make([]byte, 0, len(kc.seed)+1+len(name))
+	preimage = append(preimage, kc.seed...)
+	preimage = append(preimage, byte(sigType))
+	preimage = append(preimage, name...)
+	secret := chainhash.HashB(preimage)
+
+	key := &TestKey{Name: name, SigType: sigType}
+	switch sigType {
+	case dcrec.STEcdsaSecp256k1, dcrec.STSchnorrSecp256k1:
+		var scalar secp256k1.ModNScalar
+		for overflow := scalar.SetByteSlice(secret); overflow ||
+			scalar.IsZero(); overflow = scalar.SetByteSlice(secret) {
+
+			secret = chainhash.HashB(secret)
+		}
+		priv := secp256k1.NewPrivateKey(&scalar)
+		key.PrivKey = priv.Serialize()
+		key.PubKey = priv.PubKey().SerializeCompressed()
+
+	case dcrec.STEd25519:
+		priv, pub := edwards.PrivKeyFromSecret(secret)
+		if priv == nil {
+			return nil, fmt.Errorf("unable to derive Ed25519 key %q", name)
+		}
+		key.PrivKey = priv.SerializeSecret()
+		key.PubKey = pub.Serialize()
+
+	default:
+		str := fmt.Sprintf("unsupported signature type %d", sigType)
+		return nil, scriptError(ErrUnsupportedSigType, str)
+	}
+
+	var hash [20]byte
+	copy(hash[:], dcrutil.Hash160(key.PubKey))
+	kc.keys[hash] = key
+	return key, nil
+}
+
+// AddScript adds the provided redeem script to the keychain.
+func (kc *TestKeychain) AddScript(script []byte) {
+	kc.scripts.AddScript(script)
+}
+
+// GetKey returns the private key, its signature type and whether the public
+// key is compressed for the provided pay-to-pubkey or pay-to-pubkey-hash
+// address.  It returns ErrKeyNotFound when the key of the address has not
+// been derived by the keychain.
+//
+// This is part of the KeyDB interface.
+func (kc *TestKeychain) GetKey(addr dcrutil.Address) ([]byte, dcrec.SignatureType, bool, error) {
+	keyAddr, ok := addr.(interface {
+		Hash160() *[20]byte
+		DSA() dcrec.SignatureType
+	})
+	if !ok {
+		str := fmt.Sprintf("address %s does not have a private key", addr)
+		return nil, 0, false, scriptError(ErrUnsupportedAddress, str)
+	}
+	key, ok := kc.keys[*keyAddr.Hash160()]
+	if !ok || key.SigType != keyAddr.DSA() {
+		str := fmt.Sprintf("no private key for address %s", addr)
+		return nil, 0, false, scriptError(ErrKeyNotFound, str)
+	}
+	return key.PrivKey, key.SigType, true, nil
+}
+
+// GetScript returns the redeem script of the provided pay-to-script-hash
+// address.  It returns ErrScriptNotFound when the script has not been added
+// to the keychain.
+//
+// This is part of the ScriptDB interface.
+func (kc *TestKeychain) GetScript(addr dcrutil.Address) ([]byte, error) {
+	return kc.scripts.GetScript(addr)
+}
+
+// TestFixture houses a synthetic funding transaction paying to a public key
+// script along with a transaction spending its output which is signed by the
+// keys of a TestKeychain and valid with the script flags.
+type TestFixture struct {
+	FundingTx  *wire.MsgTx
+	SpendingTx *wire.MsgTx
+	PkScript   []byte
+	Flags      ScriptFlags
+}
+
+// Fixture creates a funding transaction with CreateFundingTx paying the amount
+// to the public key script and a spending transaction with CreateSpendingTx
+// whose input is signed with the keys and redeem scripts of the keychain for
+// the hash type.  All keys the script pays to must be derived, and redeem
+// scripts added, beforehand.  The signed input is executed with the provided
+// script flags and an error is returned when it is not valid, such as when a
+// multisig script requires more signatures than the keychain can provide.
+func (kc *TestKeychain) Fixture(params dcrutil.AddressParams, pkScript []byte, amount int64, hashType SigHashType, flags ScriptFlags) (*TestFixture, error) {
+	spendingTx, err := CreateSpendingTx(nil, pkScript, &SpendingTxConfig{
+		Version:  wire.TxVersion,
+		Sequence: wire.MaxTxInSequenceNum,
+		Amount:   amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	treasuryEnabled := flags&ScriptVerifyTreasury != 0
+	sigScript, err := SignTxOutput(params, spendingTx, 0, pkScript, hashType,
+		kc, kc, nil, treasuryEnabled)
+	if err != nil {
+		return nil, err
+	}
+	spendingTx.TxIn[0].SignatureScript = sigScript
+
+	vm, err := NewEngine(pkScript, spendingTx, 0, flags, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.Execute(); err != nil {
+		return nil, err
+	}
+
+	return &TestFixture{
+		FundingTx:  CreateFundingTx(pkScript, amount),
+		SpendingTx: spendingTx,
+		PkScript:   pkScript,
+		Flags:      flags,
+	}, nil
+}
+
+// TxValidVector returns the fixture as a vector of the reference valid
+// transaction tests, which is of the form [[[prevout hash, prevout index,
+// prevout script]], serialized transaction, flags] with the script in the
+// short form of the vectors.
+func (f *TestFixture) TxValidVector() ([]interface{}, error) {
+	serialized, err := f.SpendingTx.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	prevOut := &f.SpendingTx.TxIn[0].PreviousOutPoint
+	inputs := []interface{}{
+		[]interface{}{prevOut.Hash.String(), prevOut.Index,
+			FormatShortFormScript(f.PkScript)},
+	}
+	return []interface{}{inputs, hex.EncodeToString(serialized),
+		FormatVectorFlags(f.Flags)}, nil
+}
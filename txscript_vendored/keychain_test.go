@@ -0,0 +1,285 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestTestKeychainKeys ensures the keys derived by a keychain only depend on
+// the seed, name and signature type.
+func TestTestKeychainKeys(t *testing.T) {
+	t.Parallel()
+
+	sigTypes := []dcrec.SignatureType{dcrec.STEcdsaSecp256k1,
+		dcrec.STEd25519, dcrec.STSchnorrSecp256k1}
+	seen := make(map[string]struct{})
+	for _, seed := range []string{"seed1", "seed2"} {
+		for _, name := range []string{"alice", "bob"} {
+			for _, sigType := range sigTypes {
+				kc1 := NewTestKeychain([]byte(seed))
+				kc2 := NewTestKeychain([]byte(seed))
+				key1, err := kc1.Key(name, sigType)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				key2, err := kc2.Key(name, sigType)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !bytes.Equal(key1.PrivKey, key2.PrivKey) ||
+					!bytes.Equal(key1.PubKey, key2.PubKey) {
+
+					t.Fatalf("%s/%s/%d: key is not deterministic",
+						seed, name, sigType)
+				}
+				pubKey := hex.EncodeToString(key1.PubKey)
+				if _, ok := seen[pubKey]; ok {
+					t.Fatalf("%s/%s/%d: key is not unique", seed,
+						name, sigType)
+				}
+				seen[pubKey] = struct{}{}
+
+				// Ensure the WIF encoding has the same public key.
+				wif, err := key1.WIF(mainNetParams.PrivateKeyID)
+				if err != nil {
+					t.Fatalf("%s/%s/%d: unexpected error: %v", seed,
+						name, sigType, err)
+				}
+				if !bytes.Equal(wif.PubKey(), key1.PubKey) {
+					t.Fatalf("%s/%s/%d: unexpected WIF public key %x",
+						seed, name, sigType, wif.PubKey())
+				}
+
+				// Ensure both the key and its WIF encoding sign for
+				// its own signature type.
+				for _, privKey := range [][]byte{key1.PrivKey,
+					wif.PrivKey()} {
+
+					sig, err := RawTxInSignature(wire.NewMsgTx(), 0,
+						nil, SigHashAll, privKey, sigType)
+					if err != nil {
+						t.Fatalf("%s/%s/%d: unexpected error: %v",
+							seed, name, sigType, err)
+					}
+					_, hash, err := CalcCheckSigHash(wire.NewMsgTx(),
+						0, nil, sig)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					valid, err := VerifySignature(sigType, sig,
+						key1.PubKey, hash)
+					if err != nil || !valid {
+						t.Fatalf("%s/%s/%d: invalid signature: %v",
+							seed, name, sigType, err)
+					}
+				}
+			}
+		}
+	}
+
+	// Ensure the derivation does not change.
+	key, err := NewTestKeychain([]byte("seed")).Key("alice",
+		dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const wantPubKey = "025e20eeca23da1fb5a519e133543dbf060231d169fa912ec9c" +
+		"0760356fccf3b1b"
+	if hex.EncodeToString(key.PubKey) != wantPubKey {
+		t.Fatalf("unexpected public key %x, want %s", key.PubKey,
+			wantPubKey)
+	}
+
+	_, err = NewTestKeychain(nil).Key("alice", 3)
+	if !errors.Is(err, ErrUnsupportedSigType) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrUnsupportedSigType)
+	}
+}
+
+// TestTestKeychainFixtures ensures the fixtures of a keychain are signed for
+// scripts of all standard classes and are valid reference test vectors.
+func TestTestKeychainFixtures(t *testing.T) {
+	t.Parallel()
+
+	kc := NewTestKeychain([]byte("fixtures"))
+	key := func(name string, sigType dcrec.SignatureType) *TestKey {
+		key, err := kc.Key(name, sigType)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return key
+	}
+	alice := key("alice", dcrec.STEcdsaSecp256k1)
+	bob := key("bob", dcrec.STEcdsaSecp256k1)
+	carol := key("carol", dcrec.STEd25519)
+	dave := key("dave", dcrec.STSchnorrSecp256k1)
+
+	p2pkh := func(key *TestKey) dcrutil.Address {
+		addr, err := key.PubKeyHashAddress(mainNetParams)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return addr
+	}
+	p2pk := func(key *TestKey) dcrutil.Address {
+		addr, err := key.PubKeyAddress(mainNetParams)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return addr
+	}
+	multiSig := func(nrequired int, keys ...*TestKey) []byte {
+		var addrs []*dcrutil.AddressSecpPubKey
+		for _, key := range keys {
+			addrs = append(addrs, p2pk(key).(*dcrutil.AddressSecpPubKey))
+		}
+		script, err := MultiSigScript(addrs, nrequired)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return script
+	}
+	p2sh := func(redeemScript []byte) dcrutil.Address {
+		kc.AddScript(redeemScript)
+		addr, err := dcrutil.NewAddressScriptHash(redeemScript,
+			mainNetParams)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return addr
+	}
+
+	tests := []struct {
+		name   string
+		script func(addr dcrutil.Address) ([]byte, error)
+		addr   dcrutil.Address
+		raw    []byte
+	}{{
+		name:   "p2pkh ecdsa",
+		script: PayToAddrScript,
+		addr:   p2pkh(alice),
+	}, {
+		name:   "p2pkh ed25519",
+		script: PayToAddrScript,
+		addr:   p2pkh(carol),
+	}, {
+		name:   "p2pkh schnorr",
+		script: PayToAddrScript,
+		addr:   p2pkh(dave),
+	}, {
+		name:   "p2pk ecdsa",
+		script: PayToAddrScript,
+		addr:   p2pk(alice),
+	}, {
+		name:   "p2pk ed25519",
+		script: PayToAddrScript,
+		addr:   p2pk(carol),
+	}, {
+		name:   "p2pk schnorr",
+		script: PayToAddrScript,
+		addr:   p2pk(dave),
+	}, {
+		name: "bare multisig",
+		raw:  multiSig(2, alice, bob),
+	}, {
+		name:   "p2sh multisig",
+		script: PayToAddrScript,
+		addr:   p2sh(multiSig(1, alice, bob)),
+	}, {
+		name:   "sstx p2pkh",
+		script: PayToSStx,
+		addr:   p2pkh(alice),
+	}, {
+		name:   "ssgen p2pkh",
+		script: PayToSSGen,
+		addr:   p2pkh(bob),
+	}, {
+		name:   "ssrtx p2sh",
+		script: PayToSSRtx,
+		addr:   p2sh(multiSig(2, alice, bob)),
+	}, {
+		name:   "sstxchange p2pkh",
+		script: PayToSStxChange,
+		addr:   p2pkh(alice),
+	}}
+
+	for _, test := range tests {
+		pkScript := test.raw
+		if pkScript == nil {
+			var err error
+			pkScript, err = test.script(test.addr)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+		}
+		fixture, err := kc.Fixture(mainNetParams, pkScript, 1e8, SigHashAll,
+			ConsensusScriptFlags)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		// Ensure the vector survives a round trip through JSON and
+		// refers to the funding transaction.
+		vector, err := fixture.TxValidVector()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		b, err := json.Marshal(vector)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		var decoded []interface{}
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		input := decoded[0].([]interface{})[0].([]interface{})
+		script, err := ParseShortFormScript(input[2].(string))
+		if err != nil || !bytes.Equal(script, pkScript) {
+			t.Errorf("%q: unexpected vector script %v (%v)", test.name,
+				input[2], err)
+		}
+		if input[0] != fixture.FundingTx.TxHash().String() ||
+			input[1] != float64(0) {
+
+			t.Errorf("%q: unexpected vector outpoint %v:%v", test.name,
+				input[0], input[1])
+		}
+		wantTx, err := fixture.SpendingTx.Bytes()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		flags, err := ParseVectorFlags(decoded[2].(string))
+		if decoded[1] != hex.EncodeToString(wantTx) || err != nil ||
+			flags != ConsensusScriptFlags {
+
+			t.Errorf("%q: unexpected vector %s", test.name, b)
+		}
+	}
+
+	// Ensure fixtures which can not be signed by the keychain are rejected.
+	stranger, err := NewTestKeychain([]byte("other")).Key("eve",
+		dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pkScript := multiSig(2, alice, stranger)
+	_, err = kc.Fixture(mainNetParams, pkScript, 1e8, SigHashAll,
+		ConsensusScriptFlags)
+	if err == nil {
+		t.Fatal("fixture of a multisig script without enough keys " +
+			"succeeded")
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// readLines invokes the provided function with the trimmed content of each
+// line read from r, skipping empty lines and comments starting with #.
+func readLines(r io.Reader, f func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f(line); err != nil {
+			return fmt.Errorf("line %d: %v", lineNum, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// FileKeyDB is a KeyDB with private keys read from files in the Wallet Import
+// Format.  The keys are found by the hash of their public key, so they sign for
+// both pay-to-pubkey and pay-to-pubkey-hash outputs of the signature type of
+// the key, including those of multisig and stake scripts.  Ed25519 keys are
+// returned as the private scalar they are encoded as, which RawTxInSignature
+// signs with.
+type FileKeyDB struct {
+	keys map[[20]byte]*dcrutil.WIF
+}
+
+// Ensure FileKeyDB implements the KeyDB interface.
+var _ KeyDB = (*FileKeyDB)(nil)
+
+// NewFileKeyDB returns an empty FileKeyDB.
+func NewFileKeyDB() *FileKeyDB {
+	return &FileKeyDB{keys: make(map[[20]byte]*dcrutil.WIF)}
+}
+
+// AddKey adds the provided private key to the database.
+func (db *FileKeyDB) AddKey(wif *dcrutil.WIF) {
+	var hash [20]byte
+	copy(hash[:], dcrutil.Hash160(wif.PubKey()))
+	db.keys[hash] = wif
+}
+
+// ReadKeys adds the private keys read from r, which contains one WIF-encoded
+// private key of the network identified by privKeyID per line.  Empty lines
+// and lines starting with # are ignored.
+func (db *FileKeyDB) ReadKeys(r io.Reader, privKeyID [2]byte) error {
+	return readLines(r, func(line string) error {
+		wif, err := dcrutil.DecodeWIF(line, privKeyID)
+		if err != nil {
+			return err
+		}
+		db.AddKey(wif)
+		return nil
+	})
+}
+
+// LoadKeyDB returns a FileKeyDB with the private keys read from the files at
+// the provided paths.  See ReadKeys for the format of the files.
+func LoadKeyDB(privKeyID [2]byte, paths ...string) (*FileKeyDB, error) {
+	db := NewFileKeyDB()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = db.ReadKeys(f, privKeyID)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return db, nil
+}
+
+// Keys returns the private keys in the database.
+func (db *FileKeyDB) Keys() []*dcrutil.WIF {
+	keys := make([]*dcrutil.WIF, 0, len(db.keys))
+	for _, wif := range db.keys {
+		keys = append(keys, wif)
+	}
+	return keys
+}
+
+// GetKey returns the private key, its signature type and whether the public
+// key is compressed for the provided pay-to-pubkey or pay-to-pubkey-hash
+// address.  It returns ErrKeyNotFound when the database does not contain the
+// key of the address.
+//
+// This is part of the KeyDB interface.
+func (db *FileKeyDB) GetKey(addr dcrutil.Address) ([]byte, dcrec.SignatureType, bool, error) {
+	keyAddr, ok := addr.(interface {
+		Hash160() *[20]byte
+		DSA() dcrec.SignatureType
+	})
+	if !ok {
+		str := fmt.Sprintf("address %s does not have a private key", addr)
+		return nil, 0, false, scriptError(ErrUnsupportedAddress, str)
+	}
+	wif, ok := db.keys[*keyAddr.Hash160()]
+	if !ok || wif.DSA() != keyAddr.DSA() {
+		str := fmt.Sprintf("no private key for address %s", addr)
+		return nil, 0, false, scriptError(ErrKeyNotFound, str)
+	}
+	return wif.PrivKey(), wif.DSA(), true, nil
+}
+
+// FileScriptDB is a ScriptDB with redeem scripts read from files and indexed
+// by their pay-to-script-hash address.
+type FileScriptDB struct {
+	scripts map[[20]byte][]byte
+}
+
+// Ensure FileScriptDB implements the ScriptDB interface.
+var _ ScriptDB = (*FileScriptDB)(nil)
+
+// NewFileScriptDB returns an empty FileScriptDB.
+func NewFileScriptDB() *FileScriptDB {
+	return &FileScriptDB{scripts: make(map[[20]byte][]byte)}
+}
+
+// AddScript adds the provided redeem script to the database.
+func (db *FileScriptDB) AddScript(script []byte) {
+	var hash [20]byte
+	copy(hash[:], dcrutil.Hash160(script))
+	db.scripts[hash] = script
+}
+
+// ReadScripts adds the redeem scripts read from r, which contains one
+// hex-encoded redeem script per line.  Empty lines and lines starting with #
+// are ignored.
+func (db *FileScriptDB) ReadScripts(r io.Reader) error {
+	return readLines(r, func(line string) error {
+		script, err := hex.DecodeString(line)
+		if err != nil {
+			return err
+		}
+		db.AddScript(script)
+		return nil
+	})
+}
+
+// LoadScriptDB returns a FileScriptDB with the redeem scripts read from the
+// files at the provided paths.  See ReadScripts for the format of the files.
+func LoadScriptDB(paths ...string) (*FileScriptDB, error) {
+	db := NewFileScriptDB()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		err = db.ReadScripts(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return db, nil
+}
+
+// GetScript returns the redeem script of the provided pay-to-script-hash
+// address.  It returns ErrScriptNotFound when the database does not contain
+// the script of the address.
+//
+// This is part of the ScriptDB interface.
+func (db *FileScriptDB) GetScript(addr dcrutil.Address) ([]byte, error) {
+	p2shAddr, ok := addr.(*dcrutil.AddressScriptHash)
+	if !ok {
+		str := fmt.Sprintf("address %s is not a pay-to-script-hash "+
+			"address", addr)
+		return nil, scriptError(ErrUnsupportedAddress, str)
+	}
+	script, ok := db.scripts[*p2shAddr.Hash160()]
+	if !ok {
+		str := fmt.Sprintf("no redeem script for address %s", addr)
+		return nil, scriptError(ErrScriptNotFound, str)
+	}
+	return script, nil
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// testSecpKey is a secp256k1 key the tests sign with.
+type testSecpKey struct {
+	privKey []byte
+	pubKey  []byte
+	wif     *dcrutil.WIF
+	addr    *dcrutil.AddressSecpPubKey
+}
+
+// testSecpKeys returns n secp256k1 keys with private keys made of the bytes 1
+// through n repeated 32 times, along with their compressed public key, WIF
+// encoding and pay-to-pubkey address on the test network.
+func testSecpKeys(t *testing.T, n int) []testSecpKey {
+	t.Helper()
+
+	keys := make([]testSecpKey, n)
+	for i := range keys {
+		privKey := bytes.Repeat([]byte{byte(i + 1)}, 32)
+		wif, err := dcrutil.NewWIF(privKey, testingParams.PrivateKeyID,
+			dcrec.STEcdsaSecp256k1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		addr, err := dcrutil.NewAddressSecpPubKey(wif.PubKey(),
+			testingParams)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		keys[i] = testSecpKey{
+			privKey: privKey,
+			pubKey:  wif.PubKey(),
+			wif:     wif,
+			addr:    addr,
+		}
+	}
+	return keys
+}
+
+// TestFileKeyDB ensures the keys and redeem scripts read by FileKeyDB and
+// FileScriptDB sign pay-to-pubkey-hash and pay-to-script-hash multisig
+// outputs, including merging the partial signatures of co-signers.
+func TestFileKeyDB(t *testing.T) {
+	t.Parallel()
+
+	// Create two keys and a 2-of-2 multisig redeem script with them.
+	keys := testSecpKeys(t, 2)
+	redeemScript, err := MultiSigScript([]*dcrutil.AddressSecpPubKey{
+		keys[0].addr, keys[1].addr}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Load a database with each key and one with the redeem script.
+	var kdbs [2]*FileKeyDB
+	for i, key := range keys {
+		kdbs[i] = NewFileKeyDB()
+		keys := "# co-signer key\n\n" + key.wif.String() + "\n"
+		if err := kdbs[i].ReadKeys(strings.NewReader(keys),
+			testingParams.PrivateKeyID); err != nil {
+
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	sdb := NewFileScriptDB()
+	if err := sdb.ReadScripts(strings.NewReader(hex.EncodeToString(
+		redeemScript))); err != nil {
+
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Ensure a pay-to-pubkey-hash output is signed by the first key only.
+	pkScript, err := PayToAddrScript(keys[0].addr.AddressPubKeyHash())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx, err := CreateSpendingTx(nil, pkScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := signAndCheck("p2pkh", tx, 0, pkScript, SigHashAll, kdbs[0],
+		sdb, noTreasury); err != nil {
+
+		t.Fatal(err)
+	}
+	_, err = SignTxOutput(testingParams, tx, 0, pkScript, SigHashAll,
+		kdbs[1], sdb, nil, noTreasury)
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrKeyNotFound)
+	}
+
+	// Ensure the pay-to-script-hash output is only valid once the partial
+	// signature script of the first co-signer is merged with the second.
+	p2shAddr, err := dcrutil.NewAddressScriptHash(redeemScript,
+		testingParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pkScript, err = PayToAddrScript(p2shAddr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx, err = CreateSpendingTx(nil, pkScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	partial, err := SignTxOutput(testingParams, tx, 0, pkScript,
+		SigHashAll, kdbs[0], sdb, nil, noTreasury)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkScripts("partial p2sh", tx, 0, partial,
+		pkScript); err == nil {
+
+		t.Fatal("unexpected success with a partial signature script")
+	}
+	sigScript, err := SignTxOutput(testingParams, tx, 0, pkScript,
+		SigHashAll, kdbs[1], sdb, partial, noTreasury)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkScripts("merged p2sh", tx, 0, sigScript,
+		pkScript); err != nil {
+
+		t.Fatal(err)
+	}
+
+	// Ensure missing redeem scripts are reported.
+	_, err = SignTxOutput(testingParams, tx, 0, pkScript, SigHashAll,
+		kdbs[0], NewFileScriptDB(), nil, noTreasury)
+	if !errors.Is(err, ErrScriptNotFound) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrScriptNotFound)
+	}
+
+	// Ensure invalid lines are reported.
+	err = NewFileKeyDB().ReadKeys(strings.NewReader("notawif"),
+		testingParams.PrivateKeyID)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 1:") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestFileKeyDBEd25519 ensures Ed25519 keys read by FileKeyDB, which are
+// encoded as their private scalar, sign pay-to-pubkey-hash outputs.
+func TestFileKeyDBEd25519(t *testing.T) {
+	t.Parallel()
+
+	key, err := NewTestKeychain([]byte("keydb")).Key("alice",
+		dcrec.STEd25519)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wif, err := key.WIF(testingParams.PrivateKeyID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kdb := NewFileKeyDB()
+	if err := kdb.ReadKeys(strings.NewReader(wif.String()),
+		testingParams.PrivateKeyID); err != nil {
+
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addr, err := key.PubKeyHashAddress(testingParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pkScript, err := PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx, err := CreateSpendingTx(nil, pkScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := signAndCheck("p2pkh ed25519", tx, 0, pkScript, SigHashAll,
+		kdb, NewFileScriptDB(), noTreasury); err != nil {
+
+		t.Fatal(err)
+	}
+}
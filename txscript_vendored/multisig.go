@@ -0,0 +1,163 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// SortedMultiSigScript returns a valid script for a multisignature redemption
+// where nrequired of the keys in pubkeys are required to have signed the
+// transaction for success.  Unlike MultiSigScript, the public keys are
+// serialized in compressed form and sorted lexicographically, so the same set
+// of keys always results in the same script, and thus pay-to-script-hash
+// address, regardless of the order the co-signers provide them in.
+//
+// An Error with the error kind ErrInvalidSignatureCount will be returned if
+// nrequired is less than one, ErrTooManyRequiredSigs if nrequired is larger
+// than the number of keys provided, ErrInvalidPubKeyCount if more than
+// MaxPubKeysPerMultiSig keys are provided and ErrDuplicatePubKey if any key is
+// provided more than once.
+func SortedMultiSigScript(pubkeys []*dcrutil.AddressSecpPubKey, nrequired int) ([]byte, error) {
+	if nrequired < 1 {
+		str := fmt.Sprintf("unable to generate multisig script with "+
+			"%d required signatures", nrequired)
+		return nil, scriptError(ErrInvalidSignatureCount, str)
+	}
+	if len(pubkeys) < nrequired {
+		str := fmt.Sprintf("unable to generate multisig script with "+
+			"%d required signatures when there are only %d public "+
+			"keys available", nrequired, len(pubkeys))
+		return nil, scriptError(ErrTooManyRequiredSigs, str)
+	}
+	if len(pubkeys) > MaxPubKeysPerMultiSig {
+		str := fmt.Sprintf("unable to generate multisig script with %d "+
+			"public keys which exceeds the max allowed of %d",
+			len(pubkeys), MaxPubKeysPerMultiSig)
+		return nil, scriptError(ErrInvalidPubKeyCount, str)
+	}
+
+	keys := make([][]byte, 0, len(pubkeys))
+	for _, key := range pubkeys {
+		keys = append(keys, key.PubKey().SerializeCompressed())
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	for i := 1; i < len(keys); i++ {
+		if bytes.Equal(keys[i-1], keys[i]) {
+			str := fmt.Sprintf("public key %x is provided more than once",
+				keys[i])
+			return nil, scriptError(ErrDuplicatePubKey, str)
+		}
+	}
+
+	builder := NewScriptBuilder().AddInt64(int64(nrequired))
+	for _, key := range keys {
+		builder.AddData(key)
+	}
+	builder.AddInt64(int64(len(keys)))
+	builder.AddOp(OP_CHECKMULTISIG)
+
+	return builder.Script()
+}
+
+// MultiSigKey houses a public key of a multisignature script along with
+// details about its encoding.
+type MultiSigKey struct {
+	// PubKey is the serialized public key as pushed by the script.
+	PubKey []byte
+
+	// Compressed is whether the key is serialized in compressed form.
+	Compressed bool
+
+	// Duplicate is whether the same key, in either form, is also pushed by
+	// another entry of the script.
+	Duplicate bool
+}
+
+// MultiSigAnalysis houses the details of a multisignature script returned by
+// AnalyzeMultiSigScript.
+type MultiSigAnalysis struct {
+	// RequiredSigs is the number of signatures required to redeem the
+	// script.
+	RequiredSigs int
+
+	// PubKeys are the public keys of the script in the order they are
+	// pushed.
+	PubKeys []MultiSigKey
+
+	// Sorted is whether all public keys are compressed and sorted
+	// lexicographically as done by SortedMultiSigScript.
+	Sorted bool
+
+	// Duplicates is whether any public key is pushed more than once.
+	Duplicates bool
+
+	// Address is the pay-to-script-hash address of the script.
+	Address *dcrutil.AddressScriptHash
+}
+
+// AnalyzeMultiSigScript returns the required number of signatures, the public
+// keys and their encodings, whether the keys are sorted or duplicated and the
+// pay-to-script-hash address of the provided multisignature script.  An Error
+// with the error kind ErrNotMultisigScript is returned when the script is not
+// a standard multisignature script.
+//
+// NOTE: This function is only valid for version 0 scripts.  Since the function
+// does not accept a script version, the results are undefined for other script
+// versions.
+func AnalyzeMultiSigScript(script []byte, params dcrutil.AddressParams) (*MultiSigAnalysis, error) {
+	const scriptVersion = 0
+	details := extractMultisigScriptDetails(scriptVersion, script, true)
+	if !details.valid {
+		str := fmt.Sprintf("script %x is not a multisig script", script)
+		return nil, scriptError(ErrNotMultisigScript, str)
+	}
+	addr, err := dcrutil.NewAddressScriptHash(script, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare the keys in compressed form so the same key pushed in both
+	// forms is detected as a duplicate.  Keys which are not valid points
+	// are compared as they are.
+	analysis := &MultiSigAnalysis{
+		RequiredSigs: details.requiredSigs,
+		PubKeys:      make([]MultiSigKey, 0, len(details.pubKeys)),
+		Sorted:       true,
+		Address:      addr,
+	}
+	firstIdx := make(map[string]int, len(details.pubKeys))
+	for i, pubKey := range details.pubKeys {
+		key := MultiSigKey{
+			PubKey:     pubKey,
+			Compressed: len(pubKey) == 33,
+		}
+		id := string(pubKey)
+		if parsed, err := secp256k1.ParsePubKey(pubKey); err == nil {
+			id = string(parsed.SerializeCompressed())
+		}
+		if j, ok := firstIdx[id]; ok {
+			key.Duplicate = true
+			analysis.PubKeys[j].Duplicate = true
+			analysis.Duplicates = true
+		} else {
+			firstIdx[id] = i
+		}
+		if !key.Compressed || (i > 0 &&
+			bytes.Compare(details.pubKeys[i-1], pubKey) > 0) {
+
+			analysis.Sorted = false
+		}
+		analysis.PubKeys = append(analysis.PubKeys, key)
+	}
+	return analysis, nil
+}
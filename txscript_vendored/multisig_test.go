@@ -0,0 +1,241 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// TestSortedMultiSigScript ensures sorted multisig scripts do not depend on
+// the order of the provided keys and invalid key sets are rejected.
+func TestSortedMultiSigScript(t *testing.T) {
+	t.Parallel()
+
+	// Create more keys than allowed by a multisig script and use the
+	// uncompressed form of one of them to ensure keys are compressed by the
+	// builder.
+	var allAddrs []*dcrutil.AddressSecpPubKey
+	for i := byte(1); i <= MaxPubKeysPerMultiSig+1; i++ {
+		priv := secp256k1.PrivKeyFromBytes(bytes.Repeat([]byte{i}, 32))
+		pubKey := priv.PubKey().SerializeCompressed()
+		if i == 2 {
+			pubKey = priv.PubKey().SerializeUncompressed()
+		}
+		addr, err := dcrutil.NewAddressSecpPubKey(pubKey, mainNetParams)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		allAddrs = append(allAddrs, addr)
+	}
+	addrs := allAddrs[:3]
+
+	want, err := SortedMultiSigScript(addrs, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, order := range [][]int{{2, 1, 0}, {1, 0, 2}, {0, 2, 1}} {
+		keys := []*dcrutil.AddressSecpPubKey{addrs[order[0]],
+			addrs[order[1]], addrs[order[2]]}
+		script, err := SortedMultiSigScript(keys, 2)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", order, err)
+		}
+		if !bytes.Equal(script, want) {
+			t.Fatalf("%v: unexpected script -- got %x, want %x", order,
+				script, want)
+		}
+	}
+
+	analysis, err := AnalyzeMultiSigScript(want, mainNetParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !analysis.Sorted || analysis.Duplicates || analysis.RequiredSigs != 2 ||
+		len(analysis.PubKeys) != 3 {
+
+		t.Fatalf("unexpected analysis of sorted script: %+v", analysis)
+	}
+
+	tests := []struct {
+		name      string
+		keys      []*dcrutil.AddressSecpPubKey
+		nrequired int
+		err       error
+	}{{
+		name:      "no required signatures",
+		keys:      addrs,
+		nrequired: 0,
+		err:       ErrInvalidSignatureCount,
+	}, {
+		name:      "negative required signatures",
+		keys:      addrs,
+		nrequired: -1,
+		err:       ErrInvalidSignatureCount,
+	}, {
+		name:      "too many required signatures",
+		keys:      addrs,
+		nrequired: 4,
+		err:       ErrTooManyRequiredSigs,
+	}, {
+		name:      "duplicate key",
+		keys:      []*dcrutil.AddressSecpPubKey{addrs[0], addrs[1], addrs[0]},
+		nrequired: 1,
+		err:       ErrDuplicatePubKey,
+	}, {
+		name:      "too many keys",
+		keys:      allAddrs,
+		nrequired: 1,
+		err:       ErrInvalidPubKeyCount,
+	}}
+	for _, test := range tests {
+		_, err := SortedMultiSigScript(test.keys, test.nrequired)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+}
+
+// TestAnalyzeMultiSigScript ensures the encodings, order and duplicates of
+// the public keys of multisig scripts are reported.
+func TestAnalyzeMultiSigScript(t *testing.T) {
+	t.Parallel()
+
+	// Create three compressed keys in increasing order and the
+	// uncompressed form of the first one.
+	var keys [][]byte
+	for i := byte(1); i <= 3; i++ {
+		priv := secp256k1.PrivKeyFromBytes(bytes.Repeat([]byte{i}, 32))
+		keys = append(keys, priv.PubKey().SerializeCompressed())
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	pk1, pk2, pk3 := keys[0], keys[1], keys[2]
+	parsed, err := secp256k1.ParsePubKey(pk1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uncompressed := parsed.SerializeUncompressed()
+	multiSig := func(nrequired int64, pubKeys ...[]byte) []byte {
+		builder := NewScriptBuilder().AddInt64(nrequired)
+		for _, pubKey := range pubKeys {
+			builder.AddData(pubKey)
+		}
+		builder.AddInt64(int64(len(pubKeys))).AddOp(OP_CHECKMULTISIG)
+		script, err := builder.Script()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return script
+	}
+
+	tests := []struct {
+		name       string
+		script     []byte
+		required   int
+		compressed []bool
+		duplicate  []bool
+		sorted     bool
+		duplicates bool
+		err        error
+	}{{
+		name:       "sorted compressed keys",
+		script:     multiSig(2, pk1, pk2, pk3),
+		required:   2,
+		compressed: []bool{true, true, true},
+		duplicate:  []bool{false, false, false},
+		sorted:     true,
+	}, {
+		name:       "unsorted compressed keys",
+		script:     multiSig(1, pk3, pk1),
+		required:   1,
+		compressed: []bool{true, true},
+		duplicate:  []bool{false, false},
+	}, {
+		name:       "uncompressed key",
+		script:     multiSig(1, uncompressed, pk2),
+		required:   1,
+		compressed: []bool{false, true},
+		duplicate:  []bool{false, false},
+	}, {
+		name:       "same key in both forms",
+		script:     multiSig(1, pk1, uncompressed),
+		required:   1,
+		compressed: []bool{true, false},
+		duplicate:  []bool{true, true},
+		duplicates: true,
+	}, {
+		name:       "sorted duplicate keys",
+		script:     multiSig(2, pk1, pk2, pk2),
+		required:   2,
+		compressed: []bool{true, true, true},
+		duplicate:  []bool{false, true, true},
+		sorted:     true,
+		duplicates: true,
+	}, {
+		name: "not multisig",
+		script: mustParseShortForm("1 DATA_33 0x" + hex.EncodeToString(pk1) +
+			" 1 CHECKSIG"),
+		err: ErrNotMultisigScript,
+	}}
+
+	for _, test := range tests {
+		analysis, err := AnalyzeMultiSigScript(test.script, mainNetParams)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: unexpected error -- got %v, want %v",
+					test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		if analysis.RequiredSigs != test.required {
+			t.Errorf("%q: unexpected required sigs -- got %d, want %d",
+				test.name, analysis.RequiredSigs, test.required)
+		}
+		if len(analysis.PubKeys) != len(test.compressed) {
+			t.Errorf("%q: unexpected number of keys -- got %d, want %d",
+				test.name, len(analysis.PubKeys), len(test.compressed))
+			continue
+		}
+		for i, key := range analysis.PubKeys {
+			if key.Compressed != test.compressed[i] ||
+				key.Duplicate != test.duplicate[i] {
+
+				t.Errorf("%q: unexpected key %d details: %+v",
+					test.name, i, key)
+			}
+		}
+		if analysis.Sorted != test.sorted {
+			t.Errorf("%q: unexpected sorted -- got %v, want %v",
+				test.name, analysis.Sorted, test.sorted)
+		}
+		if analysis.Duplicates != test.duplicates {
+			t.Errorf("%q: unexpected duplicates -- got %v, want %v",
+				test.name, analysis.Duplicates, test.duplicates)
+		}
+		wantAddr, err := dcrutil.NewAddressScriptHash(test.script,
+			mainNetParams)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if analysis.Address.String() != wantAddr.String() {
+			t.Errorf("%q: unexpected address -- got %v, want %v",
+				test.name, analysis.Address, wantAddr)
+		}
+	}
+}
@@ -2433,7 +2433,7 @@ func opcodeHash256(op *opcode, data []byte, vm *Engine) error {
 // cryptographic methods against the provided public key.
 //
 // Stack transformation: [... signature pubkey] -> [... bool]
-func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
+func opcodeCheckSig(op *opcode, data []byte, vm *Engine) (err error) {
 	pkBytes, err := vm.dstack.PopByteArray()
 	if err != nil {
 		return err
@@ -2444,6 +2444,18 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		return err
 	}
 
+	// Record how the result is determined when the checks are observed.
+	var diag *CheckSigDiagnostics
+	if vm.observeSigChecks() {
+		diag = newCheckSigDiagnostics(vm, op.value, dcrec.STEcdsaSecp256k1)
+		diag.setSignature(fullSigBytes)
+		diag.PubKey = pkBytes
+		defer func() {
+			diag.Err = err
+			vm.observeCheckSig(diag)
+		}()
+	}
+
 	// The signature actually needs to be longer than this, but at
 	// least 1 byte is needed for the hash type below.  The full length is
 	// checked depending on the script flags and upon parsing the signature.
@@ -2452,6 +2464,20 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		return nil
 	}
 
+	// Defer to the signature verifier of the engine when one is set.
+	if vm.sigVerifier != nil {
+		subScript := removeOpcodeByData(vm.subScript(), fullSigBytes)
+		valid := vm.verifySig(dcrec.STEcdsaSecp256k1, fullSigBytes, pkBytes,
+			subScript)
+		if diag != nil {
+			diag.SubScript = subScript
+			diag.SigHash = vm.verifierSigHash(fullSigBytes, subScript)
+			diag.Valid = valid
+		}
+		vm.dstack.PushBool(valid)
+		return nil
+	}
+
 	// Trim off hashtype from the signature string and check if the
 	// signature and pubkey conform to the strict encoding requirements.
 	//
@@ -2479,6 +2505,9 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 	// Remove the signature since there is no way for a signature to sign
 	// itself.
 	subScript = removeOpcodeByData(subScript, fullSigBytes)
+	if diag != nil {
+		diag.SubScript = subScript
+	}
 
 	// Generate the signature hash based on the signature hash type.
 	var prefixHash *chainhash.Hash
@@ -2488,18 +2517,30 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 	hash, err := calcSignatureHash(subScript, hashType, &vm.tx, vm.txIdx,
 		prefixHash)
 	if err != nil {
+		if diag != nil {
+			diag.ParseErr = err
+		}
 		vm.dstack.PushBool(false)
 		return nil
 	}
+	if diag != nil {
+		diag.SigHash = hash
+	}
 
 	pubKey, err := secp256k1.ParsePubKey(pkBytes)
 	if err != nil {
+		if diag != nil {
+			diag.ParseErr = err
+		}
 		vm.dstack.PushBool(false)
 		return nil
 	}
 
 	signature, err := ecdsa.ParseDERSignature(sigBytes)
 	if err != nil {
+		if diag != nil {
+			diag.ParseErr = err
+		}
 		vm.dstack.PushBool(false)
 		return nil
 	}
@@ -2510,6 +2551,9 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		copy(sigHash[:], hash)
 
 		valid = vm.sigCache.Exists(sigHash, signature, pubKey)
+		if diag != nil {
+			diag.SigCacheHit = valid
+		}
 		if !valid && signature.Verify(hash, pubKey) {
 			vm.sigCache.Add(sigHash, signature, pubKey)
 			valid = true
@@ -2518,6 +2562,9 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		valid = signature.Verify(hash, pubKey)
 	}
 
+	if diag != nil {
+		diag.Valid = valid
+	}
 	vm.dstack.PushBool(valid)
 	return nil
 }
@@ -2557,7 +2604,7 @@ type parsedSigInfo struct {
 //
 // Stack transformation:
 // [... [sig ...] numsigs [pubkey ...] numpubkeys] -> [... bool]
-func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
+func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) (err error) {
 	numKeys, err := vm.dstack.PopInt(MathOpCodeMaxScriptNumLen)
 	if err != nil {
 		return err
@@ -2616,6 +2663,17 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 		signatures = append(signatures, sigInfo)
 	}
 
+	// Record how the signatures are matched to the public keys when the
+	// checks are observed.
+	var diag *MultiSigDiagnostics
+	if vm.observeSigChecks() {
+		diag = newMultiSigDiagnostics(vm, op.value, pubKeys, signatures)
+		defer func() {
+			diag.Err = err
+			vm.observeMultiSig(diag)
+		}()
+	}
+
 	// Get script starting from the most recent OP_CODESEPARATOR.
 	script := vm.subScript()
 
@@ -2642,6 +2700,12 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 
 		sigInfo := signatures[signatureIdx]
 		pubKey := pubKeys[pubKeyIdx]
+		var sigDiag *MultiSigSignature
+		if diag != nil {
+			sigDiag = diag.signature(signatureIdx)
+			sigDiag.Tried = append(sigDiag.Tried,
+				diag.pubKeyIdx(pubKeyIdx))
+		}
 
 		// The order of the signature and public key evaluation is
 		// important here since it can be distinguished by an
@@ -2653,6 +2717,20 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 			continue
 		}
 
+		// Defer to the signature verifier of the engine when one is set.
+		if vm.sigVerifier != nil {
+			if vm.verifySig(dcrec.STEcdsaSecp256k1, rawSig, pubKey,
+				script) {
+
+				if sigDiag != nil {
+					sigDiag.PubKeyIdx = diag.pubKeyIdx(pubKeyIdx)
+				}
+				signatureIdx++
+				numSignatures--
+			}
+			continue
+		}
+
 		// Split the signature into hash type and signature components.
 		hashType := SigHashType(rawSig[len(rawSig)-1])
 		signature := rawSig[:len(rawSig)-1]
@@ -2661,9 +2739,15 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 		var parsedSig *ecdsa.Signature
 		if !sigInfo.parsed {
 			if err := CheckHashTypeEncoding(hashType); err != nil {
+				if sigDiag != nil {
+					sigDiag.ParseErr = err
+				}
 				return err
 			}
 			if err := CheckSignatureEncoding(signature); err != nil {
+				if sigDiag != nil {
+					sigDiag.ParseErr = err
+				}
 				return err
 			}
 
@@ -2672,6 +2756,9 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 			parsedSig, err = ecdsa.ParseDERSignature(signature)
 			sigInfo.parsed = true
 			if err != nil {
+				if sigDiag != nil {
+					sigDiag.ParseErr = err
+				}
 				continue
 			}
 			sigInfo.parsedSignature = parsedSig
@@ -2686,12 +2773,18 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 		}
 
 		if err := CheckPubKeyEncoding(pubKey); err != nil {
+			if diag != nil {
+				diag.PubKeyErrs[diag.pubKeyIdx(pubKeyIdx)] = err
+			}
 			return err
 		}
 
 		// Parse the pubkey.
 		parsedPubKey, err := secp256k1.ParsePubKey(pubKey)
 		if err != nil {
+			if diag != nil {
+				diag.PubKeyErrs[diag.pubKeyIdx(pubKeyIdx)] = err
+			}
 			continue
 		}
 
@@ -2722,11 +2815,17 @@ func opcodeCheckMultiSig(op *opcode, data []byte, vm *Engine) error {
 
 		if valid {
 			// PubKey verified, move on to the next signature.
+			if sigDiag != nil {
+				sigDiag.PubKeyIdx = diag.pubKeyIdx(pubKeyIdx)
+			}
 			signatureIdx++
 			numSignatures--
 		}
 	}
 
+	if diag != nil {
+		diag.Success = success
+	}
 	vm.dstack.PushBool(success)
 	return nil
 }
@@ -2755,12 +2854,23 @@ func opcodeCheckMultiSigVerify(op *opcode, data []byte, vm *Engine) error {
 // Failing to parse a pubkey or signature results in false.
 // After parsing, the signature and pubkey are verified against the message
 // (the hash of this transaction and its input).
-func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
+func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) (err error) {
 	sigType, err := vm.dstack.PopInt(altSigSuitesMaxscriptNumLen)
 	if err != nil {
 		return err
 	}
 
+	// Record how the result is determined when the checks are observed.
+	var diag *CheckSigDiagnostics
+	if vm.observeSigChecks() {
+		diag = newCheckSigDiagnostics(vm, op.value,
+			dcrec.SignatureType(sigType))
+		defer func() {
+			diag.Err = err
+			vm.observeCheckSig(diag)
+		}()
+	}
+
 	switch sigType {
 	case 0:
 		// Zero case; pre-softfork clients will return 0 in this case as well.
@@ -2773,6 +2883,9 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 	default:
 		// Caveat: All unknown signature types return true, allowing for future
 		// softforks with other new signature types.
+		if diag != nil {
+			diag.Valid = true
+		}
 		vm.dstack.PushBool(true)
 		return nil
 	}
@@ -2781,6 +2894,34 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 	if err != nil {
 		return err
 	}
+	if diag != nil {
+		diag.PubKey = pkBytes
+	}
+
+	// Defer to the signature verifier of the engine when one is set.
+	if vm.sigVerifier != nil {
+		fullSigBytes, err := vm.dstack.PopByteArray()
+		if err != nil {
+			return err
+		}
+		if diag != nil {
+			diag.setSignature(fullSigBytes)
+		}
+		if len(fullSigBytes) == 0 {
+			vm.dstack.PushBool(false)
+			return nil
+		}
+		subScript := removeOpcodeByData(vm.subScript(), fullSigBytes)
+		valid := vm.verifySig(dcrec.SignatureType(sigType), fullSigBytes,
+			pkBytes, subScript)
+		if diag != nil {
+			diag.SubScript = subScript
+			diag.SigHash = vm.verifierSigHash(fullSigBytes, subScript)
+			diag.Valid = valid
+		}
+		vm.dstack.PushBool(valid)
+		return nil
+	}
 
 	// Check the public key lengths. Only 33-byte compressed secp256k1 keys
 	// are allowed for secp256k1 Schnorr signatures, which 32 byte keys
@@ -2788,11 +2929,19 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 	switch sigType {
 	case dcrec.STEd25519:
 		if len(pkBytes) != 32 {
+			if diag != nil {
+				diag.ParseErr = scriptError(ErrPubKeyType, fmt.Sprintf(
+					"public key length %d is not 32", len(pkBytes)))
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
 	case dcrec.STSchnorrSecp256k1:
 		if len(pkBytes) != 33 {
+			if diag != nil {
+				diag.ParseErr = scriptError(ErrPubKeyType, fmt.Sprintf(
+					"public key length %d is not 33", len(pkBytes)))
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
@@ -2802,17 +2951,30 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 	if err != nil {
 		return err
 	}
+	if diag != nil {
+		diag.setSignature(fullSigBytes)
+	}
 
 	// Schnorr signatures are 65 bytes in length (64 bytes for [r,s] and
 	// 1 byte appended to the end for hashType).
 	switch sigType {
 	case dcrec.STEd25519:
 		if len(fullSigBytes) != 65 {
+			if diag != nil {
+				diag.ParseErr = scriptError(ErrSigInvalidDataLen,
+					fmt.Sprintf("signature length %d is not 65",
+						len(fullSigBytes)))
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
 	case dcrec.STSchnorrSecp256k1:
 		if len(fullSigBytes) != 65 {
+			if diag != nil {
+				diag.ParseErr = scriptError(ErrSigInvalidDataLen,
+					fmt.Sprintf("signature length %d is not 65",
+						len(fullSigBytes)))
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
@@ -2842,6 +3004,9 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 	// Remove the signature since there is no way for a signature to sign
 	// itself.
 	subScript = removeOpcodeByData(subScript, fullSigBytes)
+	if diag != nil {
+		diag.SubScript = subScript
+	}
 
 	// Generate the signature hash based on the signature hash type.
 	var prefixHash *chainhash.Hash
@@ -2854,38 +3019,62 @@ func opcodeCheckSigAlt(op *opcode, data []byte, vm *Engine) error {
 	hash, err := calcSignatureHash(subScript, hashType, &vm.tx, vm.txIdx,
 		prefixHash)
 	if err != nil {
+		if diag != nil {
+			diag.ParseErr = err
+		}
 		vm.dstack.PushBool(false)
 		return nil
 	}
+	if diag != nil {
+		diag.SigHash = hash
+	}
 
 	// Get the public key from bytes.
 	switch sigType {
 	case dcrec.STEd25519:
 		pubKeyEd, err := edwards.ParsePubKey(pkBytes)
 		if err != nil {
+			if diag != nil {
+				diag.ParseErr = err
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
 		sigEd, err := edwards.ParseSignature(sigBytes)
 		if err != nil {
+			if diag != nil {
+				diag.ParseErr = err
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
 		ok := edwards.Verify(pubKeyEd, hash, sigEd.GetR(), sigEd.GetS())
+		if diag != nil {
+			diag.Valid = ok
+		}
 		vm.dstack.PushBool(ok)
 		return nil
 	case dcrec.STSchnorrSecp256k1:
 		pubKeySec, err := schnorr.ParsePubKey(pkBytes)
 		if err != nil {
+			if diag != nil {
+				diag.ParseErr = err
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
 		sigSec, err := schnorr.ParseSignature(sigBytes)
 		if err != nil {
+			if diag != nil {
+				diag.ParseErr = err
+			}
 			vm.dstack.PushBool(false)
 			return nil
 		}
 		ok := sigSec.Verify(hash, pubKeySec)
+		if diag != nil {
+			diag.Valid = ok
+		}
 		vm.dstack.PushBool(ok)
 		return nil
 	}
@@ -0,0 +1,397 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// PartialTxInput houses the information needed by the co-signers of an input
+// of a partially signed transaction to sign it independently and by anyone to
+// finalize it once enough signatures are collected.
+type PartialTxInput struct {
+	// PkScript is the public key script of the output spent by the input.
+	PkScript []byte
+
+	// RedeemScript is the redeem script when the spent output is a
+	// pay-to-script-hash output and nil otherwise.
+	RedeemScript []byte
+
+	// HashType is the signature hash type of the signatures of the input.
+	HashType SigHashType
+
+	// Sigs maps the hex-encoded serialized public keys of the signers to
+	// their signatures, including the trailing hash type byte.
+	Sigs map[string][]byte
+}
+
+// PartialTx is a transaction whose inputs are signed by multiple parties.  It
+// is serialized as JSON with all scripts, keys and signatures encoded as hex so
+// it can be passed between co-signers, who each add their signatures with
+// Sign.  The signatures collected separately are merged with Combine and
+// Finalize produces the fully signed transaction.
+//
+// Inputs spending pay-to-pubkey, pay-to-pubkey-hash and multisig scripts,
+// either bare or through pay-to-script-hash, signed with secp256k1 ECDSA keys
+// are supported.
+type PartialTx struct {
+	Tx     *wire.MsgTx
+	Inputs []PartialTxInput
+}
+
+// NewPartialTx returns a partially signed transaction for the provided
+// unsigned transaction with no signatures.  The signature hash type of all
+// inputs is SigHashAll and the scripts of the spent outputs must be set
+// before signing.
+func NewPartialTx(tx *wire.MsgTx) *PartialTx {
+	inputs := make([]PartialTxInput, len(tx.TxIn))
+	for i := range inputs {
+		inputs[i].HashType = SigHashAll
+		inputs[i].Sigs = make(map[string][]byte)
+	}
+	return &PartialTx{Tx: tx, Inputs: inputs}
+}
+
+// partialTxJSON is the JSON serialization of a partially signed transaction.
+type partialTxJSON struct {
+	Tx     string               `json:"tx"`
+	Inputs []partialTxInputJSON `json:"inputs"`
+}
+
+// partialTxInputJSON is the JSON serialization of an input of a partially
+// signed transaction.
+type partialTxInputJSON struct {
+	PkScript     string            `json:"pkScript"`
+	RedeemScript string            `json:"redeemScript,omitempty"`
+	HashType     SigHashType       `json:"hashType"`
+	Sigs         map[string]string `json:"sigs"`
+}
+
+// MarshalJSON returns the JSON serialization of the partially signed
+// transaction.
+func (p *PartialTx) MarshalJSON() ([]byte, error) {
+	txBytes, err := p.Tx.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	pj := partialTxJSON{
+		Tx:     hex.EncodeToString(txBytes),
+		Inputs: make([]partialTxInputJSON, len(p.Inputs)),
+	}
+	for i, in := range p.Inputs {
+		sigs := make(map[string]string, len(in.Sigs))
+		for pubKey, sig := range in.Sigs {
+			sigs[pubKey] = hex.EncodeToString(sig)
+		}
+		pj.Inputs[i] = partialTxInputJSON{
+			PkScript:     hex.EncodeToString(in.PkScript),
+			RedeemScript: hex.EncodeToString(in.RedeemScript),
+			HashType:     in.HashType,
+			Sigs:         sigs,
+		}
+	}
+	return json.Marshal(&pj)
+}
+
+// UnmarshalJSON decodes a partially signed transaction serialized by
+// MarshalJSON.
+func (p *PartialTx) UnmarshalJSON(b []byte) error {
+	var pj partialTxJSON
+	if err := json.Unmarshal(b, &pj); err != nil {
+		return err
+	}
+	txBytes, err := hex.DecodeString(pj.Tx)
+	if err != nil {
+		return fmt.Errorf("invalid transaction: %v", err)
+	}
+	var tx wire.MsgTx
+	if err := tx.FromBytes(txBytes); err != nil {
+		return fmt.Errorf("invalid transaction: %v", err)
+	}
+	if len(pj.Inputs) != len(tx.TxIn) {
+		return fmt.Errorf("%d inputs described for a transaction with %d "+
+			"inputs", len(pj.Inputs), len(tx.TxIn))
+	}
+
+	inputs := make([]PartialTxInput, len(pj.Inputs))
+	for i, in := range pj.Inputs {
+		pkScript, err := hex.DecodeString(in.PkScript)
+		if err != nil {
+			return fmt.Errorf("input %d: invalid public key script: %v", i,
+				err)
+		}
+		var redeemScript []byte
+		if in.RedeemScript != "" {
+			redeemScript, err = hex.DecodeString(in.RedeemScript)
+			if err != nil {
+				return fmt.Errorf("input %d: invalid redeem script: %v",
+					i, err)
+			}
+		}
+		sigs := make(map[string][]byte, len(in.Sigs))
+		for pubKey, sigHex := range in.Sigs {
+			sig, err := hex.DecodeString(sigHex)
+			if err != nil {
+				return fmt.Errorf("input %d: invalid signature: %v", i,
+					err)
+			}
+			sigs[pubKey] = sig
+		}
+		inputs[i] = PartialTxInput{
+			PkScript:     pkScript,
+			RedeemScript: redeemScript,
+			HashType:     in.HashType,
+			Sigs:         sigs,
+		}
+	}
+	p.Tx, p.Inputs = &tx, inputs
+	return nil
+}
+
+// checkIndex returns an error when the provided input index is out of range.
+func (p *PartialTx) checkIndex(idx int) error {
+	if idx < 0 || idx >= len(p.Inputs) {
+		str := fmt.Sprintf("input index %d is out of range for a "+
+			"transaction with %d inputs", idx, len(p.Inputs))
+		return scriptError(ErrInvalidIndex, str)
+	}
+	return nil
+}
+
+// signingScript returns the script satisfied by the signatures of the input
+// with the provided index, which is the redeem script for pay-to-script-hash
+// outputs and the public key script otherwise.
+func (p *PartialTx) signingScript(idx int) ([]byte, error) {
+	in := &p.Inputs[idx]
+	if len(in.PkScript) == 0 {
+		str := fmt.Sprintf("input %d has no public key script", idx)
+		return nil, scriptError(ErrIncompletePartialTx, str)
+	}
+	if !IsPayToScriptHash(in.PkScript) {
+		return in.PkScript, nil
+	}
+	if len(in.RedeemScript) == 0 {
+		str := fmt.Sprintf("input %d spends a pay-to-script-hash output "+
+			"without a redeem script", idx)
+		return nil, scriptError(ErrIncompletePartialTx, str)
+	}
+	scriptHash := dcrutil.Hash160(in.RedeemScript)
+	if !bytes.Equal(ExtractScriptHash(in.PkScript), scriptHash) {
+		str := fmt.Sprintf("redeem script of input %d does not match the "+
+			"script hash of its output", idx)
+		return nil, scriptError(ErrPartialTxMismatch, str)
+	}
+	return in.RedeemScript, nil
+}
+
+// signingPubKeys returns the public keys that may sign the provided script
+// along with the number of required signatures.  The public key of
+// pay-to-pubkey-hash scripts is unknown and nil is returned for it instead.
+func signingPubKeys(idx int, script []byte) ([][]byte, int, error) {
+	if details := extractMultisigScriptDetails(0, script, true); details.valid {
+		return details.pubKeys, details.requiredSigs, nil
+	}
+	if pubKey := extractPubKey(script); pubKey != nil {
+		return [][]byte{pubKey}, 1, nil
+	}
+	if isPubKeyHashScript(script) {
+		return [][]byte{nil}, 1, nil
+	}
+	str := fmt.Sprintf("input %d spends an unsupported script", idx)
+	return nil, 0, scriptError(ErrUnsupportedPartialTxScript, str)
+}
+
+// Sign adds the signatures of all of the keys found in the provided key
+// database that sign the input with the provided index and returns the number
+// of signatures added.  Signing an input for which the database has no keys is
+// not an error.
+func (p *PartialTx) Sign(idx int, kdb KeyDB, chainParams dcrutil.AddressParams) (int, error) {
+	if err := p.checkIndex(idx); err != nil {
+		return 0, err
+	}
+	script, err := p.signingScript(idx)
+	if err != nil {
+		return 0, err
+	}
+	pubKeys, _, err := signingPubKeys(idx, script)
+	if err != nil {
+		return 0, err
+	}
+
+	in := &p.Inputs[idx]
+	if in.Sigs == nil {
+		in.Sigs = make(map[string][]byte)
+	}
+	var signed int
+	for _, pubKey := range pubKeys {
+		var addr dcrutil.Address
+		if pubKey == nil {
+			addr, err = dcrutil.NewAddressPubKeyHash(
+				extractPubKeyHash(script), chainParams,
+				dcrec.STEcdsaSecp256k1)
+		} else {
+			addr, err = dcrutil.NewAddressSecpPubKey(pubKey, chainParams)
+		}
+		if err != nil {
+			// Invalid public keys can not be signed for.
+			continue
+		}
+		key, sigType, _, err := kdb.GetKey(addr)
+		if err != nil || sigType != dcrec.STEcdsaSecp256k1 {
+			continue
+		}
+		if pubKey == nil {
+			priv := secp256k1.PrivKeyFromBytes(key)
+			pubKey = priv.PubKey().SerializeCompressed()
+		}
+
+		sig, err := RawTxInSignature(p.Tx, idx, script, in.HashType, key,
+			sigType)
+		if err != nil {
+			return signed, err
+		}
+		in.Sigs[hex.EncodeToString(pubKey)] = sig
+		signed++
+	}
+	return signed, nil
+}
+
+// Combine merges the scripts and signatures of another partially signed copy
+// of the same transaction into p.  Scripts missing from p are taken from the
+// other copy and it is an error for the transactions, scripts or hash types to
+// differ otherwise.
+func (p *PartialTx) Combine(other *PartialTx) error {
+	if p.Tx.TxHash() != other.Tx.TxHash() ||
+		len(p.Inputs) != len(other.Inputs) {
+
+		return scriptError(ErrPartialTxMismatch, "partially signed "+
+			"transactions are not for the same transaction")
+	}
+	for i := range p.Inputs {
+		in, otherIn := &p.Inputs[i], &other.Inputs[i]
+		if in.HashType != otherIn.HashType {
+			str := fmt.Sprintf("input %d has hash types %v and %v", i,
+				in.HashType, otherIn.HashType)
+			return scriptError(ErrPartialTxMismatch, str)
+		}
+		merge := func(script *[]byte, otherScript []byte, name string) error {
+			switch {
+			case len(otherScript) == 0:
+			case len(*script) == 0:
+				*script = otherScript
+			case !bytes.Equal(*script, otherScript):
+				str := fmt.Sprintf("input %d has different %s scripts",
+					i, name)
+				return scriptError(ErrPartialTxMismatch, str)
+			}
+			return nil
+		}
+		if err := merge(&in.PkScript, otherIn.PkScript, "public key"); err != nil {
+			return err
+		}
+		if err := merge(&in.RedeemScript, otherIn.RedeemScript, "redeem"); err != nil {
+			return err
+		}
+		if in.Sigs == nil {
+			in.Sigs = make(map[string][]byte, len(otherIn.Sigs))
+		}
+		for pubKey, sig := range otherIn.Sigs {
+			if _, ok := in.Sigs[pubKey]; !ok {
+				in.Sigs[pubKey] = sig
+			}
+		}
+	}
+	return nil
+}
+
+// signatureScript returns the signature script of the input with the provided
+// index formed by its collected signatures.
+func (p *PartialTx) signatureScript(idx int) ([]byte, error) {
+	script, err := p.signingScript(idx)
+	if err != nil {
+		return nil, err
+	}
+	pubKeys, nRequired, err := signingPubKeys(idx, script)
+	if err != nil {
+		return nil, err
+	}
+
+	// The public key of pay-to-pubkey-hash scripts is the one whose hash
+	// matches the script.
+	in := &p.Inputs[idx]
+	isPubKeyHash := pubKeys[0] == nil
+	if isPubKeyHash {
+		pkHash := extractPubKeyHash(script)
+		for pubKeyHex := range in.Sigs {
+			pubKey, err := hex.DecodeString(pubKeyHex)
+			if err == nil && bytes.Equal(dcrutil.Hash160(pubKey), pkHash) {
+				pubKeys[0] = pubKey
+				break
+			}
+		}
+	}
+
+	// Signatures of multisig scripts must be in the order of their public
+	// keys.
+	builder := NewScriptBuilder()
+	var signed int
+	for _, pubKey := range pubKeys {
+		sig, ok := in.Sigs[hex.EncodeToString(pubKey)]
+		if !ok || pubKey == nil {
+			continue
+		}
+		builder.AddData(sig)
+		if isPubKeyHash {
+			builder.AddData(pubKey)
+		}
+		signed++
+		if signed == nRequired {
+			break
+		}
+	}
+	if signed < nRequired {
+		str := fmt.Sprintf("input %d has %d of the %d required signatures",
+			idx, signed, nRequired)
+		return nil, scriptError(ErrIncompletePartialTx, str)
+	}
+	if len(in.RedeemScript) != 0 {
+		builder.AddData(in.RedeemScript)
+	}
+	return builder.Script()
+}
+
+// Finalize returns a copy of the transaction with the signature scripts of all
+// inputs formed by the collected signatures.  Every input is executed by the
+// engine with the provided flags and the first failure is returned instead of
+// the transaction.  ErrIncompletePartialTx is returned when an input does not
+// have enough signatures.
+func (p *PartialTx) Finalize(flags ScriptFlags) (*wire.MsgTx, error) {
+	tx := p.Tx.Copy()
+	for i := range p.Inputs {
+		sigScript, err := p.signatureScript(i)
+		if err != nil {
+			return nil, err
+		}
+		tx.TxIn[i].SignatureScript = sigScript
+	}
+	for i, in := range p.Inputs {
+		vm, err := NewEngine(in.PkScript, tx, i, flags, 0, nil)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	return tx, nil
+}
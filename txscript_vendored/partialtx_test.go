@@ -0,0 +1,150 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestPartialTx ensures co-signers can sign copies of a partially signed
+// transaction independently, pass them around as JSON and combine them into a
+// valid transaction.
+func TestPartialTx(t *testing.T) {
+	t.Parallel()
+
+	// Create three co-signers with a key database each.
+	keys := testSecpKeys(t, 3)
+	var kdbs [3]*FileKeyDB
+	var pubKeyAddrs []*dcrutil.AddressSecpPubKey
+	for i, key := range keys {
+		kdbs[i] = NewFileKeyDB()
+		kdbs[i].AddKey(key.wif)
+		pubKeyAddrs = append(pubKeyAddrs, key.addr)
+	}
+
+	// The first input spends a 2-of-3 multisig pay-to-script-hash output
+	// and the second a pay-to-pubkey-hash output of the third co-signer.
+	redeemScript, err := MultiSigScript(pubKeyAddrs, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p2shScript, err := PayToScriptHashScript(dcrutil.Hash160(redeemScript))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p2pkhScript, err := PayToAddrScript(pubKeyAddrs[2].AddressPubKeyHash())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx := wire.NewMsgTx()
+	for i := uint32(0); i < 2; i++ {
+		prevOut := wire.NewOutPoint(&chainhash.Hash{1}, i,
+			wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, 1e8, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(2e8-1e4, p2pkhScript))
+
+	ptx := NewPartialTx(tx)
+	ptx.Inputs[0].PkScript = p2shScript
+	ptx.Inputs[0].RedeemScript = redeemScript
+	ptx.Inputs[1].PkScript = p2pkhScript
+
+	// Ensure finalizing fails without signatures.
+	if _, err := ptx.Finalize(0); !errors.Is(err, ErrIncompletePartialTx) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrIncompletePartialTx)
+	}
+
+	// Pass copies of the partially signed transaction to the first and
+	// third co-signers as JSON.
+	b, err := json.Marshal(ptx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var copies [2]PartialTx
+	for i, kdb := range []KeyDB{kdbs[0], kdbs[2]} {
+		if err := json.Unmarshal(b, &copies[i]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for idx := range copies[i].Inputs {
+			if _, err := copies[i].Sign(idx, kdb, testingParams); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	numSigs := func(p *PartialTx) int {
+		var n int
+		for _, in := range p.Inputs {
+			n += len(in.Sigs)
+		}
+		return n
+	}
+	if n := numSigs(&copies[0]); n != 1 {
+		t.Fatalf("unexpected number of signatures of the first "+
+			"co-signer %d", n)
+	}
+	if n := numSigs(&copies[1]); n != 2 {
+		t.Fatalf("unexpected number of signatures of the third "+
+			"co-signer %d", n)
+	}
+
+	// Ensure the copies combine into a valid transaction after a JSON
+	// round trip.
+	b, err = json.Marshal(&copies[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var signed PartialTx
+	if err := json.Unmarshal(b, &signed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := copies[0].Combine(&signed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	finalTx, err := copies[0].Finalize(StandardScriptFlags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if finalTx.TxHash() != tx.TxHash() {
+		t.Fatal("finalized transaction differs from the unsigned one")
+	}
+	if len(tx.TxIn[0].SignatureScript) != 0 {
+		t.Fatal("finalizing modified the unsigned transaction")
+	}
+
+	// Ensure partially signed copies of other transactions or with
+	// different scripts are not combined.
+	otherTx := tx.Copy()
+	otherTx.LockTime = 1
+	if err := copies[0].Combine(NewPartialTx(otherTx)); !errors.Is(err,
+		ErrPartialTxMismatch) {
+
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrPartialTxMismatch)
+	}
+	other := NewPartialTx(tx)
+	other.Inputs[1].PkScript = p2shScript
+	if err := copies[0].Combine(other); !errors.Is(err,
+		ErrPartialTxMismatch) {
+
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrPartialTxMismatch)
+	}
+
+	// Ensure unsupported scripts are reported.
+	other.Inputs[1].PkScript = mustParseShortForm("1")
+	if _, err := other.Sign(1, kdbs[0], testingParams); !errors.Is(err,
+		ErrUnsupportedPartialTxScript) {
+
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrUnsupportedPartialTxScript)
+	}
+}
@@ -12,9 +12,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"regexp"
-	"strconv"
-	"strings"
 	"testing"
 
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -22,26 +19,6 @@ import (
 	"github.com/decred/dcrd/wire"
 )
 
-var (
-	// tokenRE is a regular expression used to parse tokens from short form
-	// scripts.  It splits on repeated tokens and spaces.  Repeated tokens are
-	// denoted by being wrapped in angular brackets followed by a suffix which
-	// consists of a number inside braces.
-	tokenRE = regexp.MustCompile(`\<.+?\>\{[0-9]+\}|[^\s]+`)
-
-	// repTokenRE is a regular expression used to parse short form scripts
-	// for a series of tokens repeated a specified number of times.
-	repTokenRE = regexp.MustCompile(`^\<(.+)\>\{([0-9]+)\}$`)
-
-	// repRawRE is a regular expression used to parse short form scripts
-	// for raw data that is to be repeated a specified number of times.
-	repRawRE = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+)\{([0-9]+)\}$`)
-
-	// repQuoteRE is a regular expression used to parse short form scripts for
-	// quoted data that is to be repeated a specified number of times.
-	repQuoteRE = regexp.MustCompile(`^'(.*)'\{([0-9]+)\}$`)
-)
-
 // scriptTestName returns a descriptive test name for the given reference script
 // test data.
 func scriptTestName(test []string) (string, error) {
@@ -65,323 +42,11 @@ func scriptTestName(test []string) (string, error) {
 	return name, nil
 }
 
-// parse hex string into a []byte.
-func parseHex(tok string) ([]byte, error) {
-	if !strings.HasPrefix(tok, "0x") {
-		return nil, errors.New("not a hex number")
-	}
-	return hex.DecodeString(tok[2:])
-}
-
-// shortFormOps holds a map of opcode names to values for use in short form
-// parsing.  It is declared here so it only needs to be created once.
-var shortFormOps map[string]byte
-
-// parseShortForm parses a string as used in the reference tests into the
-// script it came from.
-//
-// The format used for these tests is pretty simple if ad-hoc:
-//   - Opcodes other than the push opcodes and unknown are present as either
-//     OP_NAME or just NAME
-//   - Plain numbers are made into push operations
-//   - Numbers beginning with 0x are inserted into the []byte as-is (so 0x14 is
-//     OP_DATA_20)
-//   - Numbers beginning with 0x which have a suffix which consists of a number
-//     in braces (e.g. 0x6161{10}) repeat the raw bytes the specified number of
-//     times and are inserted as-is
-//   - Single quoted strings are pushed as data
-//   - Single quoted strings that have a suffix which consists of a number in
-//     braces (e.g. 'b'{10}) repeat the data the specified number of times and
-//     are pushed as a single data push
-//   - Tokens inside of angular brackets with a suffix which consists of a
-//     number in braces (e.g. <0 0 CHECKMULTSIG>{5}) is parsed as if the tokens
-//     inside the angular brackets were manually repeated the specified number
-//     of times
-//   - Anything else is an error
-func parseShortForm(script string) ([]byte, error) {
-	// Only create the short form opcode map once.
-	if shortFormOps == nil {
-		ops := make(map[string]byte)
-		for opcodeName, opcodeValue := range OpcodeByName {
-			if strings.Contains(opcodeName, "OP_UNKNOWN") {
-				continue
-			}
-			ops[opcodeName] = opcodeValue
-
-			// The opcodes named OP_# can't have the OP_ prefix
-			// stripped or they would conflict with the plain
-			// numbers.  Also, since OP_FALSE and OP_TRUE are
-			// aliases for the OP_0, and OP_1, respectively, they
-			// have the same value, so detect those by name and
-			// allow them.
-			if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
-				(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
-					opcodeValue > OP_16)) {
-
-				ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
-			}
-		}
-		shortFormOps = ops
-	}
-
-	builder := NewScriptBuilder()
-
-	var handleToken func(tok string) error
-	handleToken = func(tok string) error {
-		// Multiple repeated tokens.
-		if m := repTokenRE.FindStringSubmatch(tok); m != nil {
-			count, err := strconv.ParseInt(m[2], 10, 32)
-			if err != nil {
-				return fmt.Errorf("bad token %q", tok)
-			}
-			tokens := tokenRE.FindAllStringSubmatch(m[1], -1)
-			for i := 0; i < int(count); i++ {
-				for _, t := range tokens {
-					if err := handleToken(t[0]); err != nil {
-						return err
-					}
-				}
-			}
-			return nil
-		}
-
-		// Plain number.
-		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
-			builder.AddInt64(num)
-			return nil
-		}
-
-		// Raw data.
-		if bts, err := parseHex(tok); err == nil {
-			// Concatenate the bytes manually since the test code
-			// intentionally creates scripts that are too large and
-			// would cause the builder to error otherwise.
-			if builder.err == nil {
-				builder.script = append(builder.script, bts...)
-			}
-			return nil
-		}
-
-		// Repeated raw bytes.
-		if m := repRawRE.FindStringSubmatch(tok); m != nil {
-			bts, err := parseHex(m[1])
-			if err != nil {
-				return fmt.Errorf("bad token %q", tok)
-			}
-			count, err := strconv.ParseInt(m[2], 10, 32)
-			if err != nil {
-				return fmt.Errorf("bad token %q", tok)
-			}
-
-			// Concatenate the bytes manually since the test code
-			// intentionally creates scripts that are too large and
-			// would cause the builder to error otherwise.
-			bts = bytes.Repeat(bts, int(count))
-			if builder.err == nil {
-				builder.script = append(builder.script, bts...)
-			}
-			return nil
-		}
-
-		// Quoted data.
-		if len(tok) >= 2 && tok[0] == '\'' && tok[len(tok)-1] == '\'' {
-			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
-			return nil
-		}
-
-		// Repeated quoted data.
-		if m := repQuoteRE.FindStringSubmatch(tok); m != nil {
-			count, err := strconv.ParseInt(m[2], 10, 32)
-			if err != nil {
-				return fmt.Errorf("bad token %q", tok)
-			}
-			data := strings.Repeat(m[1], int(count))
-			builder.AddFullData([]byte(data))
-			return nil
-		}
-
-		// Named opcode.
-		if opcode, ok := shortFormOps[tok]; ok {
-			builder.AddOp(opcode)
-			return nil
-		}
-
-		return fmt.Errorf("bad token %q", tok)
-	}
-
-	for _, tokens := range tokenRE.FindAllStringSubmatch(script, -1) {
-		if err := handleToken(tokens[0]); err != nil {
-			return nil, err
-		}
-	}
-	return builder.Script()
-}
-
-// parseScriptFlags parses the provided flags string from the format used in the
-// reference tests into ScriptFlags suitable for use in the script engine.
-func parseScriptFlags(flagStr string) (ScriptFlags, error) {
-	var flags ScriptFlags
-
-	sFlags := strings.Split(flagStr, ",")
-	for _, flag := range sFlags {
-		switch flag {
-		case "":
-			// Nothing.
-		case "CHECKLOCKTIMEVERIFY":
-			flags |= ScriptVerifyCheckLockTimeVerify
-		case "CHECKSEQUENCEVERIFY":
-			flags |= ScriptVerifyCheckSequenceVerify
-		case "CLEANSTACK":
-			flags |= ScriptVerifyCleanStack
-		case "DISCOURAGE_UPGRADABLE_NOPS":
-			flags |= ScriptDiscourageUpgradableNops
-		case "NONE":
-			// Nothing.
-		case "SIGPUSHONLY":
-			flags |= ScriptVerifySigPushOnly
-		case "SHA256":
-			flags |= ScriptVerifySHA256
-		case "TREASURY":
-			flags |= ScriptVerifyTreasury
-		default:
-			return flags, fmt.Errorf("invalid flag: %s", flag)
-		}
-	}
-	return flags, nil
-}
-
-// parseExpectedResult parses the provided expected result string into allowed
-// script error kinds.  An error is returned if the expected result string is
-// not supported.
-func parseExpectedResult(expected string) ([]ErrorKind, error) {
-	switch expected {
-	case "OK":
-		return nil, nil
-	case "ERR_EARLY_RETURN":
-		return []ErrorKind{ErrEarlyReturn}, nil
-	case "ERR_EMPTY_STACK":
-		return []ErrorKind{ErrEmptyStack}, nil
-	case "ERR_EVAL_FALSE":
-		return []ErrorKind{ErrEvalFalse}, nil
-	case "ERR_SCRIPT_SIZE":
-		return []ErrorKind{ErrScriptTooBig}, nil
-	case "ERR_PUSH_SIZE":
-		return []ErrorKind{ErrElementTooBig}, nil
-	case "ERR_OP_COUNT":
-		return []ErrorKind{ErrTooManyOperations}, nil
-	case "ERR_STACK_SIZE":
-		return []ErrorKind{ErrStackOverflow}, nil
-	case "ERR_PUBKEY_COUNT":
-		return []ErrorKind{ErrInvalidPubKeyCount}, nil
-	case "ERR_SIG_COUNT":
-		return []ErrorKind{ErrInvalidSignatureCount}, nil
-	case "ERR_OUT_OF_RANGE":
-		return []ErrorKind{ErrNumOutOfRange}, nil
-	case "ERR_VERIFY":
-		return []ErrorKind{ErrVerify}, nil
-	case "ERR_EQUAL_VERIFY":
-		return []ErrorKind{ErrEqualVerify}, nil
-	case "ERR_DISABLED_OPCODE":
-		return []ErrorKind{ErrDisabledOpcode}, nil
-	case "ERR_RESERVED_OPCODE":
-		return []ErrorKind{ErrReservedOpcode}, nil
-	case "ERR_P2SH_STAKE_OPCODES":
-		return []ErrorKind{ErrP2SHStakeOpCodes}, nil
-	case "ERR_MALFORMED_PUSH":
-		return []ErrorKind{ErrMalformedPush}, nil
-	case "ERR_INVALID_STACK_OPERATION", "ERR_INVALID_ALTSTACK_OPERATION":
-		return []ErrorKind{ErrInvalidStackOperation}, nil
-	case "ERR_UNBALANCED_CONDITIONAL":
-		return []ErrorKind{ErrUnbalancedConditional}, nil
-	case "ERR_NEGATIVE_SUBSTR_INDEX":
-		return []ErrorKind{ErrNegativeSubstrIdx}, nil
-	case "ERR_OVERFLOW_SUBSTR_INDEX":
-		return []ErrorKind{ErrOverflowSubstrIdx}, nil
-	case "ERR_NEGATIVE_ROTATION":
-		return []ErrorKind{ErrNegativeRotation}, nil
-	case "ERR_OVERFLOW_ROTATION":
-		return []ErrorKind{ErrOverflowRotation}, nil
-	case "ERR_DIVIDE_BY_ZERO":
-		return []ErrorKind{ErrDivideByZero}, nil
-	case "ERR_NEGATIVE_SHIFT":
-		return []ErrorKind{ErrNegativeShift}, nil
-	case "ERR_OVERFLOW_SHIFT":
-		return []ErrorKind{ErrOverflowShift}, nil
-	case "ERR_MINIMAL_DATA":
-		return []ErrorKind{ErrMinimalData}, nil
-	case "ERR_SIG_HASH_TYPE":
-		return []ErrorKind{ErrInvalidSigHashType}, nil
-	case "ERR_SIG_TOO_SHORT":
-		return []ErrorKind{ErrSigTooShort}, nil
-	case "ERR_SIG_TOO_LONG":
-		return []ErrorKind{ErrSigTooLong}, nil
-	case "ERR_SIG_INVALID_SEQ_ID":
-		return []ErrorKind{ErrSigInvalidSeqID}, nil
-	case "ERR_SIG_INVALID_DATA_LEN":
-		return []ErrorKind{ErrSigInvalidDataLen}, nil
-	case "ERR_SIG_MISSING_S_TYPE_ID":
-		return []ErrorKind{ErrSigMissingSTypeID}, nil
-	case "ERR_SIG_MISSING_S_LEN":
-		return []ErrorKind{ErrSigMissingSLen}, nil
-	case "ERR_SIG_INVALID_S_LEN":
-		return []ErrorKind{ErrSigInvalidSLen}, nil
-	case "ERR_SIG_INVALID_R_INT_ID":
-		return []ErrorKind{ErrSigInvalidRIntID}, nil
-	case "ERR_SIG_ZERO_R_LEN":
-		return []ErrorKind{ErrSigZeroRLen}, nil
-	case "ERR_SIG_NEGATIVE_R":
-		return []ErrorKind{ErrSigNegativeR}, nil
-	case "ERR_SIG_TOO_MUCH_R_PADDING":
-		return []ErrorKind{ErrSigTooMuchRPadding}, nil
-	case "ERR_SIG_INVALID_S_INT_ID":
-		return []ErrorKind{ErrSigInvalidSIntID}, nil
-	case "ERR_SIG_ZERO_S_LEN":
-		return []ErrorKind{ErrSigZeroSLen}, nil
-	case "ERR_SIG_NEGATIVE_S":
-		return []ErrorKind{ErrSigNegativeS}, nil
-	case "ERR_SIG_TOO_MUCH_S_PADDING":
-		return []ErrorKind{ErrSigTooMuchSPadding}, nil
-	case "ERR_SIG_HIGH_S":
-		return []ErrorKind{ErrSigHighS}, nil
-	case "ERR_SIG_PUSHONLY":
-		return []ErrorKind{ErrNotPushOnly}, nil
-	case "ERR_PUBKEY_TYPE":
-		return []ErrorKind{ErrPubKeyType}, nil
-	case "ERR_CLEAN_STACK":
-		return []ErrorKind{ErrCleanStack}, nil
-	case "ERR_DISCOURAGE_UPGRADABLE_NOPS":
-		return []ErrorKind{ErrDiscourageUpgradableNOPs}, nil
-	case "ERR_NEGATIVE_LOCKTIME":
-		return []ErrorKind{ErrNegativeLockTime}, nil
-	case "ERR_UNSATISFIED_LOCKTIME":
-		return []ErrorKind{ErrUnsatisfiedLockTime}, nil
-	}
-
-	return nil, fmt.Errorf("unrecognized expected result in test data: %v",
-		expected)
-}
-
 // createSpendTx generates a basic spending transaction given the passed
 // signature and public key scripts.
 func createSpendingTx(sigScript, pkScript []byte) *wire.MsgTx {
-	coinbaseTx := wire.NewMsgTx()
-
-	outPoint := wire.NewOutPoint(&chainhash.Hash{}, ^uint32(0),
-		wire.TxTreeRegular)
-	txIn := wire.NewTxIn(outPoint, 0, []byte{OP_0, OP_0})
-	txOut := wire.NewTxOut(0, pkScript)
-	coinbaseTx.AddTxIn(txIn)
-	coinbaseTx.AddTxOut(txOut)
-
-	spendingTx := wire.NewMsgTx()
-	coinbaseTxHash := coinbaseTx.TxHash()
-	outPoint = wire.NewOutPoint(&coinbaseTxHash, 0, wire.TxTreeRegular)
-	txIn = wire.NewTxIn(outPoint, 0, sigScript)
-	txOut = wire.NewTxOut(0, nil)
-
-	spendingTx.AddTxIn(txIn)
-	spendingTx.AddTxOut(txOut)
-
+	// The default configuration is always valid.
+	spendingTx, _ := CreateSpendingTx(sigScript, pkScript, nil)
 	return spendingTx
 }
 
@@ -411,21 +76,21 @@ func testScripts(t *testing.T, tests [][]string, useSigCache bool) {
 		}
 
 		// Extract and parse the signature script from the test fields.
-		scriptSig, err := parseShortForm(test[0])
+		scriptSig, err := ParseShortFormScript(test[0])
 		if err != nil {
 			t.Errorf("%s: can't parse scriptSig; %v", name, err)
 			continue
 		}
 
 		// Extract and parse the public key script from the test fields.
-		scriptPubKey, err := parseShortForm(test[1])
+		scriptPubKey, err := ParseShortFormScript(test[1])
 		if err != nil {
 			t.Errorf("%s: can't parse scriptPubkey; %v", name, err)
 			continue
 		}
 
 		// Extract and parse the script flags from the test fields.
-		flags, err := parseScriptFlags(test[2])
+		flags, err := ParseVectorFlags(test[2])
 		if err != nil {
 			t.Errorf("%s: %v", name, err)
 			continue
@@ -438,7 +103,7 @@ func testScripts(t *testing.T, tests [][]string, useSigCache bool) {
 		// reference test data by allowing some of the test data errors to map
 		// to more than one possibility.
 		resultStr := test[3]
-		allowErrorKinds, err := parseExpectedResult(resultStr)
+		allowErrorKinds, err := ParseVectorResult(resultStr)
 		if err != nil {
 			t.Errorf("%s: %v", name, err)
 			continue
@@ -572,7 +237,7 @@ testloop:
 			continue
 		}
 
-		flags, err := parseScriptFlags(verifyFlags)
+		flags, err := ParseVectorFlags(verifyFlags)
 		if err != nil {
 			t.Errorf("bad test %d: %v", i, err)
 			continue
@@ -622,7 +287,7 @@ testloop:
 				continue testloop
 			}
 
-			script, err := parseShortForm(oscript)
+			script, err := ParseShortFormScript(oscript)
 			if err != nil {
 				t.Errorf("bad test (%dth input script doesn't "+
 					"parse %v) %d: %v", j, err, i, test)
@@ -714,7 +379,7 @@ testloop:
 			continue
 		}
 
-		flags, err := parseScriptFlags(verifyFlags)
+		flags, err := ParseVectorFlags(verifyFlags)
 		if err != nil {
 			t.Errorf("bad test %d: %v", i, err)
 			continue
@@ -764,7 +429,7 @@ testloop:
 				continue
 			}
 
-			script, err := parseShortForm(oscript)
+			script, err := ParseShortFormScript(oscript)
 			if err != nil {
 				t.Errorf("bad test (%dth input script doesn't "+
 					"parse %v) %d: %v", j, err, i, test)
@@ -799,21 +464,6 @@ testloop:
 	}
 }
 
-// parseSigHashExpectedResult parses the provided expected result string into
-// allowed error kinds.  An error is returned if the expected result string is
-// not supported.
-func parseSigHashExpectedResult(expected string) (error, error) {
-	switch expected {
-	case "OK":
-		return nil, nil
-	case "SIGHASH_SINGLE_IDX":
-		return ErrInvalidSigHashSingleIndex, nil
-	}
-
-	return nil, fmt.Errorf("unrecognized expected result in test data: %v",
-		expected)
-}
-
 // TestCalcSignatureHashReference runs the reference signature hash calculation
 // tests in sighash.json.
 func TestCalcSignatureHashReference(t *testing.T) {
@@ -908,7 +558,7 @@ func TestCalcSignatureHashReference(t *testing.T) {
 			t.Errorf("Test #%d: result field is not a string", i)
 			continue
 		}
-		expectedErr, err := parseSigHashExpectedResult(expectedErrStr)
+		expectedErr, err := ParseSigHashVectorResult(expectedErrStr)
 		if err != nil {
 			t.Errorf("Test #%d: %v", i, err)
 			continue
@@ -0,0 +1,361 @@
+// Copyright (c) 2013-2017 The btcsuite developers
+// Copyright (c) 2015-2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// This file houses the parsing of the formats used by the reference test
+// vectors in the data directory so they can be run and authored outside of the
+// tests.
+
+var (
+	// tokenRE is a regular expression used to parse tokens from short form
+	// scripts.  It splits on repeated tokens and spaces.  Repeated tokens are
+	// denoted by being wrapped in angular brackets followed by a suffix which
+	// consists of a number inside braces.
+	tokenRE = regexp.MustCompile(`\<.+?\>\{[0-9]+\}|[^\s]+`)
+
+	// repTokenRE is a regular expression used to parse short form scripts
+	// for a series of tokens repeated a specified number of times.
+	repTokenRE = regexp.MustCompile(`^\<(.+)\>\{([0-9]+)\}$`)
+
+	// repRawRE is a regular expression used to parse short form scripts
+	// for raw data that is to be repeated a specified number of times.
+	repRawRE = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+)\{([0-9]+)\}$`)
+
+	// repQuoteRE is a regular expression used to parse short form scripts for
+	// quoted data that is to be repeated a specified number of times.
+	repQuoteRE = regexp.MustCompile(`^'(.*)'\{([0-9]+)\}$`)
+)
+
+// parse hex string into a []byte.
+func parseHex(tok string) ([]byte, error) {
+	if !strings.HasPrefix(tok, "0x") {
+		return nil, errors.New("not a hex number")
+	}
+	return hex.DecodeString(tok[2:])
+}
+
+// shortFormOps maps the opcode names accepted by short form scripts to their
+// values.  They are the same names accepted by templates, and the map is built
+// when the package is initialized so concurrent parsing is safe.
+var shortFormOps = templateOps
+
+// ParseShortFormScript parses a script in the short form used by the reference
+// script tests, such as "DUP HASH160 0x14 0x<20 bytes> EQUALVERIFY CHECKSIG",
+// into the script it came from.  See FormatShortFormScript for the inverse.
+//
+// The format used for these tests is pretty simple if ad-hoc:
+//   - Opcodes other than the push opcodes and unknown are present as either
+//     OP_NAME or just NAME
+//   - Plain numbers are made into push operations
+//   - Numbers beginning with 0x are inserted into the []byte as-is (so 0x14 is
+//     OP_DATA_20)
+//   - Numbers beginning with 0x which have a suffix which consists of a number
+//     in braces (e.g. 0x6161{10}) repeat the raw bytes the specified number of
+//     times and are inserted as-is
+//   - Single quoted strings are pushed as data
+//   - Single quoted strings that have a suffix which consists of a number in
+//     braces (e.g. 'b'{10}) repeat the data the specified number of times and
+//     are pushed as a single data push
+//   - Tokens inside of angular brackets with a suffix which consists of a
+//     number in braces (e.g. <0 0 CHECKMULTSIG>{5}) is parsed as if the tokens
+//     inside the angular brackets were manually repeated the specified number
+//     of times
+//   - Anything else is an error
+func ParseShortFormScript(script string) ([]byte, error) {
+	builder := NewScriptBuilder()
+
+	var handleToken func(tok string) error
+	handleToken = func(tok string) error {
+		// Multiple repeated tokens.
+		if m := repTokenRE.FindStringSubmatch(tok); m != nil {
+			count, err := strconv.ParseInt(m[2], 10, 32)
+			if err != nil {
+				return fmt.Errorf("bad token %q", tok)
+			}
+			tokens := tokenRE.FindAllStringSubmatch(m[1], -1)
+			for i := 0; i < int(count); i++ {
+				for _, t := range tokens {
+					if err := handleToken(t[0]); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		// Plain number.
+		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			builder.AddInt64(num)
+			return nil
+		}
+
+		// Raw data.
+		if bts, err := parseHex(tok); err == nil {
+			// Concatenate the bytes manually since the test code
+			// intentionally creates scripts that are too large and
+			// would cause the builder to error otherwise.
+			if builder.err == nil {
+				builder.script = append(builder.script, bts...)
+			}
+			return nil
+		}
+
+		// Repeated raw bytes.
+		if m := repRawRE.FindStringSubmatch(tok); m != nil {
+			bts, err := parseHex(m[1])
+			if err != nil {
+				return fmt.Errorf("bad token %q", tok)
+			}
+			count, err := strconv.ParseInt(m[2], 10, 32)
+			if err != nil {
+				return fmt.Errorf("bad token %q", tok)
+			}
+
+			// Concatenate the bytes manually since the test code
+			// intentionally creates scripts that are too large and
+			// would cause the builder to error otherwise.
+			bts = bytes.Repeat(bts, int(count))
+			if builder.err == nil {
+				builder.script = append(builder.script, bts...)
+			}
+			return nil
+		}
+
+		// Quoted data.
+		if len(tok) >= 2 && tok[0] == '\'' && tok[len(tok)-1] == '\'' {
+			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
+			return nil
+		}
+
+		// Repeated quoted data.
+		if m := repQuoteRE.FindStringSubmatch(tok); m != nil {
+			count, err := strconv.ParseInt(m[2], 10, 32)
+			if err != nil {
+				return fmt.Errorf("bad token %q", tok)
+			}
+			data := strings.Repeat(m[1], int(count))
+			builder.AddFullData([]byte(data))
+			return nil
+		}
+
+		// Named opcode.
+		if opcode, ok := shortFormOps[tok]; ok {
+			builder.AddOp(opcode)
+			return nil
+		}
+
+		return fmt.Errorf("bad token %q", tok)
+	}
+
+	for _, tokens := range tokenRE.FindAllStringSubmatch(script, -1) {
+		if err := handleToken(tokens[0]); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Script()
+}
+
+// FormatShortFormScript returns the short form used by the reference script
+// tests of the provided script, which parses back into the exact same script
+// with ParseShortFormScript.  Small integers are formatted as numbers, data
+// pushes as the raw push opcode followed by the raw data and other opcodes by
+// their name without the OP_ prefix.  Unknown opcodes and any bytes that do
+// not parse are formatted as raw bytes.
+func FormatShortFormScript(script []byte) string {
+	var tokens []string
+	tokenizer := MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		data := tokenizer.Data()
+		switch {
+		case op == OP_0:
+			tokens = append(tokens, "0")
+
+		case op == OP_1NEGATE:
+			tokens = append(tokens, "-1")
+
+		case IsSmallInt(op):
+			tokens = append(tokens, strconv.Itoa(AsSmallInt(op)))
+
+		case op <= OP_PUSHDATA4:
+			// The push opcode is followed by the length of the data
+			// for the OP_PUSHDATA# opcodes.
+			prefixLen := 1
+			switch op {
+			case OP_PUSHDATA1:
+				prefixLen = 2
+			case OP_PUSHDATA2:
+				prefixLen = 3
+			case OP_PUSHDATA4:
+				prefixLen = 5
+			}
+			dataStart := int(tokenizer.ByteIndex()) - len(data)
+			tokens = append(tokens, "0x"+hex.EncodeToString(
+				script[dataStart-prefixLen:dataStart]))
+			if len(data) > 0 {
+				tokens = append(tokens, "0x"+hex.EncodeToString(data))
+			}
+
+		case strings.HasPrefix(opcodeArray[op].name, "OP_UNKNOWN"):
+			tokens = append(tokens, fmt.Sprintf("0x%02x", op))
+
+		default:
+			tokens = append(tokens, strings.TrimPrefix(opcodeArray[op].name,
+				"OP_"))
+		}
+	}
+	if tokenizer.Err() != nil {
+		offset := tokenizer.ByteIndex()
+		tokens = append(tokens, "0x"+hex.EncodeToString(script[offset:]))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// ParseVectorFlags parses script flags in the comma-separated format used by
+// the reference tests, such as "CHECKLOCKTIMEVERIFY,SHA256", into ScriptFlags
+// suitable for use in the script engine.  Each flag is parsed as by
+// ParseScriptFlags, so the unknown flags formatted as a hex number by
+// FormatVectorFlags parse back into the same flags.
+func ParseVectorFlags(flagStr string) (ScriptFlags, error) {
+	var flags ScriptFlags
+	for _, name := range strings.Split(flagStr, ",") {
+		flag, err := parseScriptFlag(name)
+		if err != nil {
+			return 0, err
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// FormatVectorFlags returns the provided script flags in the format used by
+// the reference tests.  It returns "NONE" when no flags are set and formats
+// unknown flags as a hex number.
+func FormatVectorFlags(flags ScriptFlags) string {
+	return flags.String()
+}
+
+// vectorResults maps the expected results used by the reference tests to the
+// error kinds they allow.  Multiple results may map to the same kind, in which
+// case the first one is used when formatting results.
+var vectorResults = []struct {
+	name string
+	kind ErrorKind
+}{
+	{"ERR_EARLY_RETURN", ErrEarlyReturn},
+	{"ERR_EMPTY_STACK", ErrEmptyStack},
+	{"ERR_EVAL_FALSE", ErrEvalFalse},
+	{"ERR_SCRIPT_SIZE", ErrScriptTooBig},
+	{"ERR_PUSH_SIZE", ErrElementTooBig},
+	{"ERR_OP_COUNT", ErrTooManyOperations},
+	{"ERR_STACK_SIZE", ErrStackOverflow},
+	{"ERR_PUBKEY_COUNT", ErrInvalidPubKeyCount},
+	{"ERR_SIG_COUNT", ErrInvalidSignatureCount},
+	{"ERR_OUT_OF_RANGE", ErrNumOutOfRange},
+	{"ERR_VERIFY", ErrVerify},
+	{"ERR_EQUAL_VERIFY", ErrEqualVerify},
+	{"ERR_DISABLED_OPCODE", ErrDisabledOpcode},
+	{"ERR_RESERVED_OPCODE", ErrReservedOpcode},
+	{"ERR_P2SH_STAKE_OPCODES", ErrP2SHStakeOpCodes},
+	{"ERR_MALFORMED_PUSH", ErrMalformedPush},
+	{"ERR_INVALID_STACK_OPERATION", ErrInvalidStackOperation},
+	{"ERR_INVALID_ALTSTACK_OPERATION", ErrInvalidStackOperation},
+	{"ERR_UNBALANCED_CONDITIONAL", ErrUnbalancedConditional},
+	{"ERR_NEGATIVE_SUBSTR_INDEX", ErrNegativeSubstrIdx},
+	{"ERR_OVERFLOW_SUBSTR_INDEX", ErrOverflowSubstrIdx},
+	{"ERR_NEGATIVE_ROTATION", ErrNegativeRotation},
+	{"ERR_OVERFLOW_ROTATION", ErrOverflowRotation},
+	{"ERR_DIVIDE_BY_ZERO", ErrDivideByZero},
+	{"ERR_NEGATIVE_SHIFT", ErrNegativeShift},
+	{"ERR_OVERFLOW_SHIFT", ErrOverflowShift},
+	{"ERR_MINIMAL_DATA", ErrMinimalData},
+	{"ERR_SIG_HASH_TYPE", ErrInvalidSigHashType},
+	{"ERR_SIG_TOO_SHORT", ErrSigTooShort},
+	{"ERR_SIG_TOO_LONG", ErrSigTooLong},
+	{"ERR_SIG_INVALID_SEQ_ID", ErrSigInvalidSeqID},
+	{"ERR_SIG_INVALID_DATA_LEN", ErrSigInvalidDataLen},
+	{"ERR_SIG_MISSING_S_TYPE_ID", ErrSigMissingSTypeID},
+	{"ERR_SIG_MISSING_S_LEN", ErrSigMissingSLen},
+	{"ERR_SIG_INVALID_S_LEN", ErrSigInvalidSLen},
+	{"ERR_SIG_INVALID_R_INT_ID", ErrSigInvalidRIntID},
+	{"ERR_SIG_ZERO_R_LEN", ErrSigZeroRLen},
+	{"ERR_SIG_NEGATIVE_R", ErrSigNegativeR},
+	{"ERR_SIG_TOO_MUCH_R_PADDING", ErrSigTooMuchRPadding},
+	{"ERR_SIG_INVALID_S_INT_ID", ErrSigInvalidSIntID},
+	{"ERR_SIG_ZERO_S_LEN", ErrSigZeroSLen},
+	{"ERR_SIG_NEGATIVE_S", ErrSigNegativeS},
+	{"ERR_SIG_TOO_MUCH_S_PADDING", ErrSigTooMuchSPadding},
+	{"ERR_SIG_HIGH_S", ErrSigHighS},
+	{"ERR_SIG_PUSHONLY", ErrNotPushOnly},
+	{"ERR_PUBKEY_TYPE", ErrPubKeyType},
+	{"ERR_CLEAN_STACK", ErrCleanStack},
+	{"ERR_DISCOURAGE_UPGRADABLE_NOPS", ErrDiscourageUpgradableNOPs},
+	{"ERR_NEGATIVE_LOCKTIME", ErrNegativeLockTime},
+	{"ERR_UNSATISFIED_LOCKTIME", ErrUnsatisfiedLockTime},
+}
+
+// ParseVectorResult parses an expected result in the format used by the
+// reference script tests, such as "OK" or "ERR_EVAL_FALSE", into the error
+// kinds it allows.  It returns no kinds for "OK" and an error if the expected
+// result string is not supported.
+func ParseVectorResult(expected string) ([]ErrorKind, error) {
+	if expected == "OK" {
+		return nil, nil
+	}
+	var kinds []ErrorKind
+	for _, result := range vectorResults {
+		if result.name == expected {
+			kinds = append(kinds, result.kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("unrecognized expected result in test "+
+			"data: %v", expected)
+	}
+	return kinds, nil
+}
+
+// FormatVectorResult returns the expected result in the format used by the
+// reference script tests that matches the provided script execution error.
+// It returns "OK" for a nil error and an error when the kind of the error has
+// no equivalent in the format.
+func FormatVectorResult(err error) (string, error) {
+	if err == nil {
+		return "OK", nil
+	}
+	for _, result := range vectorResults {
+		if errors.Is(err, result.kind) {
+			return result.name, nil
+		}
+	}
+	return "", fmt.Errorf("error %q has no equivalent in the test data "+
+		"format", err)
+}
+
+// ParseSigHashVectorResult parses an expected result in the format used by the
+// reference signature hash tests into the expected error, which is nil for
+// "OK".  An error is returned if the expected result string is not supported.
+func ParseSigHashVectorResult(expected string) (error, error) {
+	switch expected {
+	case "OK":
+		return nil, nil
+	case "SIGHASH_SINGLE_IDX":
+		return ErrInvalidSigHashSingleIndex, nil
+	}
+
+	return nil, fmt.Errorf("unrecognized expected result in test data: %v",
+		expected)
+}
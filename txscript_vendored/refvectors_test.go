@@ -0,0 +1,143 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// TestFormatShortFormScript ensures formatting the scripts of the reference
+// script tests in short form parses back into the same scripts.
+func TestFormatShortFormScript(t *testing.T) {
+	t.Parallel()
+
+	file, err := ioutil.ReadFile("data/script_tests.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var tests [][]string
+	if err := json.Unmarshal(file, &tests); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, test := range tests {
+		if len(test) < 2 {
+			continue
+		}
+		for _, shortForm := range test[:2] {
+			script, err := ParseShortFormScript(shortForm)
+			if err != nil {
+				// Some scripts are intentionally too large for the
+				// builder.
+				continue
+			}
+			formatted := FormatShortFormScript(script)
+			reparsed, err := ParseShortFormScript(formatted)
+			if err != nil {
+				t.Errorf("test #%d: unable to parse %q: %v", i,
+					formatted, err)
+				continue
+			}
+			if !bytes.Equal(reparsed, script) {
+				t.Errorf("test #%d: round trip mismatch -- got %x, "+
+					"want %x", i, reparsed, script)
+			}
+		}
+	}
+
+	// Ensure scripts that fail to parse are formatted as raw bytes.
+	script := hexToBytes("51764c05")
+	formatted := FormatShortFormScript(script)
+	if formatted != "1 DUP 0x4c05" {
+		t.Fatalf("unexpected short form %q", formatted)
+	}
+}
+
+// TestVectorFlags ensures script flags round trip through the format used by
+// the reference tests.
+func TestVectorFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		flags ScriptFlags
+		str   string
+	}{
+		{0, "NONE"},
+		{ScriptVerifyCleanStack, "CLEANSTACK"},
+		{ScriptVerifyCheckLockTimeVerify | ScriptVerifySHA256,
+			"CHECKLOCKTIMEVERIFY,SHA256"},
+		{ScriptVerifyTreasury | 1<<30, "TREASURY,0x40000000"},
+	}
+
+	for _, test := range tests {
+		str := FormatVectorFlags(test.flags)
+		if str != test.str {
+			t.Errorf("unexpected flags string -- got %q, want %q", str,
+				test.str)
+			continue
+		}
+		flags, err := ParseVectorFlags(str)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", str, err)
+			continue
+		}
+		if flags != test.flags {
+			t.Errorf("%q: unexpected flags -- got %v, want %v", str, flags,
+				test.flags)
+		}
+	}
+}
+
+// TestFormatVectorResult ensures script execution errors are formatted as the
+// expected results used by the reference tests.
+func TestFormatVectorResult(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err     error
+		result  string
+		invalid bool
+	}{
+		{err: nil, result: "OK"},
+		{err: scriptError(ErrEvalFalse, ""), result: "ERR_EVAL_FALSE"},
+		{err: scriptError(ErrInvalidStackOperation, ""),
+			result: "ERR_INVALID_STACK_OPERATION"},
+		{err: scriptError(ErrTooManyPaths, ""), invalid: true},
+	}
+
+	for _, test := range tests {
+		result, err := FormatVectorResult(test.err)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%v: unexpected result %q", test.err, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.err, err)
+			continue
+		}
+		if result != test.result {
+			t.Errorf("%v: unexpected result -- got %q, want %q", test.err,
+				result, test.result)
+			continue
+		}
+
+		// Ensure the result parses back into the kind of the error.
+		kinds, err := ParseVectorResult(result)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", result, err)
+			continue
+		}
+		if test.err != nil && (len(kinds) != 1 ||
+			kinds[0] != test.err.(Error).Err) {
+
+			t.Errorf("%q: unexpected kinds %v", result, kinds)
+		}
+	}
+}
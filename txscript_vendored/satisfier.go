@@ -0,0 +1,493 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// satisfierHashOps maps the names of the hashing operators of symbolic values
+// to the opcodes that compute them.
+var satisfierHashOps = map[string]byte{
+	"ripemd160": OP_RIPEMD160,
+	"sha1":      OP_SHA1,
+	"sha256":    OP_SHA256,
+	"blake256":  OP_BLAKE256,
+	"hash160":   OP_HASH160,
+	"hash256":   OP_HASH256,
+}
+
+// satisfierSigOps maps the names of the signature checking operators of
+// symbolic values to the signature type they verify.
+var satisfierSigOps = map[string]dcrec.SignatureType{
+	"checksig":         dcrec.STEcdsaSecp256k1,
+	"checksig_schnorr": dcrec.STSchnorrSecp256k1,
+	"checksig_ed25519": dcrec.STEd25519,
+}
+
+// satisfierKey is a private key available to a Satisfier.
+type satisfierKey struct {
+	privKey []byte
+	sigType dcrec.SignatureType
+}
+
+// Satisfier builds signature scripts for arbitrary contracts from a bag of
+// secrets: private keys, hash preimages and the preferred outcome of the
+// conditionals of the script.
+//
+// The contract is analyzed with SymbolicExecute and the constraints of each of
+// its execution paths are solved in turn with the available secrets.  Inputs
+// compared against constants are set to them, inputs whose hash is compared
+// against a constant are set to a matching preimage or public key, signatures
+// are produced for the public keys with an available private key, inputs used
+// directly as conditions are set to the required outcome and the lock time,
+// sequence and version of the spending transaction are adjusted to the lock
+// times of the path.  The first path whose solution is accepted by the engine
+// is used.
+type Satisfier struct {
+	keys      map[string]satisfierKey
+	preimages [][]byte
+	branches  []bool
+}
+
+// NewSatisfier returns a satisfier without any secrets.
+func NewSatisfier() *Satisfier {
+	return &Satisfier{keys: make(map[string]satisfierKey)}
+}
+
+// AddKey makes the provided private key of the given signature type available
+// to sign the public keys it corresponds to.  Public keys of secp256k1 ECDSA
+// keys are recognized in both compressed and uncompressed form.
+func (s *Satisfier) AddKey(privKey []byte, sigType dcrec.SignatureType) error {
+	key := satisfierKey{privKey: privKey, sigType: sigType}
+	switch sigType {
+	case dcrec.STEcdsaSecp256k1:
+		pubKey := secp256k1.PrivKeyFromBytes(privKey).PubKey()
+		s.keys[string(pubKey.SerializeCompressed())] = key
+		s.keys[string(pubKey.SerializeUncompressed())] = key
+	case dcrec.STSchnorrSecp256k1:
+		pubKey := secp256k1.PrivKeyFromBytes(privKey).PubKey()
+		s.keys[string(pubKey.SerializeCompressed())] = key
+	case dcrec.STEd25519:
+		_, pubKey := edwardsPrivKeyFromBytes(privKey)
+		if pubKey == nil {
+			return fmt.Errorf("invalid ed25519 private key")
+		}
+		s.keys[string(pubKey.Serialize())] = key
+	default:
+		return fmt.Errorf("unsupported signature type %v", sigType)
+	}
+	return nil
+}
+
+// AddPreimage makes the provided hash preimage, such as the secret of a hash
+// locked contract, available to the satisfier.
+func (s *Satisfier) AddPreimage(preimage []byte) {
+	s.preimages = append(s.preimages, preimage)
+}
+
+// SetBranches restricts the satisfier to the execution paths that take the
+// provided outcomes for the first conditionals which depend on the signature
+// script, such as false to choose the refund branch of a contract.  Paths are
+// otherwise tried in order with true outcomes first.
+func (s *Satisfier) SetBranches(branches []bool) {
+	s.branches = branches
+}
+
+// Satisfaction describes the solution found by Satisfier.Satisfy.
+type Satisfaction struct {
+	// SigScript is the signature script that satisfies the contract.
+	SigScript []byte
+
+	// Path is the execution path taken by the signature script.
+	Path SymbolicPath
+}
+
+// pathSolution houses the values assigned to the inputs of a path and the
+// adjustments to the spending transaction it requires.
+type pathSolution struct {
+	values   [][]byte
+	assigned []bool
+	sigKeys  map[int]satisfierKey
+
+	lockTime    uint32
+	sequence    uint32
+	hasSequence bool
+	finalSeq    bool
+	version     uint16
+}
+
+// assign sets the value of the provided input unless it is already assigned and
+// returns whether it was assigned.
+func (sol *pathSolution) assign(index int, value []byte) bool {
+	if sol.assigned[index] {
+		return false
+	}
+	sol.values[index] = value
+	sol.assigned[index] = true
+	return true
+}
+
+// resolve returns the concrete value of the provided expression when it is a
+// constant or an input that is already assigned.
+func (sol *pathSolution) resolve(v *SymbolicValue) ([]byte, bool) {
+	switch v.Kind {
+	case SymConst:
+		return v.Data, true
+	case SymInput:
+		if sol.assigned[v.Index] && sol.sigKeys[v.Index].privKey == nil {
+			return sol.values[v.Index], true
+		}
+	}
+	return nil, false
+}
+
+// unassignedInput returns the index of the provided expression when it is an
+// input that is not yet assigned.
+func (sol *pathSolution) unassignedInput(v *SymbolicValue) (int, bool) {
+	if v.Kind != SymInput || sol.assigned[v.Index] {
+		return 0, false
+	}
+	return v.Index, true
+}
+
+// hash returns the result of the named hashing operator over the data.
+func (x *symbolicExecutor) hash(name string, data []byte) []byte {
+	op := &opcodeArray[satisfierHashOps[name]]
+	results, err := x.evalConcrete(op, nil, []*SymbolicValue{symConst(data)})
+	if err != nil || len(results) != 1 {
+		return nil
+	}
+	return results[0].Data
+}
+
+// solveEquality attempts to assign an input so that the provided expressions
+// are equal, or differ when equal is false.
+func (s *Satisfier) solveEquality(x *symbolicExecutor, sol *pathSolution, a, b *SymbolicValue, equal bool) bool {
+	want, ok := sol.resolve(b)
+	if !ok {
+		a, b = b, a
+		if want, ok = sol.resolve(b); !ok {
+			return false
+		}
+	}
+
+	if index, ok := sol.unassignedInput(a); ok {
+		if equal {
+			return sol.assign(index, want)
+		}
+		if len(want) == 0 {
+			return sol.assign(index, []byte{1})
+		}
+		return sol.assign(index, nil)
+	}
+
+	// Look for a preimage, including the public keys of the available
+	// private keys, whose hash is the wanted one.
+	if !equal || a.Kind != SymOp || len(a.Args) != 1 {
+		return false
+	}
+	if _, ok := satisfierHashOps[a.Name]; !ok {
+		return false
+	}
+	index, ok := sol.unassignedInput(a.Args[0])
+	if !ok {
+		return false
+	}
+	candidates := append([][]byte(nil), s.preimages...)
+	for pubKey := range s.keys {
+		candidates = append(candidates, []byte(pubKey))
+	}
+	for _, candidate := range candidates {
+		if bytes.Equal(x.hash(a.Name, candidate), want) {
+			return sol.assign(index, candidate)
+		}
+	}
+	return false
+}
+
+// solveSig attempts to assign the signature input of a signature check that
+// must hold when a private key for its public key is available, or an empty
+// signature when it must fail.
+func (s *Satisfier) solveSig(sol *pathSolution, sigType dcrec.SignatureType, sig, pubKey *SymbolicValue, holds bool) bool {
+	index, ok := sol.unassignedInput(sig)
+	if !ok {
+		return false
+	}
+	if !holds {
+		return sol.assign(index, nil)
+	}
+	pk, ok := sol.resolve(pubKey)
+	if !ok {
+		return false
+	}
+	key, ok := s.keys[string(pk)]
+	if !ok || key.sigType != sigType {
+		return false
+	}
+	sol.sigKeys[index] = key
+	return sol.assign(index, nil)
+}
+
+// solveMultiSig attempts to assign the signature inputs of a multisig check
+// with signatures of the available private keys in the order of the public
+// keys.
+func (s *Satisfier) solveMultiSig(sol *pathSolution, sigs, pubKeys []*SymbolicValue, holds bool) bool {
+	indices := make([]int, 0, len(sigs))
+	for _, sig := range sigs {
+		index, ok := sol.unassignedInput(sig)
+		if !ok {
+			return false
+		}
+		indices = append(indices, index)
+	}
+	if !holds {
+		for _, index := range indices {
+			sol.assign(index, nil)
+		}
+		return len(indices) > 0
+	}
+
+	var keys []satisfierKey
+	for _, pubKey := range pubKeys {
+		pk, ok := sol.resolve(pubKey)
+		if !ok {
+			return false
+		}
+		key, ok := s.keys[string(pk)]
+		if ok && key.sigType == dcrec.STEcdsaSecp256k1 &&
+			len(keys) < len(indices) {
+
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) < len(indices) {
+		return false
+	}
+	for i, index := range indices {
+		sol.sigKeys[index] = keys[i]
+		sol.assign(index, nil)
+	}
+	return true
+}
+
+// solveTxField records the adjustment to the spending transaction required by
+// a constraint on one of its fields.
+func (sol *pathSolution) solveTxField(v *SymbolicValue, holds bool) bool {
+	if len(v.Args) != 2 || v.Args[0].Kind != SymTxField {
+		return false
+	}
+	data, ok := sol.resolve(v.Args[1])
+	if !ok {
+		return false
+	}
+	n, err := MakeScriptNum(data, 5)
+	if err != nil || n < 0 || n > 0xffffffff {
+		return false
+	}
+	value := uint32(n)
+
+	field := v.Args[0].Name
+	switch {
+	case v.Name == ">=" && holds && field == SymFieldLockTime:
+		if value > sol.lockTime {
+			sol.lockTime = value
+		}
+	case v.Name == ">=" && holds && field == SymFieldSequence:
+		sol.sequence, sol.hasSequence = value, true
+	case v.Name == ">=" && holds && field == SymFieldVersion:
+		if uint16(value) > sol.version {
+			sol.version = uint16(value)
+		}
+	case v.Name == "!=" && holds && field == SymFieldSequence &&
+		value == wire.MaxTxInSequenceNum:
+		sol.finalSeq = false
+	default:
+		return false
+	}
+	return true
+}
+
+// solveConstraint attempts to make progress on the provided constraint and
+// returns whether it did.
+func (s *Satisfier) solveConstraint(x *symbolicExecutor, sol *pathSolution, c SymbolicConstraint) bool {
+	v, holds := c.Expr, c.Holds
+	if v.Kind == SymOp && v.Name == "!" && len(v.Args) == 1 {
+		v, holds = v.Args[0], !holds
+	}
+
+	if index, ok := sol.unassignedInput(v); ok {
+		if holds {
+			return sol.assign(index, []byte{1})
+		}
+		return sol.assign(index, nil)
+	}
+	if v.Kind != SymOp {
+		return false
+	}
+
+	if sigType, ok := satisfierSigOps[v.Name]; ok && len(v.Args) == 2 {
+		return s.solveSig(sol, sigType, v.Args[0], v.Args[1], holds)
+	}
+	switch {
+	case v.Name == "checkmultisig" && len(v.Args) == 2:
+		return s.solveMultiSig(sol, v.Args[0].Args, v.Args[1].Args, holds)
+
+	case len(v.Args) == 2 && v.Args[0].Kind == SymTxField:
+		return sol.solveTxField(v, holds)
+
+	case v.Name == "==" && len(v.Args) == 2:
+		return s.solveEquality(x, sol, v.Args[0], v.Args[1], holds)
+
+	case v.Name == "!=" && len(v.Args) == 2:
+		return s.solveEquality(x, sol, v.Args[0], v.Args[1], !holds)
+	}
+	return false
+}
+
+// solvePath returns the signature script that satisfies the provided path of
+// the subscript along with the spending transaction adjusted to its lock
+// times.
+func (s *Satisfier) solvePath(x *symbolicExecutor, path *SymbolicPath, tx *wire.MsgTx, idx int, subScript, redeemScript []byte) ([]byte, *wire.MsgTx, error) {
+	sol := &pathSolution{
+		values:   make([][]byte, path.NumInputs),
+		assigned: make([]bool, path.NumInputs),
+		sigKeys:  make(map[int]satisfierKey),
+		lockTime: tx.LockTime,
+		sequence: tx.TxIn[idx].Sequence,
+		finalSeq: true,
+		version:  tx.Version,
+	}
+
+	// Solve the constraints until no more progress is made since solving
+	// one constraint may provide the values needed by another.
+	solved := make([]bool, len(path.Constraints))
+	for progress := true; progress; {
+		progress = false
+		for i, c := range path.Constraints {
+			if !solved[i] && s.solveConstraint(x, sol, c) {
+				solved[i], progress = true, true
+			}
+		}
+	}
+
+	// Apply the lock times of the path to a copy of the transaction.
+	tx = tx.Copy()
+	tx.LockTime, tx.Version = sol.lockTime, sol.version
+	txIn := tx.TxIn[idx]
+	if sol.hasSequence {
+		txIn.Sequence = sol.sequence
+	}
+	if !sol.finalSeq && txIn.Sequence == wire.MaxTxInSequenceNum {
+		txIn.Sequence = wire.MaxTxInSequenceNum - 1
+	}
+
+	// Sign with the adjusted transaction.  Unassigned inputs are left
+	// empty.
+	for index, key := range sol.sigKeys {
+		sig, err := RawTxInSignature(tx, idx, subScript, SigHashAll,
+			key.privKey, key.sigType)
+		if err != nil {
+			return nil, nil, err
+		}
+		sol.values[index] = sig
+	}
+	builder := NewScriptBuilder()
+	for _, value := range sol.values {
+		builder.AddData(value)
+	}
+	if redeemScript != nil {
+		builder.AddData(redeemScript)
+	}
+	script, err := builder.Script()
+	if err != nil {
+		return nil, nil, err
+	}
+	return script, tx, nil
+}
+
+// hasBranches returns whether the path takes the preferred branches.
+func (s *Satisfier) hasBranches(path *SymbolicPath) bool {
+	if len(s.branches) > len(path.Branches) {
+		return false
+	}
+	for i, branch := range s.branches {
+		if path.Branches[i] != branch {
+			return false
+		}
+	}
+	return true
+}
+
+// Satisfy builds a signature script for the input with the provided index of
+// the transaction that spends the provided public key script.  The redeem
+// script must be provided when the public key script is pay-to-script-hash and
+// is nil otherwise.
+//
+// On success, the signature script is set on the input and the lock time and
+// version of the transaction and the sequence of the input are updated as
+// required by the satisfied path.  Since changing them invalidates the
+// signatures of other inputs, inputs with lock time requirements should be
+// satisfied first.  ErrUnsatisfiable is returned along with the reason the
+// last path failed when none of the paths can be satisfied with the available
+// secrets.
+func (s *Satisfier) Satisfy(tx *wire.MsgTx, idx int, pkScript, redeemScript []byte, flags ScriptFlags) (*Satisfaction, error) {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", idx, len(tx.TxIn))
+		return nil, scriptError(ErrInvalidIndex, str)
+	}
+	script := pkScript
+	if IsPayToScriptHash(pkScript) {
+		if redeemScript == nil {
+			return nil, scriptError(ErrUnsatisfiable, "a redeem script "+
+				"is required to satisfy a pay-to-script-hash script")
+		}
+		script = redeemScript
+	} else {
+		redeemScript = nil
+	}
+
+	paths, err := SymbolicExecute(script, 0, flags)
+	if err != nil {
+		return nil, err
+	}
+	x := &symbolicExecutor{flags: flags}
+	lastErr := "no execution path takes the preferred branches"
+	for i := range paths {
+		path := &paths[i]
+		if path.Err != nil || !s.hasBranches(path) {
+			continue
+		}
+		sigScript, pathTx, err := s.solvePath(x, path, tx, idx, script,
+			redeemScript)
+		if err != nil {
+			return nil, err
+		}
+		pathTx.TxIn[idx].SignatureScript = sigScript
+		vm, err := NewEngine(pkScript, pathTx, idx, flags, 0, nil)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			lastErr = fmt.Sprintf("path %v: %v", path.Branches, err)
+			continue
+		}
+
+		tx.LockTime, tx.Version = pathTx.LockTime, pathTx.Version
+		tx.TxIn[idx].Sequence = pathTx.TxIn[idx].Sequence
+		tx.TxIn[idx].SignatureScript = sigScript
+		return &Satisfaction{SigScript: sigScript, Path: *path}, nil
+	}
+
+	str := fmt.Sprintf("unable to satisfy the script with the available "+
+		"secrets (%s)", lastErr)
+	return nil, scriptError(ErrUnsatisfiable, str)
+}
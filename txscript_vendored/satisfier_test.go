@@ -0,0 +1,187 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestSatisfier ensures the satisfier builds valid signature scripts for the
+// execution paths of contracts that can be satisfied with the available
+// secrets and adjusts the spending transaction to their lock times.
+func TestSatisfier(t *testing.T) {
+	t.Parallel()
+
+	// Create the keys of the participants.
+	keys := testSecpKeys(t, 3)
+	secret := bytes.Repeat([]byte{0x55}, 32)
+	secretHash := sha256.Sum256(secret)
+
+	// The swap contract pays the first participant with the secret or
+	// refunds the second after block 500.  The escrow contract is spent by
+	// two of the three participants or by the third after 10 blocks.
+	swapScript, err := NewScriptBuilder().
+		AddOp(OP_IF).
+		AddOp(OP_SIZE).AddInt64(32).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_SHA256).AddData(secretHash[:]).
+		AddOp(OP_EQUALVERIFY).
+		AddOp(OP_DUP).AddOp(OP_HASH160).
+		AddData(dcrutil.Hash160(keys[0].pubKey)).
+		AddOp(OP_ELSE).
+		AddInt64(500).AddOp(OP_CHECKLOCKTIMEVERIFY).AddOp(OP_DROP).
+		AddOp(OP_DUP).AddOp(OP_HASH160).
+		AddData(dcrutil.Hash160(keys[1].pubKey)).
+		AddOp(OP_ENDIF).
+		AddOp(OP_EQUALVERIFY).AddOp(OP_CHECKSIG).Script()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	escrowScript, err := NewScriptBuilder().
+		AddOp(OP_IF).
+		AddInt64(2).AddData(keys[0].pubKey).AddData(keys[1].pubKey).
+		AddData(keys[2].pubKey).AddInt64(3).AddOp(OP_CHECKMULTISIG).
+		AddOp(OP_ELSE).
+		AddInt64(10).AddOp(OP_CHECKSEQUENCEVERIFY).AddOp(OP_DROP).
+		AddData(keys[2].pubKey).AddOp(OP_CHECKSIG).
+		AddOp(OP_ENDIF).Script()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		redeemScript []byte
+		keys         []int
+		preimages    [][]byte
+		branches     []bool
+		wantBranches []bool
+		lockTime     uint32
+		sequence     uint32
+		version      uint16
+		err          error
+	}{{
+		name:         "swap redeemed with the secret",
+		redeemScript: swapScript,
+		keys:         []int{0},
+		preimages:    [][]byte{{0x01}, secret},
+		wantBranches: []bool{true},
+		sequence:     wire.MaxTxInSequenceNum,
+		version:      1,
+	}, {
+		name:         "swap refunded without the secret",
+		redeemScript: swapScript,
+		keys:         []int{1},
+		wantBranches: []bool{false},
+		lockTime:     500,
+		sequence:     wire.MaxTxInSequenceNum - 1,
+		version:      1,
+	}, {
+		name:         "swap refund chosen despite the secret",
+		redeemScript: swapScript,
+		keys:         []int{0, 1},
+		preimages:    [][]byte{secret},
+		branches:     []bool{false},
+		wantBranches: []bool{false},
+		lockTime:     500,
+		sequence:     wire.MaxTxInSequenceNum - 1,
+		version:      1,
+	}, {
+		name:         "swap without any secret",
+		redeemScript: swapScript,
+		keys:         []int{2},
+		err:          ErrUnsatisfiable,
+	}, {
+		name:         "escrow spent by two participants",
+		redeemScript: escrowScript,
+		keys:         []int{0, 2},
+		wantBranches: []bool{true},
+		sequence:     wire.MaxTxInSequenceNum,
+		version:      1,
+	}, {
+		name:         "escrow spent by the third participant after 10 blocks",
+		redeemScript: escrowScript,
+		keys:         []int{2},
+		wantBranches: []bool{false},
+		sequence:     10,
+		version:      2,
+	}}
+
+	pkScripts := make(map[string][]byte)
+	for _, script := range [][]byte{swapScript, escrowScript} {
+		pkScript, err := PayToScriptHashScript(dcrutil.Hash160(script))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pkScripts[string(script)] = pkScript
+	}
+
+	for _, test := range tests {
+		s := NewSatisfier()
+		for _, i := range test.keys {
+			err := s.AddKey(keys[i].privKey, dcrec.STEcdsaSecp256k1)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+		}
+		for _, preimage := range test.preimages {
+			s.AddPreimage(preimage)
+		}
+		s.SetBranches(test.branches)
+
+		pkScript := pkScripts[string(test.redeemScript)]
+		tx, err := CreateSpendingTx(nil, pkScript, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		sat, err := s.Satisfy(tx, 0, pkScript, test.redeemScript,
+			ConsensusScriptFlags)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: unexpected error -- got %v, want %v",
+					test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		if len(sat.Path.Branches) != len(test.wantBranches) ||
+			sat.Path.Branches[0] != test.wantBranches[0] {
+
+			t.Errorf("%q: unexpected branches -- got %v, want %v",
+				test.name, sat.Path.Branches, test.wantBranches)
+		}
+		if !bytes.Equal(tx.TxIn[0].SignatureScript, sat.SigScript) {
+			t.Errorf("%q: signature script not set on the input",
+				test.name)
+		}
+		if tx.LockTime != test.lockTime ||
+			tx.TxIn[0].Sequence != test.sequence ||
+			tx.Version != test.version {
+
+			t.Errorf("%q: unexpected lock time %d, sequence %d and "+
+				"version %d", test.name, tx.LockTime,
+				tx.TxIn[0].Sequence, tx.Version)
+		}
+
+		// Ensure the updated transaction verifies.
+		vm, err := NewEngine(pkScript, tx, 0, ConsensusScriptFlags, 0, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+		}
+	}
+}
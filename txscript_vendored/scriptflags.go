@@ -0,0 +1,124 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConsensusPreTreasuryScriptFlags are the script flags enforced by the
+	// consensus rules prior to the activation of the treasury agenda.
+	ConsensusPreTreasuryScriptFlags = ScriptVerifyCleanStack |
+		ScriptVerifyCheckLockTimeVerify |
+		ScriptVerifyCheckSequenceVerify |
+		ScriptVerifySHA256
+
+	// ConsensusScriptFlags are the script flags enforced by the current
+	// consensus rules.
+	ConsensusScriptFlags = ConsensusPreTreasuryScriptFlags |
+		ScriptVerifyTreasury
+
+	// StandardScriptFlags are the script flags enforced by the current
+	// consensus rules along with the additional flags enforced by the
+	// standardness policy of the mempool.
+	StandardScriptFlags = ConsensusScriptFlags |
+		ScriptDiscourageUpgradableNops
+)
+
+// scriptFlagNames lists the names of the script flags in the order they are
+// formatted along with the shorter aliases also accepted when parsing.
+var scriptFlagNames = []struct {
+	name  string
+	alias string
+	flag  ScriptFlags
+}{
+	{"CHECKLOCKTIMEVERIFY", "cltv", ScriptVerifyCheckLockTimeVerify},
+	{"CHECKSEQUENCEVERIFY", "csv", ScriptVerifyCheckSequenceVerify},
+	{"CLEANSTACK", "", ScriptVerifyCleanStack},
+	{"DISCOURAGE_UPGRADABLE_NOPS", "discourage-nops",
+		ScriptDiscourageUpgradableNops},
+	{"SIGPUSHONLY", "", ScriptVerifySigPushOnly},
+	{"SHA256", "", ScriptVerifySHA256},
+	{"TREASURY", "", ScriptVerifyTreasury},
+}
+
+// scriptFlagPresets maps the names of the presets accepted when parsing script
+// flags to the flags they enable.
+var scriptFlagPresets = map[string]ScriptFlags{
+	"consensus-pre-treasury": ConsensusPreTreasuryScriptFlags,
+	"consensus-current":      ConsensusScriptFlags,
+	"standard":               StandardScriptFlags,
+}
+
+// String returns the names of the script flags separated by commas in the
+// format used by the reference tests, such as "CHECKLOCKTIMEVERIFY,SHA256".
+// It returns "NONE" when no flags are set and unknown flags are formatted as a
+// hex number.
+func (flags ScriptFlags) String() string {
+	var names []string
+	for _, f := range scriptFlagNames {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+			flags &^= f.flag
+		}
+	}
+	if flags != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(flags)))
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseScriptFlags parses script flags separated by commas, such as
+// "CHECKLOCKTIMEVERIFY,SHA256" as returned by String.  Names are case
+// insensitive and may also be one of the shorter aliases cltv, csv and
+// discourage-nops, a hex number of flags, NONE, or one of these presets:
+//
+//   consensus-pre-treasury  ConsensusPreTreasuryScriptFlags
+//   consensus-current       ConsensusScriptFlags
+//   standard                StandardScriptFlags
+//
+// The resulting flags are the union of all of the listed ones.
+func ParseScriptFlags(flagStr string) (ScriptFlags, error) {
+	var flags ScriptFlags
+	for _, name := range strings.Split(flagStr, ",") {
+		name = strings.TrimSpace(name)
+		flag, err := parseScriptFlag(name)
+		if err != nil {
+			return 0, err
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// parseScriptFlag returns the script flags with the provided name.
+func parseScriptFlag(name string) (ScriptFlags, error) {
+	if name == "" || strings.EqualFold(name, "NONE") {
+		return 0, nil
+	}
+	for _, f := range scriptFlagNames {
+		if strings.EqualFold(name, f.name) ||
+			(f.alias != "" && strings.EqualFold(name, f.alias)) {
+
+			return f.flag, nil
+		}
+	}
+	if flags, ok := scriptFlagPresets[strings.ToLower(name)]; ok {
+		return flags, nil
+	}
+	if strings.HasPrefix(name, "0x") {
+		flags, err := strconv.ParseUint(name[2:], 16, 32)
+		if err == nil {
+			return ScriptFlags(flags), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown script flag %q", name)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import "testing"
+
+// TestScriptFlagsString ensures script flags are formatted by name.
+func TestScriptFlagsString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		flags ScriptFlags
+		want  string
+	}{
+		{0, "NONE"},
+		{ScriptVerifyCleanStack, "CLEANSTACK"},
+		{ConsensusScriptFlags,
+			"CHECKLOCKTIMEVERIFY,CHECKSEQUENCEVERIFY,CLEANSTACK,SHA256," +
+				"TREASURY"},
+		{ScriptVerifySigPushOnly | 1<<30, "SIGPUSHONLY,0x40000000"},
+	}
+
+	for _, test := range tests {
+		if got := test.flags.String(); got != test.want {
+			t.Errorf("%d: unexpected string -- got %q, want %q",
+				uint32(test.flags), got, test.want)
+		}
+	}
+}
+
+// TestParseScriptFlags ensures script flags are parsed from names, aliases and
+// presets.
+func TestParseScriptFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		str     string
+		flags   ScriptFlags
+		invalid bool
+	}{
+		{str: "", flags: 0},
+		{str: "NONE", flags: 0},
+		{str: "CHECKLOCKTIMEVERIFY,SHA256",
+			flags: ScriptVerifyCheckLockTimeVerify | ScriptVerifySHA256},
+		{str: "cltv, csv", flags: ScriptVerifyCheckLockTimeVerify |
+			ScriptVerifyCheckSequenceVerify},
+		{str: "cleanstack,discourage-nops", flags: ScriptVerifyCleanStack |
+			ScriptDiscourageUpgradableNops},
+		{str: "consensus-pre-treasury",
+			flags: ConsensusPreTreasuryScriptFlags},
+		{str: "consensus-current", flags: ConsensusScriptFlags},
+		{str: "standard,SIGPUSHONLY",
+			flags: StandardScriptFlags | ScriptVerifySigPushOnly},
+		{str: "SIGPUSHONLY,0x40000000",
+			flags: ScriptVerifySigPushOnly | 1<<30},
+		{str: "P2SH", invalid: true},
+		{str: "0xzz", invalid: true},
+	}
+
+	for _, test := range tests {
+		flags, err := ParseScriptFlags(test.str)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%q: unexpected flags %v", test.str, flags)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.str, err)
+			continue
+		}
+		if flags != test.flags {
+			t.Errorf("%q: unexpected flags -- got %v, want %v", test.str,
+				flags, test.flags)
+			continue
+		}
+
+		// Ensure the formatted flags parse back into the same flags.
+		reparsed, err := ParseScriptFlags(flags.String())
+		if err != nil || reparsed != flags {
+			t.Errorf("%q: round trip mismatch -- got %v (%v)", test.str,
+				reparsed, err)
+		}
+	}
+}
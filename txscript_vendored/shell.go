@@ -0,0 +1,220 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ScriptShell executes scripts incrementally using the opcode implementations
+// of the engine, which allows the effect of every opcode on the stacks to be
+// inspected interactively.
+//
+// The opcodes executed through the shell form the public key script of a
+// synthetic transaction created by CreateSpendingTx whose signature script is
+// the one loaded by LoadSigScript, so opcodes which depend on the transaction,
+// such as signature checks, operate on that transaction.
+//
+// Unlike the engine, the shell does not require conditionals to be balanced
+// since opcodes may still be entered.
+type ScriptShell struct {
+	flags     ScriptFlags
+	sigScript []byte
+	entries   [][]byte
+	vm        *Engine
+	sigChecks *SigCheckRecorder
+}
+
+// NewScriptShell returns a new script shell which executes opcodes with the
+// provided flags.
+func NewScriptShell(flags ScriptFlags) *ScriptShell {
+	s := &ScriptShell{flags: flags}
+	s.vm, _ = s.replay(nil, nil)
+	return s
+}
+
+// runScript executes the remaining opcodes of the current script of the
+// engine.  It mirrors Step without the handling of the end of the script.
+func (vm *Engine) runScript() error {
+	for vm.tokenizer.Next() {
+		err := vm.executeOpcode(vm.tokenizer.op, vm.tokenizer.Data())
+		if err != nil {
+			return err
+		}
+
+		combinedStackSize := vm.dstack.Depth() + vm.astack.Depth()
+		if combinedStackSize > MaxStackSize {
+			str := fmt.Sprintf("combined stack size %d > max allowed %d",
+				combinedStackSize, MaxStackSize)
+			return scriptError(ErrStackOverflow, str)
+		}
+		vm.opcodeIdx++
+	}
+	return vm.tokenizer.Err()
+}
+
+// replay returns an engine that executed the provided signature script
+// followed by the entries as the public key script.  The signature checks it
+// performed are recorded in the shell even when the execution fails.
+func (s *ScriptShell) replay(sigScript []byte, entries [][]byte) (*Engine, error) {
+	pkScript := bytes.Join(entries, nil)
+	tx, err := CreateSpendingTx(sigScript, pkScript, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sigChecks = new(SigCheckRecorder)
+	vm := &Engine{
+		flags:            s.flags,
+		tx:               *tx,
+		scripts:          [][]byte{sigScript, pkScript},
+		tokenizer:        MakeScriptTokenizer(0, sigScript),
+		condDisableDepth: noCondDisableDepth,
+		sigCheckObserver: s.sigChecks,
+	}
+
+	// Execute the signature script and reset the state that does not
+	// persist between scripts as the engine does.
+	if err := vm.runScript(); err != nil {
+		return vm, err
+	}
+	if vm.condNestDepth != 0 {
+		return vm, scriptError(ErrUnbalancedConditional,
+			"end of script reached in conditional execution")
+	}
+	_ = vm.astack.DropN(vm.astack.Depth())
+	vm.numOps = 0
+	vm.opcodeIdx = 0
+
+	vm.scriptIdx = 1
+	vm.tokenizer = MakeScriptTokenizer(0, pkScript)
+	return vm, vm.runScript()
+}
+
+// LoadSigScript clears the shell and executes the provided signature script.
+// The shell is left unchanged when the signature script fails to parse or
+// execute.
+func (s *ScriptShell) LoadSigScript(sigScript []byte) error {
+	if err := checkScriptParses(0, sigScript); err != nil {
+		return err
+	}
+	vm, err := s.replay(sigScript, nil)
+	if err != nil {
+		return err
+	}
+	s.sigScript, s.entries, s.vm = sigScript, nil, vm
+	return nil
+}
+
+// SigScript returns the loaded signature script.
+func (s *ScriptShell) SigScript() []byte {
+	return s.sigScript
+}
+
+// Exec executes the opcodes of the provided script after the previously
+// executed ones.  The shell is left unchanged when the script fails to parse
+// or execute.
+func (s *ScriptShell) Exec(script []byte) error {
+	if err := checkScriptParses(0, script); err != nil {
+		return err
+	}
+	entries := append(s.entries[:len(s.entries):len(s.entries)], script)
+	vm, err := s.replay(s.sigScript, entries)
+	if err != nil {
+		return err
+	}
+	s.entries, s.vm = entries, vm
+	return nil
+}
+
+// Undo reverts the most recent script executed by Exec.  It returns false when
+// there is nothing to undo.
+func (s *ScriptShell) Undo() bool {
+	if len(s.entries) == 0 {
+		return false
+	}
+	s.entries = s.entries[:len(s.entries)-1]
+	s.vm, _ = s.replay(s.sigScript, s.entries)
+	return true
+}
+
+// Clear reverts all of the scripts executed by Exec.  The loaded signature
+// script is kept.
+func (s *ScriptShell) Clear() {
+	s.entries = nil
+	s.vm, _ = s.replay(s.sigScript, nil)
+}
+
+// Script returns the script formed by the scripts executed by Exec.
+func (s *ScriptShell) Script() []byte {
+	return bytes.Join(s.entries, nil)
+}
+
+// Flags returns the flags the shell executes opcodes with.
+func (s *ScriptShell) Flags() ScriptFlags {
+	return s.flags
+}
+
+// SetFlags changes the flags the shell executes opcodes with and executes the
+// loaded signature script and previously executed scripts again with them.
+// The flags are left unchanged when the scripts fail under the new flags.
+func (s *ScriptShell) SetFlags(flags ScriptFlags) error {
+	oldFlags := s.flags
+	s.flags = flags
+	vm, err := s.replay(s.sigScript, s.entries)
+	if err != nil {
+		s.flags = oldFlags
+		return err
+	}
+	s.vm = vm
+	return nil
+}
+
+// SigChecks returns the diagnostics of the signature checks performed by the
+// most recent execution of the scripts by the shell.  Since the scripts are
+// executed again for every change, this includes the checks performed by a
+// script that failed to execute and left the shell unchanged.
+func (s *ScriptShell) SigChecks() *SigCheckRecorder {
+	return s.sigChecks
+}
+
+// Stack returns the data stack from bottom to top.
+func (s *ScriptShell) Stack() [][]byte {
+	return s.vm.GetStack()
+}
+
+// AltStack returns the alternate stack from bottom to top.
+func (s *ScriptShell) AltStack() [][]byte {
+	return s.vm.GetAltStack()
+}
+
+// IsBranchExecuting returns whether opcodes entered next are executed, which
+// is not the case in the untaken branch of a conditional.
+func (s *ScriptShell) IsBranchExecuting() bool {
+	return s.vm.isBranchExecuting()
+}
+
+// StackItem houses the interpretations of a stack element by the opcodes.
+type StackItem struct {
+	// Data is the raw stack element.
+	Data []byte
+
+	// Num is the element interpreted as a number by the arithmetic opcodes.
+	// NumErr is set instead when the element is not a valid number for
+	// them.
+	Num    ScriptNum
+	NumErr error
+
+	// Bool is the element interpreted as a boolean by the conditional
+	// opcodes.
+	Bool bool
+}
+
+// InterpretStackItem returns the interpretations of the provided stack
+// element.
+func InterpretStackItem(data []byte) StackItem {
+	num, err := MakeScriptNum(data, MathOpCodeMaxScriptNumLen)
+	return StackItem{Data: data, Num: num, NumErr: err, Bool: asBool(data)}
+}
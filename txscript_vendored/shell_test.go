@@ -0,0 +1,154 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestScriptShell ensures the script shell executes opcodes incrementally and
+// supports undoing them.
+func TestScriptShell(t *testing.T) {
+	t.Parallel()
+
+	checkStacks := func(s *ScriptShell, desc string, stack, altStack [][]byte) {
+		t.Helper()
+		if got := s.Stack(); !reflect.DeepEqual(got, stack) {
+			t.Fatalf("%s: unexpected stack -- got %x, want %x", desc, got,
+				stack)
+		}
+		if got := s.AltStack(); !reflect.DeepEqual(got, altStack) {
+			t.Fatalf("%s: unexpected alt stack -- got %x, want %x", desc,
+				got, altStack)
+		}
+	}
+
+	s := NewScriptShell(0)
+	checkStacks(s, "new shell", [][]byte{}, [][]byte{})
+	if err := s.LoadSigScript(mustParseShortForm("2 3")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkStacks(s, "sigscript", [][]byte{{2}, {3}}, [][]byte{})
+
+	if err := s.Exec(mustParseShortForm("ADD")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkStacks(s, "add", [][]byte{{5}}, [][]byte{})
+	if err := s.Exec(mustParseShortForm("DUP TOALTSTACK")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkStacks(s, "alt stack", [][]byte{{5}}, [][]byte{{5}})
+
+	// Ensure failing opcodes leave the shell unchanged.
+	err := s.Exec(mustParseShortForm("DROP DROP"))
+	if !errors.Is(err, ErrInvalidStackOperation) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrInvalidStackOperation)
+	}
+	checkStacks(s, "failed exec", [][]byte{{5}}, [][]byte{{5}})
+
+	// Ensure unbalanced conditionals are allowed.
+	if err := s.Exec(mustParseShortForm("0 IF")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.IsBranchExecuting() {
+		t.Fatal("branch unexpectedly executing")
+	}
+	if err := s.Exec(mustParseShortForm("DROP ELSE 6")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkStacks(s, "conditional", [][]byte{{5}, {6}}, [][]byte{{5}})
+
+	// Ensure undo reverts the most recent exec.
+	if !s.Undo() {
+		t.Fatal("nothing to undo")
+	}
+	checkStacks(s, "undo", [][]byte{{5}}, [][]byte{{5}})
+	wantScript := mustParseShortForm("ADD DUP TOALTSTACK 0 IF")
+	if got := s.Script(); !reflect.DeepEqual(got, wantScript) {
+		t.Fatalf("unexpected script -- got %x, want %x", got, wantScript)
+	}
+
+	// Ensure clear keeps the signature script.
+	s.Clear()
+	checkStacks(s, "clear", [][]byte{{2}, {3}}, [][]byte{})
+	if s.Undo() {
+		t.Fatal("undo unexpectedly succeeded after clear")
+	}
+}
+
+// TestScriptShellFlags ensures the script shell honours its flags.
+func TestScriptShellFlags(t *testing.T) {
+	t.Parallel()
+
+	// Ensure the opcode is a no-op without the SHA256 flag.
+	s := NewScriptShell(0)
+	if err := s.Exec(mustParseShortForm("'abc' SHA256")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.Stack(); !reflect.DeepEqual(got, [][]byte{[]byte("abc")}) {
+		t.Fatalf("unexpected stack without the SHA256 flag %x", got)
+	}
+	if err := s.SetFlags(ScriptVerifySHA256); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.Stack(); len(got) != 1 || len(got[0]) != 32 {
+		t.Fatalf("unexpected stack with the SHA256 flag %x", got)
+	}
+
+	// Ensure flags which would make the executed scripts fail are rejected.
+	s.Clear()
+	if err := s.Exec(mustParseShortForm("1 CHECKLOCKTIMEVERIFY")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := s.SetFlags(ScriptVerifySHA256 | ScriptVerifyCheckLockTimeVerify)
+	if !errors.Is(err, ErrUnsatisfiedLockTime) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrUnsatisfiedLockTime)
+	}
+	if s.Flags() != ScriptVerifySHA256 {
+		t.Fatalf("unexpected flags %v", s.Flags())
+	}
+}
+
+// TestInterpretStackItem ensures stack elements are interpreted as numbers and
+// booleans.
+func TestInterpretStackItem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		data    []byte
+		num     ScriptNum
+		invalid bool
+		bool    bool
+	}{
+		{data: nil, num: 0, bool: false},
+		{data: []byte{0x01}, num: 1, bool: true},
+		{data: []byte{0x81}, num: -1, bool: true},
+		{data: []byte{0x80}, invalid: true, bool: false},
+		{data: []byte{0x00, 0x00}, invalid: true, bool: false},
+		{data: []byte{0x01, 0x02, 0x03, 0x04, 0x05}, invalid: true,
+			bool: true},
+	}
+
+	for _, test := range tests {
+		item := InterpretStackItem(test.data)
+		if test.invalid != (item.NumErr != nil) {
+			t.Errorf("%x: unexpected number error %v", test.data,
+				item.NumErr)
+			continue
+		}
+		if !test.invalid && item.Num != test.num {
+			t.Errorf("%x: unexpected number -- got %d, want %d", test.data,
+				item.Num, test.num)
+		}
+		if item.Bool != test.bool {
+			t.Errorf("%x: unexpected bool -- got %v, want %v", test.data,
+				item.Bool, test.bool)
+		}
+	}
+}
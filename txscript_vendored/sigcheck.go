@@ -0,0 +1,372 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/slog"
+)
+
+// SigCheckObserver defines an interface to be notified of the signature checks
+// performed by the signature checking opcodes along with the details of how
+// their result was determined.
+type SigCheckObserver interface {
+	// ObserveMultiSig is invoked with the diagnostics of every executed
+	// OP_CHECKMULTISIG and OP_CHECKMULTISIGVERIFY once the signatures and
+	// public keys are popped from the stack.
+	ObserveMultiSig(diag *MultiSigDiagnostics)
+
+	// ObserveCheckSig is invoked with the diagnostics of every executed
+	// OP_CHECKSIG, OP_CHECKSIGALT and their verify variants once the
+	// signature and public key are popped from the stack.
+	ObserveCheckSig(diag *CheckSigDiagnostics)
+}
+
+// SetSigCheckObserver sets the observer notified of the signature checks
+// performed by the engine, which is nil by default.  The diagnostics are also
+// logged at the trace level.
+//
+// It must only be called prior to executing any scripts.
+func (vm *Engine) SetSigCheckObserver(observer SigCheckObserver) {
+	vm.sigCheckObserver = observer
+}
+
+// observeSigChecks returns whether the diagnostics of signature checks must be
+// collected because an observer is set or they are logged.
+func (vm *Engine) observeSigChecks() bool {
+	return vm.sigCheckObserver != nil || log.Level() <= slog.LevelTrace
+}
+
+// observeMultiSig notifies the observer of the engine of the provided multisig
+// diagnostics and logs them.
+func (vm *Engine) observeMultiSig(diag *MultiSigDiagnostics) {
+	if vm.sigCheckObserver != nil {
+		vm.sigCheckObserver.ObserveMultiSig(diag)
+	}
+	if log.Level() <= slog.LevelTrace {
+		log.Tracef("%v", diag)
+	}
+}
+
+// observeCheckSig notifies the observer of the engine of the provided
+// signature check diagnostics and logs them.
+func (vm *Engine) observeCheckSig(diag *CheckSigDiagnostics) {
+	if vm.sigCheckObserver != nil {
+		vm.sigCheckObserver.ObserveCheckSig(diag)
+	}
+	if log.Level() <= slog.LevelTrace {
+		log.Tracef("%v", diag)
+	}
+}
+
+// SigCheckRecorder is a SigCheckObserver which records the diagnostics of the
+// observed signature checks in the order they are performed for each kind of
+// check.
+type SigCheckRecorder struct {
+	MultiSigs []*MultiSigDiagnostics
+	CheckSigs []*CheckSigDiagnostics
+}
+
+// Ensure SigCheckRecorder implements the SigCheckObserver interface.
+var _ SigCheckObserver = (*SigCheckRecorder)(nil)
+
+// ObserveMultiSig records the provided multisig diagnostics.
+//
+// This is part of the SigCheckObserver interface.
+func (r *SigCheckRecorder) ObserveMultiSig(diag *MultiSigDiagnostics) {
+	r.MultiSigs = append(r.MultiSigs, diag)
+}
+
+// ObserveCheckSig records the provided signature check diagnostics.
+//
+// This is part of the SigCheckObserver interface.
+func (r *SigCheckRecorder) ObserveCheckSig(diag *CheckSigDiagnostics) {
+	r.CheckSigs = append(r.CheckSigs, diag)
+}
+
+// MultiSigSignature houses the details of the checks of a signature popped by
+// OP_CHECKMULTISIG.
+type MultiSigSignature struct {
+	// Signature is the raw signature including the trailing hash type.
+	Signature []byte
+
+	// HashType is the hash type of the signature.  It is zero for empty
+	// signatures.
+	HashType SigHashType
+
+	// Tried houses the indices of the public keys the signature was checked
+	// against in the order they were checked.
+	Tried []int
+
+	// ParseErr is the reason the signature failed to parse, if it did.
+	ParseErr error
+
+	// PubKeyIdx is the index of the public key the signature is valid for,
+	// or -1 when it did not match any.
+	PubKeyIdx int
+}
+
+// MultiSigDiagnostics houses the details of how the result of an executed
+// OP_CHECKMULTISIG or OP_CHECKMULTISIGVERIFY was determined.
+//
+// The opcode walks the signatures and public keys from the last pushed ones,
+// checking each signature against the remaining public keys until it matches
+// one, so a signature fails when it does not match any of the public keys
+// pushed before the one matched by the signature pushed after it.
+type MultiSigDiagnostics struct {
+	// Opcode is either OP_CHECKMULTISIG or OP_CHECKMULTISIGVERIFY.
+	Opcode byte
+
+	// ScriptIdx and OpcodeIdx locate the opcode in the executed scripts as
+	// done by Engine.DisasmPC.
+	ScriptIdx int
+	OpcodeIdx int
+
+	// PubKeys and Signatures house the public keys and signatures in the
+	// order they are pushed by the scripts.  All indices refer to that
+	// order.
+	PubKeys    [][]byte
+	Signatures []MultiSigSignature
+
+	// PubKeyErrs houses the reasons the public keys failed to parse, if
+	// they did, by index.
+	PubKeyErrs []error
+
+	// Success is whether all signatures matched a public key.
+	Success bool
+
+	// Err is the error which terminated the execution of the opcode, such
+	// as an invalid signature encoding, if any.
+	Err error
+}
+
+// newMultiSigDiagnostics returns the diagnostics of an OP_CHECKMULTISIG
+// executed by the engine with the provided public keys and signatures, in the
+// order they are popped from the stack, none of which has been checked yet.
+func newMultiSigDiagnostics(vm *Engine, op byte, pubKeys [][]byte, signatures []*parsedSigInfo) *MultiSigDiagnostics {
+	diag := &MultiSigDiagnostics{
+		Opcode:     op,
+		ScriptIdx:  vm.scriptIdx,
+		OpcodeIdx:  vm.opcodeIdx,
+		PubKeys:    make([][]byte, len(pubKeys)),
+		Signatures: make([]MultiSigSignature, len(signatures)),
+		PubKeyErrs: make([]error, len(pubKeys)),
+	}
+	for i, pubKey := range pubKeys {
+		diag.PubKeys[diag.pubKeyIdx(i)] = pubKey
+	}
+	for i, sigInfo := range signatures {
+		sig := diag.signature(i)
+		sig.Signature = sigInfo.signature
+		sig.PubKeyIdx = -1
+		if len(sig.Signature) != 0 {
+			sig.HashType = SigHashType(sig.Signature[len(sig.Signature)-1])
+		}
+	}
+	return diag
+}
+
+// pubKeyIdx returns the index of the public key popped from the stack at the
+// provided position.
+func (d *MultiSigDiagnostics) pubKeyIdx(popIdx int) int {
+	return len(d.PubKeys) - 1 - popIdx
+}
+
+// signature returns the details of the signature popped from the stack at the
+// provided position.
+func (d *MultiSigDiagnostics) signature(popIdx int) *MultiSigSignature {
+	return &d.Signatures[len(d.Signatures)-1-popIdx]
+}
+
+// String returns a multi-line description of the diagnostics.
+func (d *MultiSigDiagnostics) String() string {
+	var matched int
+	for i := range d.Signatures {
+		if d.Signatures[i].PubKeyIdx >= 0 {
+			matched++
+		}
+	}
+	result := "success"
+	switch {
+	case d.Err != nil:
+		result = fmt.Sprintf("error: %v", d.Err)
+	case !d.Success:
+		result = "failure"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s at %02x:%04x: %d of %d signatures matched %d "+
+		"public keys: %s", opcodeArray[d.Opcode].name, d.ScriptIdx,
+		d.OpcodeIdx, matched, len(d.Signatures), len(d.PubKeys), result)
+	for i := range d.Signatures {
+		sig := &d.Signatures[i]
+		if len(sig.Signature) == 0 {
+			fmt.Fprintf(&b, "\n  signature %d (empty): ", i)
+		} else {
+			fmt.Fprintf(&b, "\n  signature %d %x (hash type 0x%02x): ", i,
+				sig.Signature, byte(sig.HashType))
+		}
+		switch {
+		case len(sig.Tried) == 0:
+			b.WriteString("not checked")
+			continue
+		case sig.PubKeyIdx >= 0:
+			fmt.Fprintf(&b, "matched public key %d", sig.PubKeyIdx)
+		case sig.ParseErr != nil:
+			fmt.Fprintf(&b, "invalid: %v", sig.ParseErr)
+		default:
+			b.WriteString("no match")
+		}
+		tried := make([]string, 0, len(sig.Tried))
+		for _, idx := range sig.Tried {
+			tried = append(tried, fmt.Sprint(idx))
+		}
+		fmt.Fprintf(&b, " (tried public keys %s)", strings.Join(tried, ", "))
+	}
+	for i, pubKey := range d.PubKeys {
+		fmt.Fprintf(&b, "\n  public key %d %x", i, pubKey)
+		if d.PubKeyErrs[i] != nil {
+			fmt.Fprintf(&b, ": invalid: %v", d.PubKeyErrs[i])
+		}
+	}
+	return b.String()
+}
+
+// sigTypeNames maps the signature suites supported by OP_CHECKSIG and
+// OP_CHECKSIGALT to their names.
+var sigTypeNames = map[dcrec.SignatureType]string{
+	dcrec.STEcdsaSecp256k1:   "ECDSA secp256k1",
+	dcrec.STEd25519:          "Ed25519",
+	dcrec.STSchnorrSecp256k1: "Schnorr secp256k1",
+}
+
+// CheckSigDiagnostics houses the details of how the result of an executed
+// OP_CHECKSIG, OP_CHECKSIGALT or their verify variants was determined.
+//
+// The fields are filled in as the opcode progresses, so the ones for the steps
+// it did not reach, such as the signature hash of a signature which does not
+// conform to the strict encoding requirements, are left empty.
+type CheckSigDiagnostics struct {
+	// Opcode is the signature checking opcode.
+	Opcode byte
+
+	// ScriptIdx and OpcodeIdx locate the opcode in the executed scripts as
+	// done by Engine.DisasmPC.
+	ScriptIdx int
+	OpcodeIdx int
+
+	// SigType is the signature suite of the check.  It is always ECDSA
+	// secp256k1 for OP_CHECKSIG.  OP_CHECKSIGALT neither pops nor checks
+	// the signature and public key for unsupported types.
+	SigType dcrec.SignatureType
+
+	// Signature is the raw signature including the trailing hash type, or
+	// nil when it was not popped, and HashType is the hash type of the
+	// signature.
+	Signature []byte
+	HashType  SigHashType
+
+	// PubKey is the raw public key.
+	PubKey []byte
+
+	// SubScript is the script committed to by the signature hash, which is
+	// the portion of the executing script after the most recent
+	// OP_CODESEPARATOR with any pushes of the signature removed.
+	SubScript []byte
+
+	// SigHash is the computed signature hash the signature is checked
+	// against.
+	SigHash []byte
+
+	// SigCacheHit is whether the signature was found in the signature
+	// cache of the engine instead of being verified.
+	SigCacheHit bool
+
+	// ParseErr is the reason the signature or public key failed to parse
+	// or the signature hash failed to be computed, if any, which makes the
+	// check fail without verifying the signature.
+	ParseErr error
+
+	// Valid is the result of the check.
+	Valid bool
+
+	// Err is the error which terminated the execution of the opcode, such
+	// as an invalid signature encoding, if any.
+	Err error
+}
+
+// newCheckSigDiagnostics returns the diagnostics of a signature check of the
+// provided type executed by the engine, none of which has been performed yet.
+func newCheckSigDiagnostics(vm *Engine, op byte, sigType dcrec.SignatureType) *CheckSigDiagnostics {
+	return &CheckSigDiagnostics{
+		Opcode:    op,
+		ScriptIdx: vm.scriptIdx,
+		OpcodeIdx: vm.opcodeIdx,
+		SigType:   sigType,
+	}
+}
+
+// setSignature records the provided raw signature along with its hash type.
+// Empty signatures are recorded as non-nil to distinguish them from signatures
+// which were not popped.
+func (d *CheckSigDiagnostics) setSignature(sig []byte) {
+	if sig == nil {
+		sig = []byte{}
+	}
+	d.Signature = sig
+	if len(sig) != 0 {
+		d.HashType = SigHashType(sig[len(sig)-1])
+	}
+}
+
+// String returns a multi-line description of the diagnostics.
+func (d *CheckSigDiagnostics) String() string {
+	sigType, ok := sigTypeNames[d.SigType]
+	if !ok {
+		sigType = fmt.Sprintf("unknown type %d", d.SigType)
+	}
+	result := "invalid"
+	switch {
+	case d.Err != nil:
+		result = fmt.Sprintf("error: %v", d.Err)
+	case d.Valid:
+		result = "valid"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s at %02x:%04x: %s signature %s",
+		opcodeArray[d.Opcode].name, d.ScriptIdx, d.OpcodeIdx, sigType, result)
+	if d.Signature == nil && d.PubKey == nil {
+		b.WriteString(" (not checked)")
+		return b.String()
+	}
+	switch {
+	case d.Signature == nil:
+		b.WriteString("\n  signature (not popped)")
+	case len(d.Signature) == 0:
+		b.WriteString("\n  signature (empty)")
+	default:
+		fmt.Fprintf(&b, "\n  signature %x (hash type 0x%02x)", d.Signature,
+			byte(d.HashType))
+	}
+	fmt.Fprintf(&b, "\n  public key %x", d.PubKey)
+	if d.SubScript != nil {
+		disasm, _ := DisasmString(d.SubScript)
+		fmt.Fprintf(&b, "\n  subscript %x (%s)", d.SubScript, disasm)
+	}
+	if d.SigHash != nil {
+		fmt.Fprintf(&b, "\n  signature hash %x", d.SigHash)
+	}
+	if d.SigCacheHit {
+		b.WriteString("\n  found in the signature cache")
+	}
+	if d.ParseErr != nil {
+		fmt.Fprintf(&b, "\n  not verified: %v", d.ParseErr)
+	}
+	return b.String()
+}
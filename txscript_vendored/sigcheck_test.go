@@ -0,0 +1,457 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// TestMultiSigDiagnostics ensures the diagnostics of OP_CHECKMULTISIG report
+// which public keys each signature was checked against and matched, as well
+// as signatures and public keys that fail to parse.
+func TestMultiSigDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	keys := testSecpKeys(t, 3)
+	oneOfOne, err := MultiSigScript([]*dcrutil.AddressSecpPubKey{
+		keys[0].addr}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	oneOfTwo, err := MultiSigScript([]*dcrutil.AddressSecpPubKey{
+		keys[0].addr, keys[1].addr}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	twoOfThree, err := MultiSigScript([]*dcrutil.AddressSecpPubKey{
+		keys[0].addr, keys[1].addr, keys[2].addr}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second public key is encoded correctly but its x coordinate
+	// exceeds the field prime, so it can not be built with MultiSigScript.
+	offCurve := append([]byte{0x02}, bytes.Repeat([]byte{0xff}, 32)...)
+	offCurveScript, err := NewScriptBuilder().AddOp(OP_1).
+		AddData(keys[1].pubKey).AddData(offCurve).AddOp(OP_2).
+		AddOp(OP_CHECKMULTISIG).Script()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type wantSig struct {
+		tried     []int
+		pubKeyIdx int
+		parseErr  bool
+	}
+	tests := []struct {
+		name       string
+		pkScript   []byte
+		signers    []int // -1 for an empty signature
+		hashType   SigHashType
+		success    bool
+		sigs       []wantSig
+		pubKeyErrs []bool
+		err        error
+	}{{
+		name:     "signatures in key order",
+		pkScript: twoOfThree,
+		signers:  []int{0, 2},
+		hashType: SigHashAll,
+		success:  true,
+		sigs: []wantSig{
+			{tried: []int{1, 0}, pubKeyIdx: 0},
+			{tried: []int{2}, pubKeyIdx: 2},
+		},
+		pubKeyErrs: []bool{false, false, false},
+	}, {
+		name:     "signatures out of key order",
+		pkScript: twoOfThree,
+		signers:  []int{2, 0},
+		hashType: SigHashAll,
+		sigs: []wantSig{
+			{pubKeyIdx: -1},
+			{tried: []int{2, 1}, pubKeyIdx: -1},
+		},
+		pubKeyErrs: []bool{false, false, false},
+	}, {
+		name:     "empty signature",
+		pkScript: oneOfTwo,
+		signers:  []int{-1},
+		hashType: SigHashAll,
+		sigs: []wantSig{
+			{tried: []int{1, 0}, pubKeyIdx: -1},
+		},
+		pubKeyErrs: []bool{false, false},
+	}, {
+		name:     "public key off the curve",
+		pkScript: offCurveScript,
+		signers:  []int{1},
+		hashType: SigHashAll,
+		success:  true,
+		sigs: []wantSig{
+			{tried: []int{1, 0}, pubKeyIdx: 0},
+		},
+		pubKeyErrs: []bool{false, true},
+	}, {
+		name:     "invalid hash type",
+		pkScript: oneOfOne,
+		signers:  []int{0},
+		hashType: 0x1f,
+		sigs: []wantSig{
+			{tried: []int{0}, pubKeyIdx: -1, parseErr: true},
+		},
+		pubKeyErrs: []bool{false},
+		err:        ErrInvalidSigHashType,
+	}}
+
+	for _, test := range tests {
+		tx, err := CreateSpendingTx(nil, test.pkScript, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		builder := NewScriptBuilder()
+		for _, signer := range test.signers {
+			if signer < 0 {
+				builder.AddOp(OP_0)
+				continue
+			}
+			sig, err := RawTxInSignature(tx, 0, test.pkScript,
+				test.hashType, keys[signer].privKey,
+				dcrec.STEcdsaSecp256k1)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+			builder.AddData(sig)
+		}
+		sigScript, err := builder.Script()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+
+		vm, err := NewEngine(test.pkScript, tx, 0, ConsensusScriptFlags, 0,
+			nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		var recorder SigCheckRecorder
+		vm.SetSigCheckObserver(&recorder)
+		err = vm.Execute()
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+
+		if len(recorder.MultiSigs) != 1 {
+			t.Errorf("%q: unexpected number of diagnostics %d", test.name,
+				len(recorder.MultiSigs))
+			continue
+		}
+		diag := recorder.MultiSigs[0]
+		if diag.Opcode != OP_CHECKMULTISIG || diag.ScriptIdx != 1 {
+			t.Errorf("%q: unexpected opcode location %s at script %d",
+				test.name, opcodeArray[diag.Opcode].name, diag.ScriptIdx)
+		}
+		if diag.Success != test.success {
+			t.Errorf("%q: unexpected success -- got %v, want %v",
+				test.name, diag.Success, test.success)
+		}
+		if !errors.Is(diag.Err, test.err) {
+			t.Errorf("%q: unexpected diagnostics error -- got %v, want %v",
+				test.name, diag.Err, test.err)
+		}
+		if len(diag.Signatures) != len(test.sigs) {
+			t.Errorf("%q: unexpected number of signatures %d", test.name,
+				len(diag.Signatures))
+			continue
+		}
+		for i, want := range test.sigs {
+			sig := &diag.Signatures[i]
+			if !reflect.DeepEqual(sig.Tried, want.tried) ||
+				sig.PubKeyIdx != want.pubKeyIdx ||
+				(sig.ParseErr != nil) != want.parseErr {
+
+				t.Errorf("%q: unexpected signature %d details -- got "+
+					"tried %v, matched %d, parse error %v", test.name,
+					i, sig.Tried, sig.PubKeyIdx, sig.ParseErr)
+			}
+			if len(sig.Signature) != 0 && sig.HashType != test.hashType {
+				t.Errorf("%q: unexpected signature %d hash type %v",
+					test.name, i, sig.HashType)
+			}
+		}
+		for i, want := range test.pubKeyErrs {
+			if (diag.PubKeyErrs[i] != nil) != want {
+				t.Errorf("%q: unexpected public key %d error: %v",
+					test.name, i, diag.PubKeyErrs[i])
+			}
+		}
+		if !strings.HasPrefix(diag.String(), "OP_CHECKMULTISIG at 01:") {
+			t.Errorf("%q: unexpected description %q", test.name,
+				diag.String())
+		}
+	}
+}
+
+// TestMultiSigDiagnosticsSigVerifier ensures the diagnostics of
+// OP_CHECKMULTISIG are recorded when signatures are checked by a signature
+// verifier and by the script shell even when the script fails.
+func TestMultiSigDiagnosticsSigVerifier(t *testing.T) {
+	t.Parallel()
+
+	pkScript := mustParseShortForm("2 'pk:alice' 'pk:bob' 'pk:carol' 3 " +
+		"CHECKMULTISIGVERIFY 1")
+	sigScript := mustParseShortForm("'sig:alice' 'sig:carol'")
+	tx, err := CreateSpendingTx(sigScript, pkScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vm, err := NewEngine(pkScript, tx, 0, ConsensusScriptFlags, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var recorder SigCheckRecorder
+	vm.SetSigVerifier(NamedKeySigVerifier{})
+	vm.SetSigCheckObserver(&recorder)
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorder.MultiSigs) != 1 {
+		t.Fatalf("unexpected number of diagnostics %d",
+			len(recorder.MultiSigs))
+	}
+	diag := recorder.MultiSigs[0]
+	if !diag.Success || diag.Opcode != OP_CHECKMULTISIGVERIFY ||
+		diag.Signatures[0].PubKeyIdx != 0 ||
+		diag.Signatures[1].PubKeyIdx != 2 {
+
+		t.Fatalf("unexpected diagnostics:\n%v", diag)
+	}
+
+	// Ensure the shell records the diagnostics of a failed check which
+	// leaves the shell unchanged.
+	shell := NewScriptShell(ConsensusScriptFlags)
+	err = shell.Exec(mustParseShortForm("0 1 DATA_2 0x0102 1 " +
+		"CHECKMULTISIGVERIFY"))
+	if !errors.Is(err, ErrCheckMultiSigVerify) {
+		t.Fatalf("unexpected error -- got %v, want %v", err,
+			ErrCheckMultiSigVerify)
+	}
+	if len(shell.Script()) != 0 {
+		t.Fatal("failed script was executed by the shell")
+	}
+	multiSigs := shell.SigChecks().MultiSigs
+	if len(multiSigs) != 1 || multiSigs[0].Success ||
+		len(multiSigs[0].PubKeys) != 1 {
+
+		t.Fatalf("unexpected shell diagnostics %v", multiSigs)
+	}
+}
+
+// TestCheckSigDiagnostics ensures the diagnostics of OP_CHECKSIG and
+// OP_CHECKSIGALT report the subscript and signature hash the signature is
+// checked against, whether the signature cache answered and why signatures
+// are not verified.
+func TestCheckSigDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	keys := testSecpKeys(t, 2)
+	privKey, otherPrivKey := keys[0].privKey, keys[1].privKey
+	secpPubKey := keys[0].pubKey
+	edPrivKey, edPubKey := edwards.PrivKeyFromSecret(privKey)
+
+	// checkSig returns a public key script which checks a signature of the
+	// provided type for the public key.
+	checkSig := func(sigType dcrec.SignatureType, pubKey []byte) []byte {
+		builder := NewScriptBuilder().AddData(pubKey)
+		if sigType == dcrec.STEcdsaSecp256k1 {
+			builder.AddOp(OP_CHECKSIG)
+		} else {
+			builder.AddInt64(int64(sigType)).AddOp(OP_CHECKSIGALT)
+		}
+		script, err := builder.Script()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return script
+	}
+
+	tests := []struct {
+		name     string
+		pkScript []byte
+		pkSig    bool   // push the signature in the public key script
+		signer   []byte // nil for a raw signature
+		sigType  dcrec.SignatureType
+		rawSig   []byte
+		hashType SigHashType
+		valid    bool
+		sigHash  bool
+		parseErr error
+		err      error
+	}{{
+		name:     "valid ecdsa",
+		pkScript: checkSig(dcrec.STEcdsaSecp256k1, secpPubKey),
+		signer:   privKey,
+		sigType:  dcrec.STEcdsaSecp256k1,
+		hashType: SigHashAll,
+		valid:    true,
+		sigHash:  true,
+	}, {
+		name:     "ecdsa by another key",
+		pkScript: checkSig(dcrec.STEcdsaSecp256k1, secpPubKey),
+		signer:   otherPrivKey,
+		sigType:  dcrec.STEcdsaSecp256k1,
+		hashType: SigHashSingle,
+		sigHash:  true,
+	}, {
+		name:     "ecdsa signature pushed by the public key script",
+		pkScript: checkSig(dcrec.STEcdsaSecp256k1, secpPubKey),
+		pkSig:    true,
+		signer:   privKey,
+		sigType:  dcrec.STEcdsaSecp256k1,
+		hashType: SigHashAll,
+		valid:    true,
+		sigHash:  true,
+	}, {
+		name:     "valid schnorr",
+		pkScript: checkSig(dcrec.STSchnorrSecp256k1, secpPubKey),
+		signer:   privKey,
+		sigType:  dcrec.STSchnorrSecp256k1,
+		hashType: SigHashAll,
+		valid:    true,
+		sigHash:  true,
+	}, {
+		name:     "valid ed25519",
+		pkScript: checkSig(dcrec.STEd25519, edPubKey.Serialize()),
+		signer:   edPrivKey.SerializeSecret(),
+		sigType:  dcrec.STEd25519,
+		hashType: SigHashNone,
+		valid:    true,
+		sigHash:  true,
+	}, {
+		name:     "schnorr with ed25519 public key",
+		pkScript: checkSig(dcrec.STSchnorrSecp256k1, edPubKey.Serialize()),
+		sigType:  dcrec.STSchnorrSecp256k1,
+		rawSig:   []byte{0x01},
+		parseErr: ErrPubKeyType,
+	}, {
+		name:     "short ed25519 signature",
+		pkScript: checkSig(dcrec.STEd25519, edPubKey.Serialize()),
+		sigType:  dcrec.STEd25519,
+		rawSig:   []byte{0x01, 0x01},
+		parseErr: ErrSigInvalidDataLen,
+	}, {
+		name:     "unknown signature type",
+		pkScript: checkSig(3, secpPubKey),
+		sigType:  3,
+		rawSig:   []byte{0x01},
+		valid:    true,
+	}, {
+		name:     "invalid hash type",
+		pkScript: checkSig(dcrec.STEcdsaSecp256k1, secpPubKey),
+		signer:   privKey,
+		sigType:  dcrec.STEcdsaSecp256k1,
+		hashType: 0x1f,
+		err:      ErrInvalidSigHashType,
+	}}
+
+	for _, test := range tests {
+		tx, err := CreateSpendingTx(nil, test.pkScript, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		sig := test.rawSig
+		if test.signer != nil {
+			sig, err = RawTxInSignature(tx, 0, test.pkScript,
+				test.hashType, test.signer, test.sigType)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+		}
+		sigPush, err := NewScriptBuilder().AddData(sig).Script()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+
+		// The signature is removed from the subscript when it is pushed
+		// by the public key script since it can not sign itself.
+		pkScript := test.pkScript
+		if test.pkSig {
+			pkScript = append(sigPush, pkScript...)
+		} else {
+			tx.TxIn[0].SignatureScript = sigPush
+		}
+
+		// Execute the scripts twice with a signature cache to ensure the
+		// second execution is answered by the cache for valid ECDSA
+		// signatures.
+		sigCache := NewSigCache(10)
+		for i := 0; i < 2; i++ {
+			vm, err := NewEngine(pkScript, tx, 0,
+				ConsensusScriptFlags, 0, sigCache)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+			var recorder SigCheckRecorder
+			vm.SetSigCheckObserver(&recorder)
+			err = vm.Execute()
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("%q: unexpected error -- got %v, want %v",
+					test.name, err, test.err)
+			}
+			if len(recorder.CheckSigs) != 1 {
+				t.Fatalf("%q: unexpected number of diagnostics %d",
+					test.name, len(recorder.CheckSigs))
+			}
+
+			diag := recorder.CheckSigs[0]
+			if diag.ScriptIdx != 1 || diag.SigType != test.sigType {
+				t.Errorf("%q: unexpected location or type:\n%v",
+					test.name, diag)
+			}
+			if diag.Valid != test.valid || !errors.Is(diag.Err, test.err) ||
+				!errors.Is(diag.ParseErr, test.parseErr) {
+
+				t.Errorf("%q: unexpected result:\n%v", test.name, diag)
+			}
+			if !test.sigHash {
+				if diag.SigHash != nil {
+					t.Errorf("%q: unexpected signature hash %x",
+						test.name, diag.SigHash)
+				}
+				continue
+			}
+			wantHash, err := CalcSignatureHash(test.pkScript, test.hashType,
+				tx, 0, nil)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", test.name, err)
+			}
+			if !bytes.Equal(diag.SubScript, test.pkScript) ||
+				!bytes.Equal(diag.SigHash, wantHash) ||
+				diag.HashType != test.hashType ||
+				!bytes.Equal(diag.Signature, sig) {
+
+				t.Errorf("%q: unexpected signature details:\n%v",
+					test.name, diag)
+			}
+			wantHit := i == 1 && test.valid &&
+				test.sigType == dcrec.STEcdsaSecp256k1
+			if diag.SigCacheHit != wantHit {
+				t.Errorf("%q: unexpected signature cache hit %v on "+
+					"execution %d", test.name, diag.SigCacheHit, i)
+			}
+		}
+	}
+}
@@ -18,6 +18,21 @@ import (
 	"github.com/decred/dcrd/wire"
 )
 
+// edwardsPrivKeyFromBytes returns the Ed25519 private and public keys of the
+// provided private key, which is either the 32-byte secret followed by the
+// public key or the 32-byte big endian private scalar of a WIF-encoded key.
+// It returns nil keys when the private key is invalid.
+func edwardsPrivKeyFromBytes(key []byte) (*edwards.PrivateKey, *edwards.PublicKey) {
+	if len(key) == edwards.PrivScalarSize {
+		priv, pub, err := edwards.PrivKeyFromScalar(key)
+		if err != nil {
+			return nil, nil
+		}
+		return priv, pub
+	}
+	return edwards.PrivKeyFromBytes(key)
+}
+
 // RawTxInSignature returns the serialized ECDSA signature for the input idx of
 // the given transaction, with hashType appended to it.
 //
@@ -39,7 +54,7 @@ func RawTxInSignature(tx *wire.MsgTx, idx int, subScript []byte,
 		sig := ecdsa.Sign(priv, hash)
 		sigBytes = sig.Serialize()
 	case dcrec.STEd25519:
-		priv, _ := edwards.PrivKeyFromBytes(key)
+		priv, _ := edwardsPrivKeyFromBytes(key)
 		if priv == nil {
 			return nil, fmt.Errorf("invalid privkey")
 		}
@@ -88,7 +103,10 @@ func SignatureScript(tx *wire.MsgTx, idx int, subscript []byte, hashType SigHash
 			pkData = priv.PubKey().SerializeUncompressed()
 		}
 	case dcrec.STEd25519:
-		_, pub := edwards.PrivKeyFromBytes(privKey)
+		_, pub := edwardsPrivKeyFromBytes(privKey)
+		if pub == nil {
+			return nil, fmt.Errorf("invalid privkey")
+		}
 		pkData = pub.Serialize()
 	case dcrec.STSchnorrSecp256k1:
 		priv := secp256k1.PrivKeyFromBytes(privKey)
@@ -0,0 +1,86 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+
+	"github.com/decred/dcrd/dcrec"
+)
+
+// SigVerifier defines an interface to verify the signatures checked by the
+// signature checking opcodes in place of the engine.  This allows the logic of
+// scripts to be tested without real keys and signatures.
+type SigVerifier interface {
+	// VerifySig returns whether the provided signature, which includes the
+	// trailing hash type byte, is a valid signature of the hash by the
+	// public key for the signature type.  The signature type is
+	// dcrec.STEcdsaSecp256k1 for OP_CHECKSIG and OP_CHECKMULTISIG and the
+	// one popped from the stack for OP_CHECKSIGALT.  The hash is the
+	// signature hash computed according to the final byte of the signature
+	// as the hash type, or nil when it can not be computed.
+	VerifySig(sigType dcrec.SignatureType, sig, pubKey, hash []byte) bool
+}
+
+// SetSigVerifier sets the verifier used to verify the signatures checked by
+// the signature checking opcodes, which is nil by default.  When it is set,
+// the encoding of the signatures and public keys is not checked and their
+// validity is entirely determined by the verifier.  Unknown OP_CHECKSIGALT
+// signature types still succeed without invoking the verifier.
+//
+// It must only be called prior to executing any scripts and is not intended
+// for validating transactions.
+func (vm *Engine) SetSigVerifier(verifier SigVerifier) {
+	vm.sigVerifier = verifier
+}
+
+// verifySig invokes the signature verifier of the engine with the signature
+// hash of the provided subscript.
+func (vm *Engine) verifySig(sigType dcrec.SignatureType, sig, pubKey, subScript []byte) bool {
+	return vm.sigVerifier.VerifySig(sigType, sig, pubKey,
+		vm.verifierSigHash(sig, subScript))
+}
+
+// verifierSigHash returns the signature hash of the provided subscript
+// according to the hash type of the provided signature, which must not be
+// empty, or nil when it can not be computed.
+func (vm *Engine) verifierSigHash(sig, subScript []byte) []byte {
+	hashType := SigHashType(sig[len(sig)-1])
+	hash, err := calcSignatureHash(subScript, hashType, &vm.tx, vm.txIdx, nil)
+	if err != nil {
+		return nil
+	}
+	return hash
+}
+
+var (
+	// namedSigPrefix and namedPubKeyPrefix are the prefixes of the
+	// signatures and public keys recognized by NamedKeySigVerifier.
+	namedSigPrefix    = []byte("sig:")
+	namedPubKeyPrefix = []byte("pk:")
+)
+
+// NamedKeySigVerifier is a signature verifier for testing which considers a
+// signature of the form sig:<name> valid for a public key of the form
+// pk:<name>, such as sig:alice and pk:alice, regardless of the signature type
+// and the transaction.  All other signatures are invalid.
+type NamedKeySigVerifier struct{}
+
+// Ensure NamedKeySigVerifier implements the SigVerifier interface.
+var _ SigVerifier = NamedKeySigVerifier{}
+
+// VerifySig returns whether the provided signature and public key are of the
+// form sig:<name> and pk:<name> with the same name.
+//
+// This is part of the SigVerifier interface.
+func (NamedKeySigVerifier) VerifySig(sigType dcrec.SignatureType, sig, pubKey, hash []byte) bool {
+	if !bytes.HasPrefix(sig, namedSigPrefix) ||
+		!bytes.HasPrefix(pubKey, namedPubKeyPrefix) {
+
+		return false
+	}
+	name := sig[len(namedSigPrefix):]
+	return len(name) > 0 && bytes.Equal(name, pubKey[len(namedPubKeyPrefix):])
+}
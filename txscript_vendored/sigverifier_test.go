@@ -0,0 +1,101 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// TestNamedKeySigVerifier ensures the signature checking opcodes defer to the
+// signature verifier of the engine so the branches of a pay-to-script-hash
+// contract can be exercised with named keys.
+func TestNamedKeySigVerifier(t *testing.T) {
+	t.Parallel()
+
+	// The contract may be spent by alice alone, by two of bob, carol and
+	// dave, or by erin with a Schnorr signature.
+	redeemScript := mustParseShortForm("DUP 1 EQUAL IF DROP 'pk:alice' " +
+		"CHECKSIG ELSE 2 EQUAL IF 2 'pk:bob' 'pk:carol' 'pk:dave' 3 " +
+		"CHECKMULTISIG ELSE 'pk:erin' 2 CHECKSIGALT ENDIF ENDIF")
+	pkScript, err := PayToScriptHashScript(dcrutil.Hash160(redeemScript))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		sigScript string
+		err       error
+	}{{
+		name:      "alice",
+		sigScript: "'sig:alice' 1",
+	}, {
+		name:      "wrong signer for alice",
+		sigScript: "'sig:bob' 1",
+		err:       ErrEvalFalse,
+	}, {
+		name:      "bob and dave",
+		sigScript: "0 'sig:bob' 'sig:dave' 2",
+	}, {
+		name:      "multisig signatures out of order",
+		sigScript: "0 'sig:dave' 'sig:bob' 2",
+		err:       ErrEvalFalse,
+	}, {
+		name:      "erin",
+		sigScript: "'sig:erin' 3",
+	}, {
+		name:      "empty signature for erin",
+		sigScript: "0 3",
+		err:       ErrEvalFalse,
+	}}
+
+	for _, test := range tests {
+		sigScript, err := NewScriptBuilder().
+			AddOps(mustParseShortForm(test.sigScript)).
+			AddData(redeemScript).Script()
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		tx, err := CreateSpendingTx(sigScript, pkScript, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		vm, err := NewEngine(pkScript, tx, 0, 0, 0, nil)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		vm.SetSigVerifier(NamedKeySigVerifier{})
+		err = vm.Execute()
+		if test.err == nil && err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+
+	// Ensure named keys are not valid without the verifier.
+	sigScript, err := NewScriptBuilder().AddData([]byte("sig:alice")).
+		AddInt64(1).AddData(redeemScript).Script()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx, err := CreateSpendingTx(sigScript, pkScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vm, err := NewEngine(pkScript, tx, 0, 0, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := vm.Execute(); err == nil {
+		t.Fatal("unexpected success without the signature verifier")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// SpendingTxConfig houses the parameters of a synthetic spending transaction
+// created by CreateSpendingTx.  All fields are used as provided, so the
+// sequence of the spending input, for example, is zero unless it is set.
+type SpendingTxConfig struct {
+	// Version, LockTime and Expiry are the version, lock time and expiry of
+	// the spending transaction.
+	Version  uint16
+	LockTime uint32
+	Expiry   uint32
+
+	// Sequence is the sequence number of the spending input.
+	Sequence uint32
+
+	// Amount is the value of the output being spent.
+	Amount int64
+
+	// InputIndex is the index of the spending input and NumInputs is the
+	// total number of inputs of the transaction.  The inputs other than
+	// the spending input spend distinct dummy outpoints with empty
+	// signature scripts.  A NumInputs of zero is treated as InputIndex+1.
+	InputIndex int
+	NumInputs  int
+
+	// Outputs are the outputs of the spending transaction.  A single empty
+	// output is added when none are provided.
+	Outputs []*wire.TxOut
+}
+
+// CreateFundingTx creates the synthetic transaction with a single null input
+// and a single output paying the provided amount to the public key script whose
+// output is spent by the transactions created by CreateSpendingTx.
+func CreateFundingTx(pkScript []byte, amount int64) *wire.MsgTx {
+	fundingTx := wire.NewMsgTx()
+	outPoint := wire.NewOutPoint(&chainhash.Hash{}, ^uint32(0),
+		wire.TxTreeRegular)
+	fundingTx.AddTxIn(wire.NewTxIn(outPoint, 0, []byte{OP_0, OP_0}))
+	fundingTx.AddTxOut(wire.NewTxOut(amount, pkScript))
+	return fundingTx
+}
+
+// CreateSpendingTx creates a synthetic transaction which spends an output
+// paying to the provided public key script with the provided signature script,
+// which allows scripts to be executed by the engine without a real
+// transaction.  The spent output is the output of the transaction created by
+// CreateFundingTx.
+//
+// When cfg is nil, the transaction is a version 1 transaction with a single
+// input of maximum sequence number and a single empty output, which matches
+// the transactions used by the reference script tests.
+//
+// The index of the spending input is cfg.InputIndex.  An error with kind
+// ErrInvalidIndex is returned when it is not an index of the inputs.
+func CreateSpendingTx(sigScript, pkScript []byte, cfg *SpendingTxConfig) (*wire.MsgTx, error) {
+	if cfg == nil {
+		cfg = &SpendingTxConfig{
+			Version:  wire.TxVersion,
+			Sequence: wire.MaxTxInSequenceNum,
+		}
+	}
+	numInputs := cfg.NumInputs
+	if numInputs == 0 {
+		numInputs = cfg.InputIndex + 1
+	}
+	if cfg.InputIndex < 0 || cfg.InputIndex >= numInputs {
+		str := fmt.Sprintf("input index %d is not an index of the %d "+
+			"inputs", cfg.InputIndex, numInputs)
+		return nil, scriptError(ErrInvalidIndex, str)
+	}
+
+	fundingTx := CreateFundingTx(pkScript, cfg.Amount)
+	fundingTxHash := fundingTx.TxHash()
+
+	spendingTx := wire.NewMsgTx()
+	spendingTx.Version = cfg.Version
+	spendingTx.LockTime = cfg.LockTime
+	spendingTx.Expiry = cfg.Expiry
+	for i := 0; i < numInputs; i++ {
+		if i == cfg.InputIndex {
+			outPoint := wire.NewOutPoint(&fundingTxHash, 0,
+				wire.TxTreeRegular)
+			txIn := wire.NewTxIn(outPoint, cfg.Amount, sigScript)
+			txIn.Sequence = cfg.Sequence
+			spendingTx.AddTxIn(txIn)
+			continue
+		}
+
+		// The other inputs spend distinct nonexistent outputs of the
+		// funding transaction since they are never executed.
+		outPoint := wire.NewOutPoint(&fundingTxHash, uint32(i+1),
+			wire.TxTreeRegular)
+		spendingTx.AddTxIn(wire.NewTxIn(outPoint, 0, nil))
+	}
+
+	if len(cfg.Outputs) == 0 {
+		spendingTx.AddTxOut(wire.NewTxOut(0, nil))
+	}
+	for _, txOut := range cfg.Outputs {
+		spendingTx.AddTxOut(txOut)
+	}
+	return spendingTx, nil
+}
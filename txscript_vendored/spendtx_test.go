@@ -0,0 +1,102 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestCreateSpendingTx ensures synthetic spending transactions are created
+// according to their configuration and can be executed by the engine.
+func TestCreateSpendingTx(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sigScript string
+		pkScript  string
+		cfg       *SpendingTxConfig
+		numIns    int
+		numOuts   int
+		err       error
+	}{{
+		name:      "default configuration",
+		sigScript: "1",
+		pkScript:  "1 EQUAL",
+		numIns:    1,
+		numOuts:   1,
+	}, {
+		name:      "lock time satisfied",
+		sigScript: "",
+		pkScript:  "500 CHECKLOCKTIMEVERIFY",
+		cfg:       &SpendingTxConfig{Version: 1, LockTime: 600},
+		numIns:    1,
+		numOuts:   1,
+	}, {
+		name:      "lock time not satisfied",
+		sigScript: "",
+		pkScript:  "500 CHECKLOCKTIMEVERIFY",
+		cfg:       &SpendingTxConfig{Version: 1, LockTime: 400},
+		numIns:    1,
+		numOuts:   1,
+		err:       ErrUnsatisfiedLockTime,
+	}, {
+		name:      "relative lock time with other inputs and outputs",
+		sigScript: "",
+		pkScript:  "10 CHECKSEQUENCEVERIFY",
+		cfg: &SpendingTxConfig{
+			Version:    2,
+			Sequence:   20,
+			InputIndex: 1,
+			NumInputs:  3,
+			Outputs: []*wire.TxOut{
+				wire.NewTxOut(1, nil),
+				wire.NewTxOut(2, nil),
+			},
+		},
+		numIns:  3,
+		numOuts: 2,
+	}, {
+		name:     "input index out of range",
+		pkScript: "1",
+		cfg:      &SpendingTxConfig{InputIndex: 2, NumInputs: 2},
+		err:      ErrInvalidIndex,
+	}}
+
+	const flags = ScriptVerifyCheckLockTimeVerify |
+		ScriptVerifyCheckSequenceVerify
+	for _, test := range tests {
+		sigScript := mustParseShortForm(test.sigScript)
+		pkScript := mustParseShortForm(test.pkScript)
+		tx, err := CreateSpendingTx(sigScript, pkScript, test.cfg)
+		if err == nil {
+			if len(tx.TxIn) != test.numIns || len(tx.TxOut) != test.numOuts {
+				t.Errorf("%q: unexpected number of inputs %d or outputs %d",
+					test.name, len(tx.TxIn), len(tx.TxOut))
+				continue
+			}
+			var idx int
+			if test.cfg != nil {
+				idx = test.cfg.InputIndex
+			}
+			var vm *Engine
+			vm, err = NewEngine(pkScript, tx, idx, flags, 0, nil)
+			if err == nil {
+				err = vm.Execute()
+			}
+		}
+		if test.err == nil && err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+}
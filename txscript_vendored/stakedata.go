@@ -0,0 +1,187 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/binary"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+const (
+	// sstxCommitmentDataLen is the length of the data pushed by a ticket
+	// commitment output.  It consists of a 20-byte hash, an 8-byte amount
+	// and 2 bytes of fee limits.
+	sstxCommitmentDataLen = 20 + 8 + 2
+
+	// sstxCommitmentScriptHashFlag is the bit of the most significant byte
+	// of the amount that is set when the commitment is to a script hash.
+	sstxCommitmentScriptHashFlag = 1 << 7
+
+	// SStxVoteFeeLimitMask is the mask of the fee limits of a ticket
+	// commitment which houses the exponent of the fee limit for votes.
+	SStxVoteFeeLimitMask = 0x003f
+
+	// SStxVoteFeeLimitFlag is the bit of the fee limits of a ticket
+	// commitment which indicates the fee limit for votes is enabled.
+	SStxVoteFeeLimitFlag = 0x0040
+
+	// SStxRevocationFeeLimitMask is the mask of the fee limits of a ticket
+	// commitment which houses the exponent of the fee limit for
+	// revocations.
+	SStxRevocationFeeLimitMask = 0x3f00
+
+	// SStxRevocationFeeLimitFlag is the bit of the fee limits of a ticket
+	// commitment which indicates the fee limit for revocations is enabled.
+	SStxRevocationFeeLimitFlag = 0x4000
+)
+
+// SStxCommitment houses the details of a ticket commitment output, which
+// commits to the address the funds used to purchase a ticket are returned to
+// once it votes or is revoked.
+type SStxCommitment struct {
+	// Address is the address the funds are returned to.  It is either a
+	// pay-to-pubkey-hash or a pay-to-script-hash address.
+	Address dcrutil.Address
+
+	// Amount is the amount contributed to the ticket purchase.
+	Amount dcrutil.Amount
+
+	// FeeLimits are the encoded limits of the fees that may be paid by the
+	// vote or revocation from the returned funds.
+	FeeLimits uint16
+}
+
+// feeLimit returns the fee limit encoded by the provided exponent and whether
+// the limit is enabled.
+func feeLimit(limits uint16, mask uint16, flag uint16, shift uint) (dcrutil.Amount, bool) {
+	if limits&flag == 0 {
+		return 0, false
+	}
+	return dcrutil.Amount(1) << ((limits & mask) >> shift), true
+}
+
+// VoteFeeLimit returns the maximum fee a vote may pay from the returned funds
+// and whether the limit is enabled.
+func (c *SStxCommitment) VoteFeeLimit() (dcrutil.Amount, bool) {
+	return feeLimit(c.FeeLimits, SStxVoteFeeLimitMask,
+		SStxVoteFeeLimitFlag, 0)
+}
+
+// RevocationFeeLimit returns the maximum fee a revocation may pay from the
+// returned funds and whether the limit is enabled.
+func (c *SStxCommitment) RevocationFeeLimit() (dcrutil.Amount, bool) {
+	return feeLimit(c.FeeLimits, SStxRevocationFeeLimitMask,
+		SStxRevocationFeeLimitFlag, 8)
+}
+
+// extractSStxCommitmentData returns the data pushed by a ticket commitment
+// script or nil when the script is not of that form.
+func extractSStxCommitmentData(script []byte) []byte {
+	// A ticket commitment script is of the form:
+	//  OP_RETURN OP_DATA_30 <20-byte hash> <8-byte amount> <2-byte limits>
+	if len(script) == sstxCommitmentDataLen+2 &&
+		script[0] == OP_RETURN &&
+		script[1] == OP_DATA_30 {
+
+		return script[2:]
+	}
+	return nil
+}
+
+// ExtractSStxCommitment decodes a ticket commitment script as generated by
+// GenerateSStxAddrPush.  It returns (nil, nil) when the script is not a ticket
+// commitment script.
+func ExtractSStxCommitment(version uint16, script []byte, params dcrutil.AddressParams) (*SStxCommitment, error) {
+	if version != 0 {
+		return nil, nil
+	}
+	data := extractSStxCommitmentData(script)
+	if data == nil {
+		return nil, nil
+	}
+
+	// The most significant bit of the amount is used to flag commitments to
+	// script hashes.
+	hash := data[0:20]
+	amount := binary.LittleEndian.Uint64(data[20:28])
+	isScriptHash := data[27]&sstxCommitmentScriptHashFlag != 0
+	amount &^= uint64(sstxCommitmentScriptHashFlag) << 56
+
+	var addr dcrutil.Address
+	var err error
+	if isScriptHash {
+		addr, err = dcrutil.NewAddressScriptHashFromHash(hash, params)
+	} else {
+		addr, err = dcrutil.NewAddressPubKeyHash(hash, params,
+			dcrec.STEcdsaSecp256k1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SStxCommitment{
+		Address:   addr,
+		Amount:    dcrutil.Amount(amount),
+		FeeLimits: binary.LittleEndian.Uint16(data[28:30]),
+	}, nil
+}
+
+// SSGenBlockRef houses the block a vote votes on as committed to by the block
+// reference output of the vote.
+type SSGenBlockRef struct {
+	Hash   chainhash.Hash
+	Height uint32
+}
+
+// ExtractSSGenBlockRef decodes a vote block reference script as generated by
+// GenerateSSGenBlockRef.  It returns nil when the script is not of that form.
+func ExtractSSGenBlockRef(version uint16, script []byte) *SSGenBlockRef {
+	// A block reference script is of the form:
+	//  OP_RETURN OP_DATA_36 <32-byte block hash> <4-byte block height>
+	if version != 0 || len(script) != 38 || script[0] != OP_RETURN ||
+		script[1] != OP_DATA_36 {
+
+		return nil
+	}
+
+	var ref SSGenBlockRef
+	copy(ref.Hash[:], script[2:34])
+	ref.Height = binary.LittleEndian.Uint32(script[34:38])
+	return &ref
+}
+
+// SSGenVoteBits houses the vote bits committed to by the vote bits output of a
+// vote.
+type SSGenVoteBits struct {
+	// Bits are the vote bits.  The least significant bit indicates whether
+	// the regular transaction tree of the voted block is approved and the
+	// remaining bits house the choices for the agendas being voted on.
+	Bits uint16
+
+	// Extended houses any additional data that follows the vote bits.
+	Extended []byte
+}
+
+// ExtractSSGenVoteBits decodes a vote bits script as generated by
+// GenerateSSGenVotes.  It returns nil when the script is not of that form.
+func ExtractSSGenVoteBits(version uint16, script []byte) *SSGenVoteBits {
+	// A vote bits script is of the form:
+	//  OP_RETURN <2 to 75 bytes of data>
+	// where the data starts with the 2-byte vote bits.
+	if version != 0 || len(script) < 4 || script[0] != OP_RETURN ||
+		script[1] < OP_DATA_2 || script[1] > OP_DATA_75 ||
+		len(script) != int(script[1])+2 {
+
+		return nil
+	}
+
+	return &SSGenVoteBits{
+		Bits:     binary.LittleEndian.Uint16(script[2:4]),
+		Extended: script[4:],
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// TestExtractSStxCommitment ensures ticket commitment scripts are decoded into
+// the address, amount and fee limits they were generated from.
+func TestExtractSStxCommitment(t *testing.T) {
+	t.Parallel()
+
+	testNetParams := chaincfg.TestNet3Params()
+	tests := []struct {
+		name          string
+		script        []byte
+		net           dcrutil.AddressParams
+		addr          string
+		amount        dcrutil.Amount
+		limits        uint16
+		voteLimit     dcrutil.Amount
+		voteOK        bool
+		revokeLimit   dcrutil.Amount
+		revokeOK      bool
+		notCommitment bool
+	}{{
+		name: "script hash with vote limit",
+		script: hexToBytes("6a1ef5916158e3e2c4551c1796708db8367207ed1" +
+			"3bbe8030000000000804a00"),
+		net:       mainNetParams,
+		addr:      "Dcur2mcGjmENx4DhNqDctW5wJCVyT3Qeqkx",
+		amount:    1000,
+		limits:    0x004a,
+		voteLimit: 1 << 10,
+		voteOK:    true,
+	}, {
+		name: "pubkey hash with revocation limit",
+		script: hexToBytes("6a1e7a5c4cca76f2e0b36db4763daacbd6cbb6ee6" +
+			"e7b374b0800000000000041"),
+		net:         testNetParams,
+		addr:        "TscB7V5RuR1oXpA364DFEsNDuAs8Rk6BHJE",
+		amount:      543543,
+		limits:      0x4100,
+		revokeLimit: 1 << 1,
+		revokeOK:    true,
+	}, {
+		name: "no limits",
+		script: hexToBytes("6a1e7a5c4cca76f2e0b36db4763daacbd6cbb6ee6" +
+			"e7b374b0800000000000000"),
+		net:    testNetParams,
+		addr:   "TscB7V5RuR1oXpA364DFEsNDuAs8Rk6BHJE",
+		amount: 543543,
+	}, {
+		name: "wrong push length",
+		script: hexToBytes("6a1d7a5c4cca76f2e0b36db4763daacbd6cbb6ee6" +
+			"e7b374b08000000000000"),
+		notCommitment: true,
+	}, {
+		name:          "vote bits",
+		script:        hexToBytes("6a020100"),
+		notCommitment: true,
+	}}
+
+	for _, test := range tests {
+		c, err := ExtractSStxCommitment(0, test.script, test.net)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.notCommitment {
+			if c != nil {
+				t.Errorf("%q: unexpected commitment %+v", test.name, c)
+			}
+			continue
+		}
+		if c == nil {
+			t.Errorf("%q: script not decoded as a commitment", test.name)
+			continue
+		}
+		if c.Address.String() != test.addr {
+			t.Errorf("%q: unexpected address -- got %v, want %v", test.name,
+				c.Address, test.addr)
+		}
+		if c.Amount != test.amount {
+			t.Errorf("%q: unexpected amount -- got %v, want %v", test.name,
+				c.Amount, test.amount)
+		}
+		if c.FeeLimits != test.limits {
+			t.Errorf("%q: unexpected limits -- got %x, want %x", test.name,
+				c.FeeLimits, test.limits)
+		}
+		voteLimit, voteOK := c.VoteFeeLimit()
+		if voteLimit != test.voteLimit || voteOK != test.voteOK {
+			t.Errorf("%q: unexpected vote fee limit -- got %v (%v), want "+
+				"%v (%v)", test.name, voteLimit, voteOK, test.voteLimit,
+				test.voteOK)
+		}
+		revokeLimit, revokeOK := c.RevocationFeeLimit()
+		if revokeLimit != test.revokeLimit || revokeOK != test.revokeOK {
+			t.Errorf("%q: unexpected revocation fee limit -- got %v (%v), "+
+				"want %v (%v)", test.name, revokeLimit, revokeOK,
+				test.revokeLimit, test.revokeOK)
+		}
+
+		// Ensure the commitment round trips.
+		script, err := GenerateSStxAddrPush(c.Address, c.Amount, c.FeeLimits)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(script) != string(test.script) {
+			t.Errorf("%q: unexpected round trip script -- got %x, want %x",
+				test.name, script, test.script)
+		}
+	}
+}
+
+// TestExtractSSGenBlockRef ensures vote block reference scripts are decoded
+// into the block hash and height they were generated from.
+func TestExtractSSGenBlockRef(t *testing.T) {
+	t.Parallel()
+
+	hash, err := chainhash.NewHashFromStr("0000000000004740ad140c86753f9" +
+		"295e09f9cc81b1bb75d7f5552aeeedb7012")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script, err := GenerateSSGenBlockRef(*hash, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ref := ExtractSSGenBlockRef(0, script)
+	if ref == nil {
+		t.Fatal("script not decoded as a block reference")
+	}
+	if ref.Hash != *hash || ref.Height != 1000 {
+		t.Fatalf("unexpected block reference -- got %v:%d, want %v:%d",
+			ref.Hash, ref.Height, hash, 1000)
+	}
+
+	// Ensure scripts of other forms are not decoded.
+	badScripts := [][]byte{
+		script[:len(script)-1],
+		hexToBytes("6a020100"),
+		append([]byte{OP_NOP}, script[1:]...),
+	}
+	for _, script := range badScripts {
+		if ref := ExtractSSGenBlockRef(0, script); ref != nil {
+			t.Errorf("%x: unexpected block reference %+v", script, ref)
+		}
+	}
+	if ref := ExtractSSGenBlockRef(1, script); ref != nil {
+		t.Errorf("unexpected block reference for script version 1 %+v", ref)
+	}
+}
+
+// TestExtractSSGenVoteBits ensures vote bits scripts are decoded into the vote
+// bits they were generated from.
+func TestExtractSSGenVoteBits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		script   []byte
+		bits     uint16
+		extended []byte
+		invalid  bool
+	}{{
+		name:   "vote bits",
+		script: hexToBytes("6a020500"),
+		bits:   0x0005,
+	}, {
+		name:     "extended vote bits",
+		script:   hexToBytes("6a0601000a0b0c0d"),
+		bits:     0x0001,
+		extended: hexToBytes("0a0b0c0d"),
+	}, {
+		name:    "too short",
+		script:  hexToBytes("6a0101"),
+		invalid: true,
+	}, {
+		name:    "push length mismatch",
+		script:  hexToBytes("6a030100"),
+		invalid: true,
+	}, {
+		name:    "not null data",
+		script:  hexToBytes("51020100"),
+		invalid: true,
+	}}
+
+	for _, test := range tests {
+		vb := ExtractSSGenVoteBits(0, test.script)
+		if test.invalid {
+			if vb != nil {
+				t.Errorf("%q: unexpected vote bits %+v", test.name, vb)
+			}
+			continue
+		}
+		if vb == nil {
+			t.Errorf("%q: script not decoded as vote bits", test.name)
+			continue
+		}
+		if vb.Bits != test.bits || !bytes.Equal(vb.Extended, test.extended) {
+			t.Errorf("%q: unexpected vote bits -- got %04x %x, want %04x %x",
+				test.name, vb.Bits, vb.Extended, test.bits, test.extended)
+		}
+	}
+}
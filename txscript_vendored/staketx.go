@@ -0,0 +1,384 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// maxInputsPerSStx is the maximum number of inputs of a ticket.
+	maxInputsPerSStx = 64
+
+	// maxPayoutsPerSSGen is the maximum number of payouts of a vote.
+	maxPayoutsPerSSGen = 64
+
+	// maxOutputsPerSSRtx is the maximum number of outputs of a revocation.
+	maxOutputsPerSSRtx = 64
+
+	// treasuryBaseDataLen is the length of the data pushed by the second
+	// output of a treasurybase.  It consists of the 4-byte block height and
+	// 8 random bytes.
+	treasuryBaseDataLen = 12
+
+	// tspendDataLen is the length of the data pushed by the first output of
+	// a treasury spend.  It consists of the 8-byte value spent from the
+	// treasury and 24 random bytes.
+	tspendDataLen = 32
+)
+
+// StakeTxType identifies the type of a transaction according to the stake and
+// treasury rules.
+type StakeTxType byte
+
+// Types of transactions identified by AnalyzeStakeTx.
+const (
+	TxTypeRegular       StakeTxType = iota // Not a stake transaction.
+	TxTypeTicket                           // Ticket purchase (SStx).
+	TxTypeVote                             // Vote (SSGen).
+	TxTypeRevocation                       // Revocation (SSRtx).
+	TxTypeTreasuryAdd                      // Treasury add (TADD).
+	TxTypeTreasurySpend                    // Treasury spend (TSPEND).
+	TxTypeTreasuryBase                     // Treasurybase.
+)
+
+// stakeTxTypeToName houses the human-readable strings which describe each
+// stake transaction type.
+var stakeTxTypeToName = []string{
+	TxTypeRegular:       "regular",
+	TxTypeTicket:        "ticket",
+	TxTypeVote:          "vote",
+	TxTypeRevocation:    "revocation",
+	TxTypeTreasuryAdd:   "treasuryadd",
+	TxTypeTreasurySpend: "treasuryspend",
+	TxTypeTreasuryBase:  "treasurybase",
+}
+
+// String implements the Stringer interface by returning the name of the enum
+// stake transaction type.  If the enum is invalid then "Invalid" will be
+// returned.
+func (t StakeTxType) String() string {
+	if int(t) >= len(stakeTxTypeToName) {
+		return "Invalid"
+	}
+	return stakeTxTypeToName[t]
+}
+
+// StakeOutputRole identifies the role an output plays in a stake transaction.
+type StakeOutputRole byte
+
+// Roles of the outputs of stake transactions.
+const (
+	OutputUnknown        StakeOutputRole = iota // Does not fit the layout.
+	OutputTicket                                // Ticket submission.
+	OutputCommitment                            // Ticket commitment.
+	OutputChange                                // Stake change.
+	OutputBlockRef                              // Vote block reference.
+	OutputVoteBits                              // Vote bits.
+	OutputTreasuryVotes                         // Vote on treasury spends.
+	OutputPayout                                // Vote or revocation payout.
+	OutputTreasuryAdd                           // Value added to treasury.
+	OutputTreasuryData                          // Treasury spend or base data.
+	OutputTreasuryPayout                        // Treasury spend payout.
+)
+
+// stakeOutputRoleToName houses the human-readable strings which describe each
+// stake output role.
+var stakeOutputRoleToName = []string{
+	OutputUnknown:        "unknown",
+	OutputTicket:         "ticket",
+	OutputCommitment:     "commitment",
+	OutputChange:         "change",
+	OutputBlockRef:       "blockref",
+	OutputVoteBits:       "votebits",
+	OutputTreasuryVotes:  "treasuryvotes",
+	OutputPayout:         "payout",
+	OutputTreasuryAdd:    "treasuryadd",
+	OutputTreasuryData:   "treasurydata",
+	OutputTreasuryPayout: "treasurypayout",
+}
+
+// String implements the Stringer interface by returning the name of the enum
+// stake output role.  If the enum is invalid then "Invalid" will be returned.
+func (r StakeOutputRole) String() string {
+	if int(r) >= len(stakeOutputRoleToName) {
+		return "Invalid"
+	}
+	return stakeOutputRoleToName[r]
+}
+
+// StakeTxOutput houses the details of an output of a stake transaction.
+type StakeTxOutput struct {
+	Role      StakeOutputRole
+	Class     ScriptClass
+	Amount    dcrutil.Amount
+	Addresses []dcrutil.Address
+
+	// Commitment is only set for ticket commitment outputs.
+	Commitment *SStxCommitment
+}
+
+// StakeTxInfo houses the result of analyzing a transaction with
+// AnalyzeStakeTx.
+type StakeTxInfo struct {
+	Type    StakeTxType
+	Outputs []StakeTxOutput
+
+	// BlockRef and VoteBits are only set for votes.
+	BlockRef *SSGenBlockRef
+	VoteBits *SSGenVoteBits
+
+	// Err describes the first violation of the layout required by the
+	// transaction type.  It is nil when the layout is valid.
+	Err error
+}
+
+// isNullOutPoint returns whether the provided input spends the null outpoint
+// used by coinbases, stakebases, treasurybases and treasury spends.
+func isNullOutPoint(txIn *wire.TxIn) bool {
+	prevOut := &txIn.PreviousOutPoint
+	return prevOut.Index == wire.MaxPrevOutIndex &&
+		prevOut.Tree == wire.TxTreeRegular &&
+		prevOut.Hash == chainhash.Hash{}
+}
+
+// isTSpendSigScript returns whether the provided signature script is of the
+// form used by treasury spends, which is a push-only script followed by
+// OP_TSPEND.
+func isTSpendSigScript(script []byte) bool {
+	lastIdx := len(script) - 1
+	return lastIdx >= 0 && script[lastIdx] == OP_TSPEND &&
+		IsPushOnlyScript(script[:lastIdx])
+}
+
+// stakeTxType returns the type of the transaction according to the tags of its
+// scripts.  The layout is not validated.
+func stakeTxType(tx *wire.MsgTx, isTreasuryEnabled bool) StakeTxType {
+	if len(tx.TxIn) == 0 || len(tx.TxOut) == 0 {
+		return TxTypeRegular
+	}
+	if isTreasuryEnabled && len(tx.TxIn) == 1 &&
+		isTSpendSigScript(tx.TxIn[0].SignatureScript) {
+
+		return TxTypeTreasurySpend
+	}
+
+	out0 := tx.TxOut[0]
+	switch GetScriptClass(out0.Version, out0.PkScript, isTreasuryEnabled) {
+	case StakeSubmissionTy:
+		return TxTypeTicket
+	case StakeRevocationTy:
+		return TxTypeRevocation
+	case TreasuryAddTy:
+		if len(tx.TxIn) == 1 && isNullOutPoint(tx.TxIn[0]) {
+			return TxTypeTreasuryBase
+		}
+		return TxTypeTreasuryAdd
+	}
+
+	if ExtractSSGenBlockRef(out0.Version, out0.PkScript) != nil &&
+		len(tx.TxOut) > 1 && ExtractSSGenVoteBits(tx.TxOut[1].Version,
+		tx.TxOut[1].PkScript) != nil {
+
+		return TxTypeVote
+	}
+	return TxTypeRegular
+}
+
+// stakeLayoutError returns an error for a stake transaction that does not
+// conform to the layout required by its type.
+func stakeLayoutError(txType StakeTxType, format string, args ...interface{}) error {
+	str := fmt.Sprintf("invalid %v: %s", txType, fmt.Sprintf(format, args...))
+	return scriptError(ErrInvalidStakeLayout, str)
+}
+
+// isTreasuryVotesScript returns whether the provided script is the null data
+// script a vote uses to vote on treasury spends.
+func isTreasuryVotesScript(version uint16, script []byte) bool {
+	// The script is of the form:
+	//  OP_RETURN <'T' 'V' followed by the votes>
+	if version != 0 || len(script) == 0 || script[0] != OP_RETURN {
+		return false
+	}
+	data, err := PushedData(script[1:])
+	return err == nil && len(data) == 1 && len(data[0]) >= 2 &&
+		data[0][0] == 'T' && data[0][1] == 'V'
+}
+
+// AnalyzeStakeTx determines the type of the provided transaction from the
+// tagged scripts of its outputs and the signature script of treasury spends,
+// assigns a role to each of its outputs according to the layout required by
+// that type and decodes the commitments, block reference and vote bits it
+// carries.  Violations of the required layout, such as an unexpected number of
+// inputs or outputs or outputs that do not fit their position, are reported in
+// the Err field of the result.  Transactions which are not stake transactions
+// are reported as TxTypeRegular.
+//
+// NOTE: Only the layout of the transaction is validated.  Checks that require
+// access to the chain, such as whether the inputs of votes and revocations
+// spend tickets, are not performed.
+func AnalyzeStakeTx(tx *wire.MsgTx, params dcrutil.AddressParams, isTreasuryEnabled bool) *StakeTxInfo {
+	info := &StakeTxInfo{
+		Type:    stakeTxType(tx, isTreasuryEnabled),
+		Outputs: make([]StakeTxOutput, len(tx.TxOut)),
+	}
+	for i, txOut := range tx.TxOut {
+		class, addrs, _, _ := ExtractPkScriptAddrs(txOut.Version,
+			txOut.PkScript, params, isTreasuryEnabled)
+		info.Outputs[i] = StakeTxOutput{
+			Class:     class,
+			Amount:    dcrutil.Amount(txOut.Value),
+			Addresses: addrs,
+		}
+	}
+
+	// fail records the first layout violation.
+	fail := func(format string, args ...interface{}) {
+		if info.Err == nil {
+			info.Err = stakeLayoutError(info.Type, format, args...)
+		}
+	}
+
+	// assign sets the role of the output at the provided index when it is of
+	// the expected class and records a layout violation otherwise.
+	assign := func(idx int, role StakeOutputRole, class ScriptClass) {
+		out := &info.Outputs[idx]
+		if out.Class != class {
+			fail("output %d is %v instead of %v", idx, out.Class, class)
+			return
+		}
+		out.Role = role
+	}
+
+	numIn, numOut := len(tx.TxIn), len(tx.TxOut)
+	for i, txOut := range tx.TxOut {
+		if info.Type != TxTypeRegular && txOut.Version != 0 {
+			fail("output %d has script version %d", i, txOut.Version)
+		}
+	}
+
+	switch info.Type {
+	case TxTypeTicket:
+		if numIn > maxInputsPerSStx {
+			fail("%d inputs exceeds the maximum of %d", numIn,
+				maxInputsPerSStx)
+		}
+		if numOut != 2*numIn+1 {
+			fail("%d outputs for %d inputs instead of %d", numOut, numIn,
+				2*numIn+1)
+		}
+		assign(0, OutputTicket, StakeSubmissionTy)
+		for i := 1; i < numOut; i++ {
+			if i%2 == 0 {
+				assign(i, OutputChange, StakeSubChangeTy)
+				continue
+			}
+			txOut := tx.TxOut[i]
+			c, err := ExtractSStxCommitment(txOut.Version, txOut.PkScript,
+				params)
+			if err != nil || c == nil {
+				fail("output %d is not a ticket commitment", i)
+				continue
+			}
+			info.Outputs[i].Role = OutputCommitment
+			info.Outputs[i].Commitment = c
+		}
+
+	case TxTypeVote:
+		if numIn != 2 {
+			fail("%d inputs instead of 2", numIn)
+		} else if !isNullOutPoint(tx.TxIn[0]) {
+			fail("input 0 is not a stakebase")
+		}
+		info.Outputs[0].Role = OutputBlockRef
+		info.Outputs[1].Role = OutputVoteBits
+		info.BlockRef = ExtractSSGenBlockRef(tx.TxOut[0].Version,
+			tx.TxOut[0].PkScript)
+		info.VoteBits = ExtractSSGenVoteBits(tx.TxOut[1].Version,
+			tx.TxOut[1].PkScript)
+
+		payoutsEnd := numOut
+		last := tx.TxOut[numOut-1]
+		if isTreasuryEnabled && numOut > 2 &&
+			isTreasuryVotesScript(last.Version, last.PkScript) {
+
+			info.Outputs[numOut-1].Role = OutputTreasuryVotes
+			payoutsEnd--
+		}
+		numPayouts := payoutsEnd - 2
+		if numPayouts < 1 || numPayouts > maxPayoutsPerSSGen {
+			fail("%d payouts is not between 1 and %d", numPayouts,
+				maxPayoutsPerSSGen)
+		}
+		for i := 2; i < payoutsEnd; i++ {
+			assign(i, OutputPayout, StakeGenTy)
+		}
+
+	case TxTypeRevocation:
+		if numIn != 1 {
+			fail("%d inputs instead of 1", numIn)
+		}
+		if numOut > maxOutputsPerSSRtx {
+			fail("%d outputs exceeds the maximum of %d", numOut,
+				maxOutputsPerSSRtx)
+		}
+		for i := 0; i < numOut; i++ {
+			assign(i, OutputPayout, StakeRevocationTy)
+		}
+
+	case TxTypeTreasuryAdd:
+		if numOut > 2 {
+			fail("%d outputs instead of 1 or 2", numOut)
+		}
+		assign(0, OutputTreasuryAdd, TreasuryAddTy)
+		for i := 1; i < numOut; i++ {
+			assign(i, OutputChange, StakeSubChangeTy)
+		}
+
+	case TxTypeTreasuryBase:
+		if numOut != 2 {
+			fail("%d outputs instead of 2", numOut)
+		}
+		assign(0, OutputTreasuryAdd, TreasuryAddTy)
+		if len(tx.TxOut[0].PkScript) != 1 {
+			fail("output 0 is not a bare %s", opcodeArray[OP_TADD].name)
+		}
+		if numOut > 1 {
+			script := tx.TxOut[1].PkScript
+			if len(script) != treasuryBaseDataLen+2 ||
+				script[0] != OP_RETURN || script[1] != OP_DATA_12 {
+
+				fail("output 1 is not the treasurybase height")
+			} else {
+				info.Outputs[1].Role = OutputTreasuryData
+			}
+		}
+
+	case TxTypeTreasurySpend:
+		if !isNullOutPoint(tx.TxIn[0]) {
+			fail("input 0 does not spend the treasury")
+		}
+		if numOut < 2 {
+			fail("%d outputs instead of at least 2", numOut)
+		}
+		script := tx.TxOut[0].PkScript
+		if len(script) != tspendDataLen+2 || script[0] != OP_RETURN ||
+			script[1] != OP_DATA_32 {
+
+			fail("output 0 is not the treasury spend value")
+		} else {
+			info.Outputs[0].Role = OutputTreasuryData
+		}
+		for i := 1; i < numOut; i++ {
+			assign(i, OutputTreasuryPayout, TreasurySpendTy)
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestAnalyzeStakeTx ensures stake transactions are identified, their outputs
+// are assigned the expected roles and layout violations are reported.
+func TestAnalyzeStakeTx(t *testing.T) {
+	t.Parallel()
+
+	// Convenience values used to construct the test transactions.
+	addr, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x01}, 20),
+		mainNetParams, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mustScript := func(script []byte, err error) []byte {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return script
+	}
+	p2pkh := mustScript(PayToAddrScript(addr))
+	ticket := mustScript(PayToSStx(addr))
+	commitment := mustScript(GenerateSStxAddrPush(addr, 1000, 0x5800))
+	change := mustScript(PayToSStxChange(addr))
+	blockRef := mustScript(GenerateSSGenBlockRef(chainhash.Hash{0x01}, 100))
+	voteBits := mustScript(GenerateSSGenVotes(0x0001))
+	treasuryVotes := mustScript(NewScriptBuilder().AddOp(OP_RETURN).
+		AddData([]byte{'T', 'V', 0x01}).Script())
+	payout := mustScript(PayToSSGen(addr))
+	revocation := mustScript(PayToSSRtx(addr))
+	tadd := []byte{OP_TADD}
+	tbaseData := mustScript(NewScriptBuilder().AddOp(OP_RETURN).
+		AddData(make([]byte, 12)).Script())
+	tspendData := mustScript(NewScriptBuilder().AddOp(OP_RETURN).
+		AddData(make([]byte, 32)).Script())
+	tgen := append([]byte{OP_TGEN}, p2pkh...)
+	tspendSig := mustScript(NewScriptBuilder().AddData(make([]byte, 64)).
+		AddData(make([]byte, 33)).AddOp(OP_TSPEND).Script())
+
+	nullIn := &wire.TxIn{PreviousOutPoint: wire.OutPoint{
+		Index: wire.MaxPrevOutIndex,
+	}}
+	regularIn := &wire.TxIn{PreviousOutPoint: wire.OutPoint{
+		Hash: chainhash.Hash{0x02},
+	}}
+	tspendIn := &wire.TxIn{
+		PreviousOutPoint: nullIn.PreviousOutPoint,
+		SignatureScript:  tspendSig,
+	}
+
+	tests := []struct {
+		name    string
+		ins     []*wire.TxIn
+		outs    [][]byte
+		txType  StakeTxType
+		roles   []StakeOutputRole
+		invalid bool
+	}{{
+		name:   "regular",
+		ins:    []*wire.TxIn{regularIn},
+		outs:   [][]byte{p2pkh},
+		txType: TxTypeRegular,
+		roles:  []StakeOutputRole{OutputUnknown},
+	}, {
+		name:   "ticket",
+		ins:    []*wire.TxIn{regularIn, regularIn},
+		outs:   [][]byte{ticket, commitment, change, commitment, change},
+		txType: TxTypeTicket,
+		roles: []StakeOutputRole{OutputTicket, OutputCommitment,
+			OutputChange, OutputCommitment, OutputChange},
+	}, {
+		name:   "ticket with missing commitment",
+		ins:    []*wire.TxIn{regularIn, regularIn},
+		outs:   [][]byte{ticket, commitment, change},
+		txType: TxTypeTicket,
+		roles: []StakeOutputRole{OutputTicket, OutputCommitment,
+			OutputChange},
+		invalid: true,
+	}, {
+		name:   "ticket with swapped commitment and change",
+		ins:    []*wire.TxIn{regularIn},
+		outs:   [][]byte{ticket, change, commitment},
+		txType: TxTypeTicket,
+		roles: []StakeOutputRole{OutputTicket, OutputUnknown,
+			OutputUnknown},
+		invalid: true,
+	}, {
+		name:   "vote",
+		ins:    []*wire.TxIn{nullIn, regularIn},
+		outs:   [][]byte{blockRef, voteBits, payout, payout},
+		txType: TxTypeVote,
+		roles: []StakeOutputRole{OutputBlockRef, OutputVoteBits,
+			OutputPayout, OutputPayout},
+	}, {
+		name:   "vote with treasury votes",
+		ins:    []*wire.TxIn{nullIn, regularIn},
+		outs:   [][]byte{blockRef, voteBits, payout, treasuryVotes},
+		txType: TxTypeVote,
+		roles: []StakeOutputRole{OutputBlockRef, OutputVoteBits,
+			OutputPayout, OutputTreasuryVotes},
+	}, {
+		name:    "vote without stakebase",
+		ins:     []*wire.TxIn{regularIn, regularIn},
+		outs:    [][]byte{blockRef, voteBits, payout},
+		txType:  TxTypeVote,
+		roles:   []StakeOutputRole{OutputBlockRef, OutputVoteBits, OutputPayout},
+		invalid: true,
+	}, {
+		name:    "vote without payouts",
+		ins:     []*wire.TxIn{nullIn, regularIn},
+		outs:    [][]byte{blockRef, voteBits},
+		txType:  TxTypeVote,
+		roles:   []StakeOutputRole{OutputBlockRef, OutputVoteBits},
+		invalid: true,
+	}, {
+		name:   "revocation",
+		ins:    []*wire.TxIn{regularIn},
+		outs:   [][]byte{revocation, revocation},
+		txType: TxTypeRevocation,
+		roles:  []StakeOutputRole{OutputPayout, OutputPayout},
+	}, {
+		name:    "revocation with regular output",
+		ins:     []*wire.TxIn{regularIn},
+		outs:    [][]byte{revocation, p2pkh},
+		txType:  TxTypeRevocation,
+		roles:   []StakeOutputRole{OutputPayout, OutputUnknown},
+		invalid: true,
+	}, {
+		name:   "treasury add with change",
+		ins:    []*wire.TxIn{regularIn},
+		outs:   [][]byte{tadd, change},
+		txType: TxTypeTreasuryAdd,
+		roles:  []StakeOutputRole{OutputTreasuryAdd, OutputChange},
+	}, {
+		name:   "treasurybase",
+		ins:    []*wire.TxIn{nullIn},
+		outs:   [][]byte{tadd, tbaseData},
+		txType: TxTypeTreasuryBase,
+		roles:  []StakeOutputRole{OutputTreasuryAdd, OutputTreasuryData},
+	}, {
+		name:   "treasury spend",
+		ins:    []*wire.TxIn{tspendIn},
+		outs:   [][]byte{tspendData, tgen, tgen},
+		txType: TxTypeTreasurySpend,
+		roles: []StakeOutputRole{OutputTreasuryData, OutputTreasuryPayout,
+			OutputTreasuryPayout},
+	}, {
+		name:    "treasury spend without payouts",
+		ins:     []*wire.TxIn{tspendIn},
+		outs:    [][]byte{tspendData},
+		txType:  TxTypeTreasurySpend,
+		roles:   []StakeOutputRole{OutputTreasuryData},
+		invalid: true,
+	}}
+
+	for _, test := range tests {
+		tx := wire.NewMsgTx()
+		for _, txIn := range test.ins {
+			tx.AddTxIn(txIn)
+		}
+		for _, script := range test.outs {
+			tx.AddTxOut(wire.NewTxOut(1000, script))
+		}
+
+		info := AnalyzeStakeTx(tx, mainNetParams, true)
+		if info.Type != test.txType {
+			t.Errorf("%q: unexpected type -- got %v, want %v", test.name,
+				info.Type, test.txType)
+			continue
+		}
+		var roles []StakeOutputRole
+		for _, out := range info.Outputs {
+			roles = append(roles, out.Role)
+		}
+		if !reflect.DeepEqual(roles, test.roles) {
+			t.Errorf("%q: unexpected roles -- got %v, want %v", test.name,
+				roles, test.roles)
+		}
+		if test.invalid != (info.Err != nil) {
+			t.Errorf("%q: unexpected layout error -- got %v, want error: %v",
+				test.name, info.Err, test.invalid)
+			continue
+		}
+		if info.Err != nil && !errors.Is(info.Err, ErrInvalidStakeLayout) {
+			t.Errorf("%q: unexpected error kind -- got %v, want %v",
+				test.name, info.Err, ErrInvalidStakeLayout)
+		}
+	}
+}
+
+// TestAnalyzeStakeTxDetails ensures the details carried by stake transactions
+// are decoded.
+func TestAnalyzeStakeTxDetails(t *testing.T) {
+	t.Parallel()
+
+	addr, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x01}, 20),
+		mainNetParams, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blockRef, _ := GenerateSSGenBlockRef(chainhash.Hash{0x01}, 100)
+	voteBits, _ := GenerateSSGenVotes(0x0005)
+	payout, _ := PayToSSGen(addr)
+
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{
+		Index: wire.MaxPrevOutIndex,
+	}})
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(0, blockRef))
+	tx.AddTxOut(wire.NewTxOut(0, voteBits))
+	tx.AddTxOut(wire.NewTxOut(1000, payout))
+
+	info := AnalyzeStakeTx(tx, mainNetParams, false)
+	if info.Err != nil {
+		t.Fatalf("unexpected layout error: %v", info.Err)
+	}
+	if info.BlockRef == nil || info.BlockRef.Height != 100 {
+		t.Fatalf("unexpected block reference %+v", info.BlockRef)
+	}
+	if info.VoteBits == nil || info.VoteBits.Bits != 0x0005 {
+		t.Fatalf("unexpected vote bits %+v", info.VoteBits)
+	}
+	out := info.Outputs[2]
+	if out.Amount != 1000 || len(out.Addresses) != 1 ||
+		out.Addresses[0].String() != addr.String() {
+
+		t.Fatalf("unexpected payout %+v", out)
+	}
+}
@@ -28,7 +28,7 @@ var mainNetParams = chaincfg.MainNetParams()
 // tests as a helper since the only way it can fail is if there is an error in
 // the test source code.
 func mustParseShortForm(script string) []byte {
-	s, err := parseShortForm(script)
+	s, err := ParseShortFormScript(script)
 	if err != nil {
 		panic("invalid short form script in test source: err " +
 			err.Error() + ", script: " + script)
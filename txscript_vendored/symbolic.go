@@ -0,0 +1,1041 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// MaxSymbolicPaths is the maximum number of execution paths that will be
+	// explored by SymbolicExecute before giving up.  Every conditional that
+	// depends on data provided by the signature script doubles the number of
+	// potential paths, so this bounds the work performed on adversarial
+	// scripts.
+	MaxSymbolicPaths = 1024
+)
+
+// SymbolicValueKind identifies the kind of a value tracked by the symbolic
+// interpreter.
+type SymbolicValueKind uint8
+
+const (
+	// SymConst is a concrete value, such as data pushed by the script itself
+	// or the result of an operation performed solely on concrete values.
+	SymConst SymbolicValueKind = iota
+
+	// SymInput is an item provided by the signature script.
+	SymInput
+
+	// SymTxField is a field of the spending transaction, such as its lock
+	// time or the sequence number of the input being spent.
+	SymTxField
+
+	// SymOp is the result of an operation that involves at least one
+	// non-concrete value.
+	SymOp
+)
+
+// These constants define the names of the transaction fields that may appear
+// in symbolic constraints.
+const (
+	SymFieldLockTime = "locktime"
+	SymFieldSequence = "sequence"
+	SymFieldVersion  = "version"
+)
+
+// SymbolicValue is a node of an expression tree that describes how a stack
+// item was computed from the signature script inputs, the data embedded in the
+// script, and the fields of the spending transaction.
+//
+// Values are immutable once created and may therefore be shared between
+// multiple execution paths and expressions.
+type SymbolicValue struct {
+	// Kind identifies which of the remaining fields are meaningful.
+	Kind SymbolicValueKind
+
+	// Data houses the raw bytes of a SymConst value.
+	Data []byte
+
+	// Index is the position of a SymInput value within the signature script,
+	// where index 0 is the first (deepest) item pushed by it.
+	Index int
+
+	// Name is the field name of a SymTxField value or the operator name of a
+	// SymOp value (for example "sha256", "checksig" or "==").
+	Name string
+
+	// Args are the operands of a SymOp value in stack order.
+	Args []*SymbolicValue
+}
+
+// symbolicInfixOps defines the operators which are rendered between their
+// operands as opposed to in function call notation.
+var symbolicInfixOps = map[string]bool{
+	"==": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+	"+": true, "-": true, "*": true, "/": true, "%": true, "<<": true,
+	">>": true, "&&": true, "||": true, "&": true, "|": true, "^": true,
+}
+
+// symbolicNegatedOps defines the comparison operators that are rendered as
+// their inverse when a constraint requires the comparison to be false.
+var symbolicNegatedOps = map[string]string{
+	"==": "!=", "!=": "==", "<": ">=", ">=": "<", ">": "<=", "<=": ">",
+}
+
+// symConst returns a new concrete symbolic value for the passed data.
+func symConst(data []byte) *SymbolicValue {
+	return &SymbolicValue{Kind: SymConst, Data: data}
+}
+
+// symOp returns a new symbolic value for the named operation over the passed
+// operands.
+func symOp(name string, args ...*SymbolicValue) *SymbolicValue {
+	return &SymbolicValue{Kind: SymOp, Name: name, Args: args}
+}
+
+// symTxField returns a new symbolic value that refers to the named field of
+// the spending transaction.
+func symTxField(name string) *SymbolicValue {
+	return &SymbolicValue{Kind: SymTxField, Name: name}
+}
+
+// IsConst returns whether or not the value is concrete.
+func (v *SymbolicValue) IsConst() bool {
+	return v.Kind == SymConst
+}
+
+// formatSymbolicConst returns a human-readable representation of concrete
+// data.  Data that is a minimally encoded number small enough to be used as a
+// lock time is shown in decimal while everything else is shown in hex.
+func formatSymbolicConst(data []byte) string {
+	if len(data) <= CltvMaxScriptNumLen {
+		if n, err := MakeScriptNum(data, CltvMaxScriptNumLen); err == nil {
+			return fmt.Sprintf("%d", int64(n))
+		}
+	}
+	return "0x" + hex.EncodeToString(data)
+}
+
+// inputName returns the default name of the signature script input at the
+// passed index.
+func inputName(index int) string {
+	return fmt.Sprintf("x%d", index)
+}
+
+// formatOperand returns the string for the passed value wrapped in
+// parenthesis when it is an infix operation so that precedence is unambiguous.
+func formatOperand(v *SymbolicValue, name func(int) string) string {
+	if v.Kind == SymOp && symbolicInfixOps[v.Name] {
+		return "(" + v.format(name) + ")"
+	}
+	return v.format(name)
+}
+
+// format returns a human-readable representation of the expression with the
+// signature script inputs named by the passed function.
+func (v *SymbolicValue) format(name func(int) string) string {
+	switch v.Kind {
+	case SymConst:
+		return formatSymbolicConst(v.Data)
+	case SymInput:
+		return name(v.Index)
+	case SymTxField:
+		return v.Name
+	}
+
+	switch {
+	case symbolicInfixOps[v.Name] && len(v.Args) == 2:
+		return formatOperand(v.Args[0], name) + " " + v.Name + " " +
+			formatOperand(v.Args[1], name)
+
+	case (v.Name == "!" || v.Name == "~" || v.Name == "neg") &&
+		len(v.Args) == 1:
+		prefix := v.Name
+		if prefix == "neg" {
+			prefix = "-"
+		}
+		return prefix + formatOperand(v.Args[0], name)
+	}
+
+	args := make([]string, 0, len(v.Args))
+	for _, arg := range v.Args {
+		args = append(args, arg.format(name))
+	}
+	if v.Name == "list" {
+		return "[" + strings.Join(args, ", ") + "]"
+	}
+	return v.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// String returns a human-readable representation of the expression.
+func (v *SymbolicValue) String() string {
+	return v.format(inputName)
+}
+
+// SymbolicConstraint is a condition that must hold for an execution path to be
+// taken and to succeed.
+type SymbolicConstraint struct {
+	// Expr is the expression the constraint applies to.
+	Expr *SymbolicValue
+
+	// Holds specifies whether the expression must evaluate to true or false.
+	Holds bool
+
+	// Branch specifies whether the constraint is the condition of a
+	// conditional opcode as opposed to a requirement imposed by a verify or
+	// by the final stack item.
+	Branch bool
+}
+
+// format returns a human-readable representation of the constraint with the
+// signature script inputs named by the passed function.
+func (c SymbolicConstraint) format(name func(int) string) string {
+	if c.Holds {
+		return c.Expr.format(name)
+	}
+
+	if c.Expr.Kind == SymOp && len(c.Expr.Args) == 2 {
+		if inverse, ok := symbolicNegatedOps[c.Expr.Name]; ok {
+			return formatOperand(c.Expr.Args[0], name) + " " + inverse +
+				" " + formatOperand(c.Expr.Args[1], name)
+		}
+	}
+	if c.Expr.Kind == SymOp && c.Expr.Name == "!" && len(c.Expr.Args) == 1 {
+		return c.Expr.Args[0].format(name)
+	}
+	return "!" + formatOperand(c.Expr, name)
+}
+
+// String returns a human-readable representation of the constraint.
+func (c SymbolicConstraint) String() string {
+	return c.format(inputName)
+}
+
+// SymbolicPath describes a single execution path through a script along with
+// the constraints the signature script and spending transaction must satisfy
+// for the path to be taken and for the script to succeed.
+type SymbolicPath struct {
+	// Branches houses the outcome of each conditional whose condition
+	// depended on non-concrete data, in the order they were encountered.
+	Branches []bool
+
+	// NumInputs is the minimum number of items the signature script must
+	// provide for the path.
+	NumInputs int
+
+	// Constraints are the conditions that must all hold for the path to be
+	// taken and succeed.
+	Constraints []SymbolicConstraint
+
+	// Err is set when the path can never succeed, such as when it executes
+	// OP_RETURN or verifies a concrete false value.  The constraints leading
+	// up to the failure are still populated.
+	Err error
+}
+
+// symbolicState houses the full state of the symbolic interpreter for a
+// single execution path so it can be duplicated when execution forks.
+type symbolicState struct {
+	tokenizer        ScriptTokenizer
+	dstack           []*SymbolicValue
+	astack           []*SymbolicValue
+	numInputs        int
+	numOps           int
+	condNestDepth    int32
+	condDisableDepth int32
+	branches         []bool
+	constraints      []SymbolicConstraint
+}
+
+// clone returns a deep copy of the state.  The values themselves are immutable
+// and are therefore shared.
+func (s *symbolicState) clone() *symbolicState {
+	c := *s
+	c.dstack = append([]*SymbolicValue(nil), s.dstack...)
+	c.astack = append([]*SymbolicValue(nil), s.astack...)
+	c.branches = append([]bool(nil), s.branches...)
+	c.constraints = append([]SymbolicConstraint(nil), s.constraints...)
+	return &c
+}
+
+// isBranchExecuting returns whether or not the current conditional branch is
+// actively executing.
+func (s *symbolicState) isBranchExecuting() bool {
+	return s.condDisableDepth == noCondDisableDepth
+}
+
+// ensureDepth materializes signature script inputs at the bottom of the data
+// stack until it contains at least the requested number of items.  Since the
+// script being analyzed executes after the signature script, any item it
+// accesses beyond what it pushed itself must have been provided by the
+// signature script.
+func (s *symbolicState) ensureDepth(n int) {
+	if len(s.dstack) >= n {
+		return
+	}
+	missing := n - len(s.dstack)
+	inputs := make([]*SymbolicValue, missing, missing+len(s.dstack))
+	for i := 0; i < missing; i++ {
+		// Deeper items are discovered later, so they are assigned higher
+		// discovery indices.  They are renumbered according to their
+		// position in the signature script once the path is complete.
+		inputs[missing-1-i] = &SymbolicValue{Kind: SymInput,
+			Index: s.numInputs + i}
+	}
+	s.numInputs += missing
+	s.dstack = append(inputs, s.dstack...)
+}
+
+// push adds the value to the top of the data stack.
+func (s *symbolicState) push(v *SymbolicValue) {
+	s.dstack = append(s.dstack, v)
+}
+
+// pop removes and returns the top item of the data stack.
+func (s *symbolicState) pop() *SymbolicValue {
+	s.ensureDepth(1)
+	v := s.dstack[len(s.dstack)-1]
+	s.dstack = s.dstack[:len(s.dstack)-1]
+	return v
+}
+
+// popN removes the top n items of the data stack and returns them in stack
+// order, meaning the former top of the stack is the final item.
+func (s *symbolicState) popN(n int) []*SymbolicValue {
+	s.ensureDepth(n)
+	items := append([]*SymbolicValue(nil), s.dstack[len(s.dstack)-n:]...)
+	s.dstack = s.dstack[:len(s.dstack)-n]
+	return items
+}
+
+// popConstInt removes the top item of the data stack and interprets it as a
+// script number.  An error is returned when the item is not concrete since the
+// symbolic interpreter requires concrete values for counts and indices.
+func (s *symbolicState) popConstInt(op *opcode, maxScriptNumLen int) (ScriptNum, error) {
+	v := s.pop()
+	if !v.IsConst() {
+		str := fmt.Sprintf("%s requires a concrete numeric argument, got %v",
+			op.name, v)
+		return 0, scriptError(ErrUnsupportedSymbolicOp, str)
+	}
+	return MakeScriptNum(v.Data, maxScriptNumLen)
+}
+
+// constrain adds the passed constraint to the state.
+func (s *symbolicState) constrain(v *SymbolicValue, holds bool) {
+	s.constraints = append(s.constraints, SymbolicConstraint{
+		Expr:  v,
+		Holds: holds,
+	})
+}
+
+// constrainBranch adds the passed conditional outcome to the state.
+func (s *symbolicState) constrainBranch(v *SymbolicValue, holds bool) {
+	s.constraints = append(s.constraints, SymbolicConstraint{
+		Expr:   v,
+		Holds:  holds,
+		Branch: true,
+	})
+	s.branches = append(s.branches, holds)
+}
+
+// knownOutcome returns whether the passed expression was already constrained
+// on the current path along with the outcome it was constrained to.  This
+// avoids exploring paths that contradict earlier decisions such as when a
+// script repeatedly branches on the same input.
+func (s *symbolicState) knownOutcome(v *SymbolicValue) (bool, bool) {
+	str := v.String()
+	for _, c := range s.constraints {
+		if c.Expr.String() == str {
+			return c.Holds, true
+		}
+	}
+	return false, false
+}
+
+// symbolicPureOps maps opcodes which consume a fixed number of stack items and
+// push a single result that solely depends on them to the operator used to
+// describe the result when any of the operands are not concrete.
+var symbolicPureOps = map[byte]struct {
+	name  string
+	arity int
+}{
+	OP_1ADD:               {"+", 1},
+	OP_1SUB:               {"-", 1},
+	OP_NEGATE:             {"neg", 1},
+	OP_ABS:                {"abs", 1},
+	OP_NOT:                {"!", 1},
+	OP_0NOTEQUAL:          {"!=", 1},
+	OP_INVERT:             {"~", 1},
+	OP_RIPEMD160:          {"ripemd160", 1},
+	OP_SHA1:               {"sha1", 1},
+	OP_SHA256:             {"sha256", 1},
+	OP_BLAKE256:           {"blake256", 1},
+	OP_HASH160:            {"hash160", 1},
+	OP_HASH256:            {"hash256", 1},
+	OP_ADD:                {"+", 2},
+	OP_SUB:                {"-", 2},
+	OP_MUL:                {"*", 2},
+	OP_DIV:                {"/", 2},
+	OP_MOD:                {"%", 2},
+	OP_LSHIFT:             {"<<", 2},
+	OP_RSHIFT:             {">>", 2},
+	OP_BOOLAND:            {"&&", 2},
+	OP_BOOLOR:             {"||", 2},
+	OP_NUMEQUAL:           {"==", 2},
+	OP_NUMNOTEQUAL:        {"!=", 2},
+	OP_LESSTHAN:           {"<", 2},
+	OP_GREATERTHAN:        {">", 2},
+	OP_LESSTHANOREQUAL:    {"<=", 2},
+	OP_GREATERTHANOREQUAL: {">=", 2},
+	OP_MIN:                {"min", 2},
+	OP_MAX:                {"max", 2},
+	OP_EQUAL:              {"==", 2},
+	OP_CAT:                {"cat", 2},
+	OP_AND:                {"&", 2},
+	OP_OR:                 {"|", 2},
+	OP_XOR:                {"^", 2},
+	OP_ROTR:               {"rotr", 2},
+	OP_ROTL:               {"rotl", 2},
+	OP_LEFT:               {"left", 2},
+	OP_RIGHT:              {"right", 2},
+	OP_WITHIN:             {"within", 3},
+	OP_SUBSTR:             {"substr", 3},
+}
+
+// symbolicVerifyOps maps the opcodes which are a combination of another opcode
+// followed by a verify to the underlying opcode and the error kind produced
+// when the verification fails.
+var symbolicVerifyOps = map[byte]struct {
+	base byte
+	kind ErrorKind
+}{
+	OP_EQUALVERIFY:         {OP_EQUAL, ErrEqualVerify},
+	OP_NUMEQUALVERIFY:      {OP_NUMEQUAL, ErrNumEqualVerify},
+	OP_CHECKSIGVERIFY:      {OP_CHECKSIG, ErrCheckSigVerify},
+	OP_CHECKMULTISIGVERIFY: {OP_CHECKMULTISIG, ErrCheckMultiSigVerify},
+	OP_CHECKSIGALTVERIFY:   {OP_CHECKSIGALT, ErrCheckSigAltVerify},
+}
+
+// symbolicExecutor houses the parameters shared by all paths explored while
+// symbolically executing a script.
+type symbolicExecutor struct {
+	flags    ScriptFlags
+	numPaths int
+	pending  []*symbolicState
+}
+
+// hasFlag returns whether the executor has the passed flag set.
+func (x *symbolicExecutor) hasFlag(flag ScriptFlags) bool {
+	return x.flags&flag == flag
+}
+
+// evalConcrete executes the real implementation of the opcode against a
+// scratch engine whose data stack contains the passed concrete values and
+// returns the resulting data stack.
+func (x *symbolicExecutor) evalConcrete(op *opcode, data []byte, args []*SymbolicValue) ([]*SymbolicValue, error) {
+	vm := Engine{flags: x.flags, condDisableDepth: noCondDisableDepth}
+	for _, arg := range args {
+		vm.dstack.PushByteArray(arg.Data)
+	}
+	if err := op.opfunc(op, data, &vm); err != nil {
+		return nil, err
+	}
+
+	results := vm.GetStack()
+	values := make([]*SymbolicValue, 0, len(results))
+	for _, result := range results {
+		values = append(values, symConst(result))
+	}
+	return values, nil
+}
+
+// verify requires the passed value to be true.  Concrete values are checked
+// immediately while constraints are recorded for all others.
+func (x *symbolicExecutor) verify(s *symbolicState, op *opcode, v *SymbolicValue, kind ErrorKind) error {
+	if v.IsConst() {
+		if !asBool(v.Data) {
+			return scriptError(kind, fmt.Sprintf("%s failed", op.name))
+		}
+		return nil
+	}
+	if holds, ok := s.knownOutcome(v); ok {
+		if !holds {
+			str := fmt.Sprintf("%s failed due to an earlier condition",
+				op.name)
+			return scriptError(kind, str)
+		}
+		return nil
+	}
+	s.constrain(v, true)
+	return nil
+}
+
+// branch evaluates the passed value as a boolean.  It returns the outcome
+// directly when it is known and otherwise forks the execution by queuing a
+// copy of the state that takes the false outcome while the current state
+// proceeds with the true outcome.
+func (x *symbolicExecutor) branch(s *symbolicState, v *SymbolicValue, onFalse func(*symbolicState)) (bool, error) {
+	if v.IsConst() {
+		return asBool(v.Data), nil
+	}
+	if holds, ok := s.knownOutcome(v); ok {
+		return holds, nil
+	}
+
+	x.numPaths++
+	if x.numPaths > MaxSymbolicPaths {
+		str := fmt.Sprintf("script has more than %d execution paths",
+			MaxSymbolicPaths)
+		return false, scriptError(ErrTooManyPaths, str)
+	}
+
+	alt := s.clone()
+	alt.constrainBranch(v, false)
+	onFalse(alt)
+	x.pending = append(x.pending, alt)
+
+	s.constrainBranch(v, true)
+	return true, nil
+}
+
+// executeStackOp performs the structural stack manipulation opcodes.  It
+// returns false when the opcode is not one of them.
+func (x *symbolicExecutor) executeStackOp(s *symbolicState, op *opcode) (bool, error) {
+	top := func(n int) []*SymbolicValue {
+		s.ensureDepth(n)
+		return s.dstack[len(s.dstack)-n:]
+	}
+
+	switch op.value {
+	case OP_TOALTSTACK:
+		s.astack = append(s.astack, s.pop())
+
+	case OP_FROMALTSTACK:
+		if len(s.astack) == 0 {
+			return true, scriptError(ErrInvalidStackOperation,
+				"index 0 is invalid for stack size 0")
+		}
+		s.push(s.astack[len(s.astack)-1])
+		s.astack = s.astack[:len(s.astack)-1]
+
+	case OP_2DROP:
+		s.popN(2)
+
+	case OP_2DUP:
+		items := top(2)
+		s.dstack = append(s.dstack, items[0], items[1])
+
+	case OP_3DUP:
+		items := top(3)
+		s.dstack = append(s.dstack, items[0], items[1], items[2])
+
+	case OP_2OVER:
+		items := top(4)
+		s.dstack = append(s.dstack, items[0], items[1])
+
+	case OP_2ROT:
+		items := s.popN(6)
+		s.dstack = append(s.dstack, items[2], items[3], items[4], items[5],
+			items[0], items[1])
+
+	case OP_2SWAP:
+		items := s.popN(4)
+		s.dstack = append(s.dstack, items[2], items[3], items[0], items[1])
+
+	case OP_DROP:
+		s.pop()
+
+	case OP_DUP:
+		s.push(top(1)[0])
+
+	case OP_NIP:
+		items := s.popN(2)
+		s.push(items[1])
+
+	case OP_OVER:
+		s.push(top(2)[0])
+
+	case OP_ROT:
+		items := s.popN(3)
+		s.dstack = append(s.dstack, items[1], items[2], items[0])
+
+	case OP_SWAP:
+		items := s.popN(2)
+		s.dstack = append(s.dstack, items[1], items[0])
+
+	case OP_TUCK:
+		items := s.popN(2)
+		s.dstack = append(s.dstack, items[1], items[0], items[1])
+
+	case OP_PICK, OP_ROLL:
+		n, err := s.popConstInt(op, MathOpCodeMaxScriptNumLen)
+		if err != nil {
+			return true, err
+		}
+		if n < 0 || n >= MaxStackSize {
+			str := fmt.Sprintf("index %d is invalid for %s", n, op.name)
+			return true, scriptError(ErrInvalidStackOperation, str)
+		}
+		s.ensureDepth(int(n) + 1)
+		idx := len(s.dstack) - 1 - int(n)
+		v := s.dstack[idx]
+		if op.value == OP_ROLL {
+			s.dstack = append(s.dstack[:idx], s.dstack[idx+1:]...)
+		}
+		s.push(v)
+
+	case OP_DEPTH:
+		// The final depth depends on how many items the signature script
+		// provides, which is unknown.
+		s.push(symOp("depth"))
+
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// executeOpcode symbolically executes the passed opcode against the state.  It
+// mirrors Engine.executeOpcode with regards to disabled opcodes, operation
+// limits and conditional execution.
+func (x *symbolicExecutor) executeOpcode(s *symbolicState, op *opcode, data []byte) error {
+	if isOpcodeDisabled(op.value) {
+		str := fmt.Sprintf("attempt to execute disabled opcode %s", op.name)
+		return scriptError(ErrDisabledOpcode, str)
+	}
+	if isOpcodeAlwaysIllegal(op.value) {
+		str := fmt.Sprintf("attempt to execute reserved opcode %s", op.name)
+		return scriptError(ErrReservedOpcode, str)
+	}
+	if op.value > OP_16 {
+		s.numOps++
+		if s.numOps > MaxOpsPerScript {
+			str := fmt.Sprintf("exceeded max operation limit of %d",
+				MaxOpsPerScript)
+			return scriptError(ErrTooManyOperations, str)
+		}
+	} else if len(data) > MaxScriptElementSize {
+		str := fmt.Sprintf("element size %d exceeds max allowed size %d",
+			len(data), MaxScriptElementSize)
+		return scriptError(ErrElementTooBig, str)
+	}
+
+	// Conditionals are handled here since they may fork execution.
+	switch op.value {
+	case OP_IF, OP_NOTIF:
+		if s.isBranchExecuting() {
+			depth := s.condNestDepth
+			notIf := op.value == OP_NOTIF
+			ok, err := x.branch(s, s.pop(), func(alt *symbolicState) {
+				if !notIf {
+					alt.condDisableDepth = depth
+				}
+				alt.condNestDepth++
+			})
+			if err != nil {
+				return err
+			}
+			if ok == notIf {
+				s.condDisableDepth = depth
+			}
+		}
+		s.condNestDepth++
+		return nil
+
+	case OP_ELSE, OP_ENDIF:
+		vm := Engine{condNestDepth: s.condNestDepth,
+			condDisableDepth: s.condDisableDepth}
+		if err := op.opfunc(op, data, &vm); err != nil {
+			return err
+		}
+		s.condNestDepth = vm.condNestDepth
+		s.condDisableDepth = vm.condDisableDepth
+		return nil
+	}
+
+	if !s.isBranchExecuting() {
+		return nil
+	}
+
+	if op.value <= OP_PUSHDATA4 {
+		if err := checkMinimalDataPush(op, data); err != nil {
+			return err
+		}
+	}
+
+	if handled, err := x.executeStackOp(s, op); handled {
+		return err
+	}
+
+	// Opcodes combined with a verify execute the underlying opcode first.
+	if v, ok := symbolicVerifyOps[op.value]; ok {
+		if err := x.executeValueOp(s, &opcodeArray[v.base], data); err != nil {
+			return err
+		}
+		return x.verify(s, op, s.pop(), v.kind)
+	}
+
+	return x.executeValueOp(s, op, data)
+}
+
+// executeValueOp symbolically executes all opcodes which are not conditionals
+// or structural stack manipulation.
+func (x *symbolicExecutor) executeValueOp(s *symbolicState, op *opcode, data []byte) error {
+	switch op.value {
+	case OP_VERIFY:
+		return x.verify(s, op, s.pop(), ErrVerify)
+
+	case OP_IFDUP:
+		v := s.pop()
+		s.push(v)
+		dup, err := x.branch(s, v, func(*symbolicState) {})
+		if err != nil {
+			return err
+		}
+		if dup {
+			s.push(v)
+		}
+		return nil
+
+	case OP_SIZE:
+		v := top1(s)
+		if !v.IsConst() {
+			s.push(symOp("size", v))
+			return nil
+		}
+		s.push(symConst(ScriptNum(len(v.Data)).Bytes()))
+		return nil
+
+	case OP_CHECKSIG:
+		args := s.popN(2)
+		s.push(symOp("checksig", args[0], args[1]))
+		return nil
+
+	case OP_CHECKSIGALT:
+		return x.executeCheckSigAlt(s, op)
+
+	case OP_CHECKMULTISIG:
+		return x.executeCheckMultiSig(s, op)
+
+	case OP_CHECKLOCKTIMEVERIFY:
+		if !x.hasFlag(ScriptVerifyCheckLockTimeVerify) {
+			break
+		}
+		return x.executeCheckLockTime(s, op)
+
+	case OP_CHECKSEQUENCEVERIFY:
+		if !x.hasFlag(ScriptVerifyCheckSequenceVerify) {
+			break
+		}
+		return x.executeCheckSequence(s, op)
+	}
+
+	// OP_SHA256 is only a hashing opcode when the associated flag is set.
+	pure, isPure := symbolicPureOps[op.value]
+	if op.value == OP_SHA256 && !x.hasFlag(ScriptVerifySHA256) {
+		isPure = false
+	}
+	if !isPure {
+		// The remaining opcodes do not consume any stack items, so they are
+		// evaluated concretely.  This handles data pushes, NOPs and the
+		// various reserved and invalid opcodes.
+		results, err := x.evalConcrete(op, data, nil)
+		if err != nil {
+			return err
+		}
+		s.dstack = append(s.dstack, results...)
+		return nil
+	}
+
+	args := s.popN(pure.arity)
+	allConst := true
+	for _, arg := range args {
+		allConst = allConst && arg.IsConst()
+	}
+	if allConst {
+		results, err := x.evalConcrete(op, data, args)
+		if err != nil {
+			return err
+		}
+		s.dstack = append(s.dstack, results...)
+		return nil
+	}
+
+	// Express the opcodes that operate against an implicit value in terms
+	// of the explicit operation.
+	switch op.value {
+	case OP_1ADD, OP_1SUB:
+		args = append(args, symConst(ScriptNum(1).Bytes()))
+	case OP_0NOTEQUAL:
+		args = append(args, symConst(nil))
+	}
+	s.push(symOp(pure.name, args...))
+	return nil
+}
+
+// top1 returns the top item of the data stack without removing it.
+func top1(s *symbolicState) *SymbolicValue {
+	s.ensureDepth(1)
+	return s.dstack[len(s.dstack)-1]
+}
+
+// executeCheckSigAlt symbolically executes OP_CHECKSIGALT.  The signature type
+// must be concrete since it determines how many items are consumed.
+func (x *symbolicExecutor) executeCheckSigAlt(s *symbolicState, op *opcode) error {
+	sigType, err := s.popConstInt(op, altSigSuitesMaxscriptNumLen)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	switch sigType {
+	case 0:
+		s.push(symConst(fromBool(false)))
+		return nil
+	case dcrec.STEd25519:
+		name = "checksig_ed25519"
+	case dcrec.STSchnorrSecp256k1:
+		name = "checksig_schnorr"
+	default:
+		// Unknown signature types are always valid.
+		s.push(symConst(fromBool(true)))
+		return nil
+	}
+
+	args := s.popN(2)
+	s.push(symOp(name, args[0], args[1]))
+	return nil
+}
+
+// executeCheckMultiSig symbolically executes OP_CHECKMULTISIG.  The number of
+// signatures and public keys must be concrete.
+func (x *symbolicExecutor) executeCheckMultiSig(s *symbolicState, op *opcode) error {
+	numKeys, err := s.popConstInt(op, MathOpCodeMaxScriptNumLen)
+	if err != nil {
+		return err
+	}
+	numPubKeys := int(numKeys.Int32())
+	if numPubKeys < 0 || numPubKeys > MaxPubKeysPerMultiSig {
+		str := fmt.Sprintf("invalid number of pubkeys: %d", numPubKeys)
+		return scriptError(ErrInvalidPubKeyCount, str)
+	}
+	s.numOps += numPubKeys
+	if s.numOps > MaxOpsPerScript {
+		str := fmt.Sprintf("exceeded max operation limit of %d",
+			MaxOpsPerScript)
+		return scriptError(ErrTooManyOperations, str)
+	}
+	pubKeys := s.popN(numPubKeys)
+
+	numSigs, err := s.popConstInt(op, MathOpCodeMaxScriptNumLen)
+	if err != nil {
+		return err
+	}
+	numSignatures := int(numSigs.Int32())
+	if numSignatures < 0 || numSignatures > numPubKeys {
+		str := fmt.Sprintf("invalid number of signatures: %d",
+			numSignatures)
+		return scriptError(ErrInvalidSignatureCount, str)
+	}
+	sigs := s.popN(numSignatures)
+
+	s.push(symOp("checkmultisig", symOp("list", sigs...),
+		symOp("list", pubKeys...)))
+	return nil
+}
+
+// executeCheckLockTime symbolically executes OP_CHECKLOCKTIMEVERIFY by
+// recording the constraints it imposes on the spending transaction.
+func (x *symbolicExecutor) executeCheckLockTime(s *symbolicState, op *opcode) error {
+	lockTime := top1(s)
+	if lockTime.IsConst() {
+		n, err := MakeScriptNum(lockTime.Data, CltvMaxScriptNumLen)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			str := fmt.Sprintf("negative lock time: %d", n)
+			return scriptError(ErrNegativeLockTime, str)
+		}
+	}
+
+	s.constrain(symOp(">=", symTxField(SymFieldLockTime), lockTime), true)
+	maxSeq := symConst(ScriptNum(wire.MaxTxInSequenceNum).Bytes())
+	s.constrain(symOp("!=", symTxField(SymFieldSequence), maxSeq), true)
+	return nil
+}
+
+// executeCheckSequence symbolically executes OP_CHECKSEQUENCEVERIFY by
+// recording the constraints it imposes on the spending transaction.
+func (x *symbolicExecutor) executeCheckSequence(s *symbolicState, op *opcode) error {
+	sequence := top1(s)
+	if sequence.IsConst() {
+		n, err := MakeScriptNum(sequence.Data, CsvMaxScriptNumLen)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			str := fmt.Sprintf("negative sequence: %d", n)
+			return scriptError(ErrNegativeLockTime, str)
+		}
+
+		// The opcode behaves as a NOP when the disabled flag is set.
+		if int64(n)&int64(wire.SequenceLockTimeDisabled) != 0 {
+			return nil
+		}
+		mask := int64(wire.SequenceLockTimeIsSeconds |
+			wire.SequenceLockTimeMask)
+		sequence = symConst(ScriptNum(int64(n) & mask).Bytes())
+	}
+
+	s.constrain(symOp(">=", symTxField(SymFieldVersion),
+		symConst(ScriptNum(2).Bytes())), true)
+	s.constrain(symOp(">=", symTxField(SymFieldSequence), sequence), true)
+	return nil
+}
+
+// run executes the state until the end of the script and returns the
+// resulting path.
+func (x *symbolicExecutor) run(s *symbolicState, finalize func(*symbolicState, error) SymbolicPath) SymbolicPath {
+	for s.tokenizer.Next() {
+		err := x.executeOpcode(s, s.tokenizer.op, s.tokenizer.Data())
+		if err != nil {
+			return finalize(s, err)
+		}
+
+		if len(s.dstack)+len(s.astack) > MaxStackSize {
+			str := fmt.Sprintf("combined stack size %d > max allowed %d",
+				len(s.dstack)+len(s.astack), MaxStackSize)
+			return finalize(s, scriptError(ErrStackOverflow, str))
+		}
+	}
+	if err := s.tokenizer.Err(); err != nil {
+		return finalize(s, err)
+	}
+	if s.condNestDepth != 0 {
+		return finalize(s, scriptError(ErrUnbalancedConditional,
+			"end of script reached in conditional execution"))
+	}
+
+	// The final top stack item must be true.
+	if err := x.verify(s, &opcodeArray[OP_VERIFY], s.pop(), ErrEvalFalse); err != nil {
+		return finalize(s, scriptError(ErrEvalFalse,
+			"false stack entry at end of script execution"))
+	}
+	if x.hasFlag(ScriptVerifyCleanStack) && len(s.dstack) != 0 {
+		str := fmt.Sprintf("stack must contain exactly one item (contains "+
+			"%d)", len(s.dstack)+1)
+		return finalize(s, scriptError(ErrCleanStack, str))
+	}
+	return finalize(s, nil)
+}
+
+// renumberInputs returns a copy of the passed expression with the inputs
+// renumbered from discovery order, where index 0 is the top of the stack when
+// the script starts executing, to signature script order, where index 0 is the
+// first item pushed by the signature script.
+func renumberInputs(v *SymbolicValue, numInputs int) *SymbolicValue {
+	switch v.Kind {
+	case SymInput:
+		return &SymbolicValue{Kind: SymInput, Index: numInputs - 1 - v.Index}
+	case SymOp:
+		args := make([]*SymbolicValue, 0, len(v.Args))
+		for _, arg := range v.Args {
+			args = append(args, renumberInputs(arg, numInputs))
+		}
+		return &SymbolicValue{Kind: SymOp, Name: v.Name, Args: args}
+	}
+	return v
+}
+
+// symbolicExecute symbolically executes the provided script and returns all of
+// its execution paths.  The inputs in the returned constraints are renumbered
+// to signature script order when requested and are otherwise left in discovery
+// order where index 0 is the top of the stack when the script starts
+// executing.  Discovery order is useful to relate the inputs of paths that
+// share a common prefix since it does not depend on the total number of inputs
+// consumed by each path.
+func symbolicExecute(script []byte, scriptVersion uint16, flags ScriptFlags, renumber bool) ([]SymbolicPath, error) {
+	// All script versions other than 0 currently execute without issue,
+	// making all outputs to them anyone can pay.
+	if scriptVersion != 0 {
+		return []SymbolicPath{{}}, nil
+	}
+	if len(script) > MaxScriptSize {
+		str := fmt.Sprintf("script size %d is larger than max allowed "+
+			"size %d", len(script), MaxScriptSize)
+		return nil, scriptError(ErrScriptTooBig, str)
+	}
+	if err := checkScriptParses(scriptVersion, script); err != nil {
+		return nil, err
+	}
+
+	finalize := func(s *symbolicState, err error) SymbolicPath {
+		constraints := s.constraints
+		if renumber {
+			constraints = make([]SymbolicConstraint, 0, len(s.constraints))
+			for _, c := range s.constraints {
+				c.Expr = renumberInputs(c.Expr, s.numInputs)
+				constraints = append(constraints, c)
+			}
+		}
+		return SymbolicPath{
+			Branches:    s.branches,
+			NumInputs:   s.numInputs,
+			Constraints: constraints,
+			Err:         err,
+		}
+	}
+
+	x := symbolicExecutor{flags: flags, numPaths: 1}
+	x.pending = append(x.pending, &symbolicState{
+		tokenizer:        MakeScriptTokenizer(scriptVersion, script),
+		condDisableDepth: noCondDisableDepth,
+	})
+
+	var paths []SymbolicPath
+	for len(x.pending) > 0 {
+		s := x.pending[len(x.pending)-1]
+		x.pending = x.pending[:len(x.pending)-1]
+		path := x.run(s, finalize)
+		if errors.Is(path.Err, ErrTooManyPaths) {
+			return nil, path.Err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// SymbolicExecute executes the provided script without a signature script or
+// spending transaction and returns every execution path through it along with
+// the constraints that must hold on the items provided by the signature script
+// and on the spending transaction for the path to succeed.
+//
+// Items provided by the signature script are represented by symbols named x0,
+// x1, and so on, where x0 is the first item pushed by the signature script.
+// Operations that involve symbols are recorded as expressions, so, for
+// example, the constraints for a pay-to-pubkey-hash script are:
+//
+//  hash160(x1) == <pubkey hash>
+//  checksig(x0, x1)
+//
+// The script is typically a public key script or, in the case of
+// pay-to-script-hash, the redeem script.  The flags modify the interpretation
+// of the opcodes in the same way they do for the Engine.
+//
+// An error is only returned when the script can not be analyzed at all.
+// Paths that can never succeed are reported with their Err field set.
+func SymbolicExecute(script []byte, scriptVersion uint16, flags ScriptFlags) ([]SymbolicPath, error) {
+	return symbolicExecute(script, scriptVersion, flags, true)
+}
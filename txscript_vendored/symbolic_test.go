@@ -0,0 +1,192 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestSymbolicExecute ensures the symbolic interpreter produces the expected
+// constraints and failures for each execution path of a variety of scripts.
+func TestSymbolicExecute(t *testing.T) {
+	t.Parallel()
+
+	const flags = ScriptVerifyCheckLockTimeVerify |
+		ScriptVerifyCheckSequenceVerify | ScriptVerifySHA256
+
+	// path is a simplified representation of a SymbolicPath that is easier to
+	// specify in the test data.
+	type path struct {
+		branches    []bool
+		numInputs   int
+		constraints []string
+		err         error
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		paths  []path
+		err    error
+	}{{
+		name:   "p2pkh",
+		script: "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG",
+		paths: []path{{
+			numInputs: 2,
+			constraints: []string{
+				"hash160(x1) == 0x0101010101010101010101010101010101010101",
+				"checksig(x0, x1)",
+			},
+		}},
+	}, {
+		name:   "p2sh",
+		script: "HASH160 DATA_20 0x02{20} EQUAL",
+		paths: []path{{
+			numInputs: 1,
+			constraints: []string{
+				"hash160(x0) == 0x0202020202020202020202020202020202020202",
+			},
+		}},
+	}, {
+		name: "hash locked or timelocked refund",
+		script: "IF SHA256 DATA_32 0x03{32} EQUALVERIFY DATA_33 0x02{33} " +
+			"ELSE 500000 CHECKLOCKTIMEVERIFY DROP DATA_33 0x03{33} ENDIF " +
+			"CHECKSIG",
+		paths: []path{{
+			branches:  []bool{true},
+			numInputs: 3,
+			constraints: []string{
+				"x2",
+				"sha256(x1) == 0x" +
+					"0303030303030303030303030303030303030303030303030303030303030303",
+				"checksig(x0, 0x" +
+					"020202020202020202020202020202020202020202020202020202020202020202)",
+			},
+		}, {
+			branches:  []bool{false},
+			numInputs: 2,
+			constraints: []string{
+				"!x1",
+				"locktime >= 500000",
+				"sequence != 4294967295",
+				"checksig(x0, 0x" +
+					"030303030303030303030303030303030303030303030303030303030303030303)",
+			},
+		}},
+	}, {
+		name:   "relative timelock",
+		script: "144 CHECKSEQUENCEVERIFY DROP 1",
+		paths: []path{{
+			constraints: []string{"version >= 2", "sequence >= 144"},
+		}},
+	}, {
+		name:   "2-of-2 multisig",
+		script: "2 DATA_33 0x02{33} DATA_33 0x03{33} 2 CHECKMULTISIG",
+		paths: []path{{
+			numInputs: 2,
+			constraints: []string{
+				"checkmultisig([x0, x1], [0x" +
+					"020202020202020202020202020202020202020202020202020202020202020202, 0x" +
+					"030303030303030303030303030303030303030303030303030303030303030303])",
+			},
+		}},
+	}, {
+		name:   "repeated condition is not explored twice",
+		script: "DUP IF 1 ELSE 2 ENDIF SWAP IF 1ADD ENDIF 2 NUMEQUAL",
+		paths: []path{{
+			branches:    []bool{true},
+			numInputs:   1,
+			constraints: []string{"x0"},
+		}, {
+			branches:    []bool{false},
+			numInputs:   1,
+			constraints: []string{"!x0"},
+		}},
+	}, {
+		name:   "concrete values are folded",
+		script: "1 2 ADD 3 NUMEQUALVERIFY 1",
+		paths:  []path{{}},
+	}, {
+		name:   "early return",
+		script: "RETURN",
+		paths:  []path{{err: ErrEarlyReturn}},
+	}, {
+		name:   "symbolic pick index",
+		script: "PICK",
+		paths: []path{{
+			numInputs: 1,
+			err:       ErrUnsupportedSymbolicOp,
+		}},
+	}, {
+		name:   "unparsable script",
+		script: "0x4c",
+		err:    ErrMalformedPush,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		gotPaths, err := SymbolicExecute(script, 0, flags)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if len(gotPaths) != len(test.paths) {
+			t.Errorf("%q: unexpected number of paths -- got %d, want %d",
+				test.name, len(gotPaths), len(test.paths))
+			continue
+		}
+
+		for i, gotPath := range gotPaths {
+			wantPath := test.paths[i]
+			if !errors.Is(gotPath.Err, wantPath.err) {
+				t.Errorf("%q: path #%d: unexpected error -- got %v, want %v",
+					test.name, i, gotPath.Err, wantPath.err)
+				continue
+			}
+			if len(gotPath.Branches) != 0 || len(wantPath.branches) != 0 {
+				if !reflect.DeepEqual(gotPath.Branches, wantPath.branches) {
+					t.Errorf("%q: path #%d: unexpected branches -- got %v, "+
+						"want %v", test.name, i, gotPath.Branches,
+						wantPath.branches)
+					continue
+				}
+			}
+			if gotPath.NumInputs != wantPath.numInputs {
+				t.Errorf("%q: path #%d: unexpected number of inputs -- got "+
+					"%d, want %d", test.name, i, gotPath.NumInputs,
+					wantPath.numInputs)
+				continue
+			}
+
+			var gotConstraints []string
+			for _, c := range gotPath.Constraints {
+				gotConstraints = append(gotConstraints, c.String())
+			}
+			if wantPath.err != nil {
+				continue
+			}
+			if !reflect.DeepEqual(gotConstraints, wantPath.constraints) {
+				t.Errorf("%q: path #%d: unexpected constraints -- got %q, "+
+					"want %q", test.name, i, gotConstraints,
+					wantPath.constraints)
+			}
+		}
+	}
+}
+
+// TestSymbolicExecuteTooManyPaths ensures scripts with an excessive number of
+// execution paths are rejected.
+func TestSymbolicExecuteTooManyPaths(t *testing.T) {
+	t.Parallel()
+
+	script := mustParseShortForm("<IF ENDIF>{11} 1")
+	_, err := SymbolicExecute(script, 0, 0)
+	if !errors.Is(err, ErrTooManyPaths) {
+		t.Fatalf("unexpected error -- got %v, want %v", err, ErrTooManyPaths)
+	}
+}
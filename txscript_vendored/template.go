@@ -0,0 +1,633 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TemplateValueType identifies the type of a value captured by a script
+// template.
+type TemplateValueType byte
+
+// Types of values captured by script templates.
+const (
+	TemplateData   TemplateValueType = iota // Data push of any size.
+	TemplateSized                           // Data push of a fixed size.
+	TemplatePubKey                          // Strictly-encoded public key.
+	TemplateInt                             // Canonically-encoded integer.
+	TemplateOpcode                          // Any single opcode.
+)
+
+// templateValueTypeToName houses the names used for each type of captured
+// value in the template syntax.
+var templateValueTypeToName = []string{
+	TemplateData:   "data",
+	TemplateSized:  "sized",
+	TemplatePubKey: "pubkey",
+	TemplateInt:    "int",
+	TemplateOpcode: "op",
+}
+
+// String implements the Stringer interface by returning the name of the enum
+// value type.  If the enum is invalid then "Invalid" will be returned.
+func (t TemplateValueType) String() string {
+	if int(t) >= len(templateValueTypeToName) {
+		return "Invalid"
+	}
+	return templateValueTypeToName[t]
+}
+
+// TemplateCapture is a value captured while matching a script template.  Data
+// is set for all types which capture a data push, Int is set for integers and
+// Opcode is always set to the opcode that was matched.
+type TemplateCapture struct {
+	Name   string
+	Type   TemplateValueType
+	Opcode byte
+	Data   []byte
+	Int    int64
+}
+
+// TemplateMatch is the result of successfully matching a script template.
+// Captures are listed in script order.
+type TemplateMatch struct {
+	Template *ScriptTemplate
+	Captures []TemplateCapture
+}
+
+// Values returns all of the values captured under the provided name in script
+// order.  Repeated captures, such as the public keys of a multisig script,
+// produce multiple values.
+func (m *TemplateMatch) Values(name string) []TemplateCapture {
+	var values []TemplateCapture
+	for _, c := range m.Captures {
+		if c.Name == name {
+			values = append(values, c)
+		}
+	}
+	return values
+}
+
+// templateNodeKind identifies the kind of a node of a parsed template.
+type templateNodeKind byte
+
+const (
+	tnOpcode templateNodeKind = iota
+	tnInt
+	tnLiteral
+	tnCapture
+	tnSeq
+	tnAlt
+	tnRepeat
+)
+
+// templateNode is a node of a parsed template.  Only the fields relevant to
+// the kind of node are set.
+type templateNode struct {
+	kind      templateNodeKind
+	opcode    byte
+	num       int64
+	data      []byte
+	name      string
+	valueType TemplateValueType
+	size      int
+	children  []*templateNode
+	min, max  int
+}
+
+// templateToken is a parsed opcode of the script being matched.
+type templateToken struct {
+	opcode byte
+	data   []byte
+}
+
+// templateContinuation is invoked with the position and captures after a node
+// matches and returns whether the remainder of the template matches.
+type templateContinuation func(pos int, caps []TemplateCapture) bool
+
+// tokenInt returns the integer pushed by the provided token and whether it is
+// a canonically-encoded integer.
+func tokenInt(tok templateToken) (int64, bool) {
+	switch {
+	case IsSmallInt(tok.opcode):
+		return int64(AsSmallInt(tok.opcode)), true
+	case tok.opcode == OP_1NEGATE:
+		return -1, true
+	case tok.opcode > OP_PUSHDATA4 || !isCanonicalPush(tok.opcode, tok.data):
+		return 0, false
+	}
+	val, err := MakeScriptNum(tok.data, CltvMaxScriptNumLen)
+	if err != nil {
+		return 0, false
+	}
+	return int64(val), true
+}
+
+// isTemplateDataPush returns whether the provided token is a canonical data
+// push.
+func isTemplateDataPush(tok templateToken) bool {
+	return tok.opcode <= OP_PUSHDATA4 && isCanonicalPush(tok.opcode, tok.data)
+}
+
+// matchToken returns the capture produced by matching a single token against
+// a leaf node and whether it matched.
+func (n *templateNode) matchToken(tok templateToken) (TemplateCapture, bool) {
+	switch n.kind {
+	case tnOpcode:
+		return TemplateCapture{}, tok.opcode == n.opcode
+
+	case tnInt:
+		val, ok := tokenInt(tok)
+		return TemplateCapture{}, ok && val == n.num
+
+	case tnLiteral:
+		return TemplateCapture{}, isTemplateDataPush(tok) &&
+			string(tok.data) == string(n.data)
+	}
+
+	c := TemplateCapture{Name: n.name, Type: n.valueType, Opcode: tok.opcode}
+	switch n.valueType {
+	case TemplateData:
+		c.Data = tok.data
+		return c, isTemplateDataPush(tok)
+
+	case TemplateSized:
+		c.Data = tok.data
+		return c, isTemplateDataPush(tok) && len(tok.data) == n.size
+
+	case TemplatePubKey:
+		c.Data = tok.data
+		return c, isTemplateDataPush(tok) && isStrictPubKeyEncoding(tok.data)
+
+	case TemplateInt:
+		val, ok := tokenInt(tok)
+		c.Int = val
+		return c, ok
+	}
+	c.Data = tok.data
+	return c, true
+}
+
+// match attempts to match the node against the tokens starting at the provided
+// position and invokes the continuation for every way it matches until the
+// continuation succeeds.  It returns whether the continuation succeeded.
+func (n *templateNode) match(toks []templateToken, pos int, caps []TemplateCapture, k templateContinuation) bool {
+	switch n.kind {
+	case tnSeq:
+		return n.matchSeq(0, toks, pos, caps, k)
+
+	case tnAlt:
+		for _, child := range n.children {
+			if child.match(toks, pos, caps, k) {
+				return true
+			}
+		}
+		return false
+
+	case tnRepeat:
+		return n.matchRepeat(0, toks, pos, caps, k)
+	}
+
+	if pos >= len(toks) {
+		return false
+	}
+	c, ok := n.matchToken(toks[pos])
+	if !ok {
+		return false
+	}
+	if n.kind == tnCapture {
+		caps = append(caps[:len(caps):len(caps)], c)
+	}
+	return k(pos+1, caps)
+}
+
+// matchSeq matches the children of a sequence node starting with the child at
+// the provided index.
+func (n *templateNode) matchSeq(idx int, toks []templateToken, pos int, caps []TemplateCapture, k templateContinuation) bool {
+	if idx == len(n.children) {
+		return k(pos, caps)
+	}
+	return n.children[idx].match(toks, pos, caps,
+		func(pos int, caps []TemplateCapture) bool {
+			return n.matchSeq(idx+1, toks, pos, caps, k)
+		})
+}
+
+// matchRepeat matches the child of a repetition node greedily given it already
+// matched the provided number of times.
+func (n *templateNode) matchRepeat(count int, toks []templateToken, pos int, caps []TemplateCapture, k templateContinuation) bool {
+	if n.max < 0 || count < n.max {
+		matched := n.children[0].match(toks, pos, caps,
+			func(next int, caps []TemplateCapture) bool {
+				// Stop repeating matches which do not consume any
+				// opcodes to avoid infinite recursion.
+				if next == pos {
+					return false
+				}
+				return n.matchRepeat(count+1, toks, next, caps, k)
+			})
+		if matched {
+			return true
+		}
+	}
+	return count >= n.min && k(pos, caps)
+}
+
+// ScriptTemplate is a compiled script template that matches scripts against a
+// pattern.  See ParseScriptTemplate for the syntax.
+type ScriptTemplate struct {
+	name    string
+	pattern string
+	root    *templateNode
+}
+
+// Name returns the name of the template.
+func (t *ScriptTemplate) Name() string {
+	return t.name
+}
+
+// String returns the pattern the template was parsed from.
+func (t *ScriptTemplate) String() string {
+	return t.pattern
+}
+
+// Match returns the values captured by matching the template against the
+// entire script or nil when the script does not match.  Only version 0 scripts
+// are matched.  An error is returned when the script does not parse.
+func (t *ScriptTemplate) Match(scriptVersion uint16, script []byte) (*TemplateMatch, error) {
+	if scriptVersion != 0 {
+		return nil, nil
+	}
+
+	var toks []templateToken
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		toks = append(toks, templateToken{
+			opcode: tokenizer.Opcode(),
+			data:   tokenizer.Data(),
+		})
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+
+	var match *TemplateMatch
+	t.root.match(toks, 0, nil, func(pos int, caps []TemplateCapture) bool {
+		if pos != len(toks) {
+			return false
+		}
+		match = &TemplateMatch{Template: t, Captures: caps}
+		return true
+	})
+	return match, nil
+}
+
+// templateOps maps the opcode names accepted by templates to their values.
+// Opcodes may be named with or without the OP_ prefix except for the small
+// integer opcodes whose plain form is the integer literal.  The map is built
+// from the opcode array rather than OpcodeByName since the latter is populated
+// by an init function.
+var templateOps = func() map[string]byte {
+	names := map[string]byte{
+		"OP_FALSE": OP_FALSE,
+		"OP_TRUE":  OP_TRUE,
+		"OP_NOP2":  OP_CHECKLOCKTIMEVERIFY,
+		"OP_NOP3":  OP_CHECKSEQUENCEVERIFY,
+	}
+	for _, op := range opcodeArray {
+		names[op.name] = op.value
+	}
+
+	ops := make(map[string]byte)
+	for name, value := range names {
+		if strings.Contains(name, "OP_UNKNOWN") {
+			continue
+		}
+		ops[name] = value
+		if name == "OP_FALSE" || name == "OP_TRUE" ||
+			(value != OP_0 && (value < OP_1 || value > OP_16)) {
+
+			ops[strings.TrimPrefix(name, "OP_")] = value
+		}
+	}
+	return ops
+}()
+
+// templateParser parses the tokens of a template pattern into nodes.
+type templateParser struct {
+	toks []string
+	pos  int
+}
+
+// malformedTemplate returns an error for a template that failed to parse.
+func malformedTemplate(format string, args ...interface{}) error {
+	return scriptError(ErrMalformedTemplate, fmt.Sprintf(format, args...))
+}
+
+// lexTemplate splits a template pattern into tokens.  Groups, alternation and
+// quantifiers are separate tokens even when they are not separated from their
+// neighbors by whitespace.
+func lexTemplate(pattern string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(pattern); {
+		switch c := pattern[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case strings.IndexByte("()|*+?", c) >= 0:
+			toks = append(toks, pattern[i:i+1])
+			i++
+
+		case c == '<' || c == '{':
+			closing := byte('>')
+			if c == '{' {
+				closing = '}'
+			}
+			end := strings.IndexByte(pattern[i:], closing)
+			if end < 0 {
+				return nil, malformedTemplate("unterminated %q at "+
+					"offset %d", c, i)
+			}
+			toks = append(toks, pattern[i:i+end+1])
+			i += end + 1
+
+		default:
+			end := i
+			for end < len(pattern) &&
+				strings.IndexByte(" \t\n\r()|*+?<{", pattern[end]) < 0 {
+
+				end++
+			}
+			toks = append(toks, pattern[i:end])
+			i = end
+		}
+	}
+	return toks, nil
+}
+
+// peek returns the next token or an empty string when there are none left.
+func (p *templateParser) peek() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+// parseAlt parses a sequence of alternatives separated by '|'.
+func (p *templateParser) parseAlt() (*templateNode, error) {
+	var alts []*templateNode
+	for {
+		seq, err := p.parseSeq()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, seq)
+		if p.peek() != "|" {
+			break
+		}
+		p.pos++
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return &templateNode{kind: tnAlt, children: alts}, nil
+}
+
+// parseSeq parses a sequence of quantified elements up to the end of the
+// enclosing group or alternative.
+func (p *templateParser) parseSeq() (*templateNode, error) {
+	seq := &templateNode{kind: tnSeq}
+	for {
+		switch p.peek() {
+		case "", "|", ")":
+			if len(seq.children) == 0 {
+				return nil, malformedTemplate("empty sequence at token %d",
+					p.pos)
+			}
+			return seq, nil
+		}
+
+		elem, err := p.parseElem()
+		if err != nil {
+			return nil, err
+		}
+		elem, err = p.parseQuantifier(elem)
+		if err != nil {
+			return nil, err
+		}
+		seq.children = append(seq.children, elem)
+	}
+}
+
+// parseQuantifier wraps the provided element in a repetition node when it is
+// followed by a quantifier.
+func (p *templateParser) parseQuantifier(elem *templateNode) (*templateNode, error) {
+	tok := p.peek()
+	rep := &templateNode{kind: tnRepeat, children: []*templateNode{elem}}
+	switch {
+	case tok == "*":
+		rep.min, rep.max = 0, -1
+	case tok == "+":
+		rep.min, rep.max = 1, -1
+	case tok == "?":
+		rep.min, rep.max = 0, 1
+	case strings.HasPrefix(tok, "{"):
+		bounds := strings.Split(tok[1:len(tok)-1], ",")
+		if len(bounds) > 2 {
+			return nil, malformedTemplate("bad quantifier %q", tok)
+		}
+		var err error
+		rep.min, err = strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil || rep.min < 0 {
+			return nil, malformedTemplate("bad quantifier %q", tok)
+		}
+		rep.max = rep.min
+		if len(bounds) == 2 {
+			rep.max = -1
+			if max := strings.TrimSpace(bounds[1]); max != "" {
+				rep.max, err = strconv.Atoi(max)
+				if err != nil || rep.max < rep.min {
+					return nil, malformedTemplate("bad quantifier %q",
+						tok)
+				}
+			}
+		}
+	default:
+		return elem, nil
+	}
+	p.pos++
+	return rep, nil
+}
+
+// parseElem parses a single opcode, literal, capture or group.
+func (p *templateParser) parseElem() (*templateNode, error) {
+	tok := p.peek()
+	p.pos++
+	switch {
+	case tok == "(":
+		node, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, malformedTemplate("unbalanced parentheses")
+		}
+		p.pos++
+		return node, nil
+
+	case tok == "*" || tok == "+" || tok == "?" || tok[0] == '{':
+		return nil, malformedTemplate("quantifier %q does not follow an "+
+			"element", tok)
+
+	case tok[0] == '<':
+		return parseTemplateCapture(tok)
+
+	case strings.HasPrefix(tok, "0x"):
+		data, err := hex.DecodeString(tok[2:])
+		if err != nil {
+			return nil, malformedTemplate("bad hex literal %q", tok)
+		}
+		return &templateNode{kind: tnLiteral, data: data}, nil
+	}
+
+	if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
+		return &templateNode{kind: tnInt, num: num}, nil
+	}
+	if op, ok := templateOps[strings.ToUpper(tok)]; ok {
+		return &templateNode{kind: tnOpcode, opcode: op}, nil
+	}
+	return nil, malformedTemplate("unknown opcode %q", tok)
+}
+
+// parseTemplateCapture parses a capture of the form <name:type>.
+func parseTemplateCapture(tok string) (*templateNode, error) {
+	parts := strings.SplitN(tok[1:len(tok)-1], ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, malformedTemplate("capture %q is not of the form "+
+			"<name:type>", tok)
+	}
+
+	node := &templateNode{kind: tnCapture, name: parts[0]}
+	switch parts[1] {
+	case "data":
+		node.valueType = TemplateData
+	case "pubkey":
+		node.valueType = TemplatePubKey
+	case "int":
+		node.valueType = TemplateInt
+	case "op":
+		node.valueType = TemplateOpcode
+	default:
+		size, err := strconv.Atoi(parts[1])
+		if err != nil || size < 0 || size > MaxScriptElementSize {
+			return nil, malformedTemplate("capture %q has unknown type %q",
+				tok, parts[1])
+		}
+		node.valueType = TemplateSized
+		node.size = size
+	}
+	return node, nil
+}
+
+// ParseScriptTemplate parses a pattern into a named script template.
+//
+// A pattern is a whitespace-separated sequence of elements which must match
+// the entire script:
+//   - Opcodes are written as either OP_NAME or just NAME
+//   - Plain numbers match canonical pushes of the number, including the small
+//     integer opcodes
+//   - Hex literals beginning with 0x match canonical pushes of the data
+//   - Captures of the form <name:type> match a single opcode and record its
+//     value under the name.  The type is one of data (any data push), pubkey
+//     (strictly-encoded public key), int (canonically-encoded integer), op
+//     (any opcode) or a number of bytes the data push must have
+//   - Parentheses group elements and | separates alternatives
+//   - An element or group followed by *, +, ?, {n}, {n,} or {n,m} is repeated
+//     the respective number of times
+//
+// For example, pay-to-pubkey-hash and 1-of-n multisig scripts are matched by:
+//
+//  DUP HASH160 <hash:20> EQUALVERIFY CHECKSIG
+//  1 <pubkey:pubkey>+ <n:int> CHECKMULTISIG
+func ParseScriptTemplate(name, pattern string) (*ScriptTemplate, error) {
+	toks, err := lexTemplate(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, malformedTemplate("template %q is empty", name)
+	}
+
+	p := templateParser{toks: toks}
+	root, err := p.parseAlt()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(toks) {
+		return nil, malformedTemplate("unexpected %q at token %d",
+			p.peek(), p.pos)
+	}
+	return &ScriptTemplate{name: name, pattern: pattern, root: root}, nil
+}
+
+// LoadScriptTemplates parses named script templates from the provided reader.
+// Each line consists of a name followed by a colon and the pattern as
+// described by ParseScriptTemplate.  Blank lines and lines starting with # are
+// ignored.  For example:
+//
+//  # Vault contracts used by our wallet.
+//  vault: IF <hot:pubkey> ELSE <delay:int> CHECKSEQUENCEVERIFY DROP <cold:pubkey> ENDIF CHECKSIG
+func LoadScriptTemplates(r io.Reader) ([]*ScriptTemplate, error) {
+	var templates []*ScriptTemplate
+	names := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" || strings.ContainsAny(name, " <") {
+			return nil, malformedTemplate("line %d: expected name: pattern",
+				lineNum)
+		}
+		if _, ok := names[name]; ok {
+			return nil, malformedTemplate("line %d: duplicate template %q",
+				lineNum, name)
+		}
+		names[name] = struct{}{}
+
+		template, err := ParseScriptTemplate(name, strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, malformedTemplate("line %d: %v", lineNum, err)
+		}
+		templates = append(templates, template)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// MatchScriptTemplates returns the match of the first of the provided
+// templates that matches the script or nil when none of them match.  An error
+// is returned when the script does not parse.
+func MatchScriptTemplates(templates []*ScriptTemplate, scriptVersion uint16, script []byte) (*TemplateMatch, error) {
+	for _, template := range templates {
+		match, err := template.Match(scriptVersion, script)
+		if err != nil || match != nil {
+			return match, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestScriptTemplateMatch ensures script templates match the expected scripts
+// and capture the expected values.
+func TestScriptTemplateMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		pattern  string
+		script   string
+		captures []string // nil when the script must not match
+		err      error
+	}{{
+		name:     "p2pkh",
+		pattern:  "DUP HASH160 <hash:20> EQUALVERIFY CHECKSIG",
+		script:   "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG",
+		captures: []string{"hash=" + strings.Repeat("01", 20)},
+	}, {
+		name:    "sized capture does not match other sizes",
+		pattern: "DUP HASH160 <hash:20> EQUALVERIFY CHECKSIG",
+		script:  "DUP HASH160 DATA_21 0x01{21} EQUALVERIFY CHECKSIG",
+	}, {
+		name:    "template must match entire script",
+		pattern: "DUP HASH160 <hash:20> EQUALVERIFY CHECKSIG",
+		script:  "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG NOP",
+	}, {
+		name:    "opcodes with prefix and lower case",
+		pattern: "op_dup OP_HASH160 <hash:20> equalverify checksig",
+		script:  "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG",
+		captures: []string{
+			"hash=" + strings.Repeat("01", 20),
+		},
+	}, {
+		name:    "repeated pubkeys",
+		pattern: "<m:int> <pubkey:pubkey>+ <n:int> CHECKMULTISIG",
+		script:  "2 DATA_33 0x02{33} DATA_33 0x03{33} 2 CHECKMULTISIG",
+		captures: []string{
+			"m=2",
+			"pubkey=" + strings.Repeat("02", 33),
+			"pubkey=" + strings.Repeat("03", 33),
+			"n=2",
+		},
+	}, {
+		name:    "pubkey capture requires strict encoding",
+		pattern: "<pubkey:pubkey> CHECKSIG",
+		script:  "DATA_33 0x05{33} CHECKSIG",
+	}, {
+		name:    "bounded repetition",
+		pattern: "NOP{2,3} 1",
+		script:  "NOP NOP NOP NOP 1",
+	}, {
+		name:     "repetition backtracks",
+		pattern:  "<items:data>* <last:data>",
+		script:   "DATA_1 0x81 DATA_2 0x0102",
+		captures: []string{"items=81", "last=0102"},
+	}, {
+		name:     "alternation",
+		pattern:  "(CHECKLOCKTIMEVERIFY | CHECKSEQUENCEVERIFY) DROP <lock:op>",
+		script:   "CHECKSEQUENCEVERIFY DROP NOP",
+		captures: []string{"lock=OP_NOP"},
+	}, {
+		name: "alternation with groups",
+		pattern: "(<pubkey:pubkey> CHECKSIG | DUP HASH160 <hash:20> " +
+			"EQUALVERIFY CHECKSIG)",
+		script:   "DATA_33 0x02{33} CHECKSIG",
+		captures: []string{"pubkey=" + strings.Repeat("02", 33)},
+	}, {
+		name:     "optional element",
+		pattern:  "(SIZE 32 EQUALVERIFY)? SHA256 <hash:32> EQUAL",
+		script:   "SHA256 DATA_32 0x01{32} EQUAL",
+		captures: []string{"hash=" + strings.Repeat("01", 32)},
+	}, {
+		name:     "integer literals match canonical pushes",
+		pattern:  "500000 CHECKLOCKTIMEVERIFY DROP 16 <n:int>",
+		script:   "500000 CHECKLOCKTIMEVERIFY DROP 16 -1",
+		captures: []string{"n=-1"},
+	}, {
+		name:    "integer literals do not match non-canonical pushes",
+		pattern: "1",
+		script:  "DATA_1 0x01",
+	}, {
+		name:     "hex literal",
+		pattern:  "RETURN 0x6a6b <payload:data>",
+		script:   "RETURN DATA_2 0x6a6b DATA_3 0x010203",
+		captures: []string{"payload=010203"},
+	}, {
+		name:    "unparsable script",
+		pattern: "<data:data>",
+		script:  "0x4c",
+		err:     ErrMalformedPush,
+	}}
+
+	for _, test := range tests {
+		template, err := ParseScriptTemplate(test.name, test.pattern)
+		if err != nil {
+			t.Errorf("%q: unexpected parse error: %v", test.name, err)
+			continue
+		}
+		script := mustParseShortForm(test.script)
+		match, err := template.Match(0, script)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if match == nil {
+			if test.captures != nil {
+				t.Errorf("%q: script did not match", test.name)
+			}
+			continue
+		}
+		if test.captures == nil {
+			t.Errorf("%q: script unexpectedly matched", test.name)
+			continue
+		}
+
+		var captures []string
+		for _, c := range match.Captures {
+			var value string
+			switch c.Type {
+			case TemplateInt:
+				value = fmt.Sprint(c.Int)
+			case TemplateOpcode:
+				value = opcodeArray[c.Opcode].name
+			default:
+				value = fmt.Sprintf("%x", c.Data)
+			}
+			captures = append(captures, c.Name+"="+value)
+		}
+		if len(captures) == 0 {
+			captures = []string{}
+		}
+		if !reflect.DeepEqual(captures, test.captures) {
+			t.Errorf("%q: unexpected captures -- got %q, want %q",
+				test.name, captures, test.captures)
+		}
+	}
+}
+
+// TestParseScriptTemplateErrors ensures malformed template patterns are
+// rejected.
+func TestParseScriptTemplateErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"",
+		"DUP HASH160 <hash:20",
+		"DUP (HASH160",
+		"DUP HASH160)",
+		"NOTANOPCODE",
+		"<hash>",
+		"<hash:bogus>",
+		"* DUP",
+		"DUP{3,1}",
+		"DUP ( | NOP)",
+		"0xzz",
+	}
+
+	for _, pattern := range tests {
+		_, err := ParseScriptTemplate("test", pattern)
+		if !errors.Is(err, ErrMalformedTemplate) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", pattern,
+				err, ErrMalformedTemplate)
+		}
+	}
+}
+
+// TestLoadScriptTemplates ensures templates are loaded from files and that the
+// first matching template is used.
+func TestLoadScriptTemplates(t *testing.T) {
+	t.Parallel()
+
+	const file = `
+# Comments and blank lines are ignored.
+p2pk: <pubkey:pubkey> CHECKSIG
+
+anykey: <key:33> CHECKSIG
+`
+	templates, err := LoadScriptTemplates(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(templates) != 2 {
+		t.Fatalf("unexpected number of templates -- got %d, want 2",
+			len(templates))
+	}
+
+	script := mustParseShortForm("DATA_33 0x02{33} CHECKSIG")
+	match, err := MatchScriptTemplates(templates, 0, script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if match == nil || match.Template.Name() != "p2pk" {
+		t.Fatalf("unexpected match -- got %+v, want p2pk", match)
+	}
+	if values := match.Values("pubkey"); len(values) != 1 {
+		t.Fatalf("unexpected number of values -- got %d, want 1",
+			len(values))
+	}
+
+	script = mustParseShortForm("DATA_33 0x05{33} CHECKSIG")
+	match, err = MatchScriptTemplates(templates, 0, script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if match == nil || match.Template.Name() != "anykey" {
+		t.Fatalf("unexpected match -- got %+v, want anykey", match)
+	}
+
+	badFiles := []string{
+		"no pattern",
+		"dup: NOP\ndup: NOP",
+		"bad: <hash",
+	}
+	for _, file := range badFiles {
+		_, err := LoadScriptTemplates(strings.NewReader(file))
+		if !errors.Is(err, ErrMalformedTemplate) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", file, err,
+				ErrMalformedTemplate)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// LockTimeKind identifies how the lock time required by an
+// OP_CHECKLOCKTIMEVERIFY or OP_CHECKSEQUENCEVERIFY is interpreted.
+type LockTimeKind byte
+
+// Kinds of lock times.
+const (
+	// LockTimeUnknown indicates the lock time is not a valid number pushed
+	// immediately prior to the opcode, such as when it is computed or
+	// provided by the signature script.
+	LockTimeUnknown LockTimeKind = iota
+
+	// LockTimeHeight indicates an absolute lock time that is a block height.
+	LockTimeHeight
+
+	// LockTimeTimestamp indicates an absolute lock time that is a unix
+	// timestamp.
+	LockTimeTimestamp
+
+	// LockTimeRelativeBlocks indicates a relative lock time that is a number
+	// of blocks since the spent output was mined.
+	LockTimeRelativeBlocks
+
+	// LockTimeRelativeSeconds indicates a relative lock time that is a
+	// number of 512-second units since the spent output was mined.
+	LockTimeRelativeSeconds
+
+	// LockTimeRelativeDisabled indicates a relative lock time with the
+	// disable flag set which makes OP_CHECKSEQUENCEVERIFY behave as a NOP.
+	LockTimeRelativeDisabled
+
+	// LockTimeNegative indicates a negative number pushed immediately prior
+	// to the opcode, which the script engine always rejects.
+	LockTimeNegative
+)
+
+// lockTimeKindToName houses the human-readable strings which describe each
+// kind of lock time.
+var lockTimeKindToName = []string{
+	LockTimeUnknown:          "unknown",
+	LockTimeHeight:           "height",
+	LockTimeTimestamp:        "timestamp",
+	LockTimeRelativeBlocks:   "relative blocks",
+	LockTimeRelativeSeconds:  "relative seconds",
+	LockTimeRelativeDisabled: "relative disabled",
+	LockTimeNegative:         "negative",
+}
+
+// String implements the Stringer interface by returning the name of the enum
+// lock time kind.  If the enum is invalid then "Invalid" will be returned.
+func (k LockTimeKind) String() string {
+	if int(k) >= len(lockTimeKindToName) {
+		return "Invalid"
+	}
+	return lockTimeKindToName[k]
+}
+
+// LockTimeRequirement describes a lock time enforced by a script.
+type LockTimeRequirement struct {
+	// Opcode is either OP_CHECKLOCKTIMEVERIFY or OP_CHECKSEQUENCEVERIFY.
+	Opcode byte
+
+	// Offset is the byte offset of the opcode in the script.
+	Offset int
+
+	// Value is the number pushed immediately prior to the opcode.  It is
+	// only valid when the kind is not LockTimeUnknown.
+	Value ScriptNum
+
+	// Kind identifies how the value is interpreted.
+	Kind LockTimeKind
+}
+
+// relativeLockTimeMask is the mask of the bits of a sequence number that are
+// compared by OP_CHECKSEQUENCEVERIFY.
+const relativeLockTimeMask = wire.SequenceLockTimeIsSeconds |
+	wire.SequenceLockTimeMask
+
+// String returns a human-readable interpretation of the lock time.
+func (r *LockTimeRequirement) String() string {
+	switch r.Kind {
+	case LockTimeHeight:
+		return fmt.Sprintf("block height %d", r.Value)
+
+	case LockTimeTimestamp:
+		t := time.Unix(int64(r.Value), 0).UTC()
+		return fmt.Sprintf("time %d (%s)", r.Value,
+			t.Format("2006-01-02 15:04:05 UTC"))
+
+	case LockTimeRelativeBlocks:
+		return fmt.Sprintf("%d blocks after the spent output is mined",
+			int64(r.Value)&wire.SequenceLockTimeMask)
+
+	case LockTimeRelativeSeconds:
+		units := int64(r.Value) & wire.SequenceLockTimeMask
+		seconds := units << wire.SequenceLockTimeGranularity
+		return fmt.Sprintf("%d x 512 seconds (%v) after the spent output "+
+			"is mined", units, time.Duration(seconds)*time.Second)
+
+	case LockTimeRelativeDisabled:
+		return fmt.Sprintf("sequence %d has the disable flag set and is "+
+			"not enforced", r.Value)
+
+	case LockTimeNegative:
+		return fmt.Sprintf("negative lock time %d", r.Value)
+	}
+	return "unknown lock time"
+}
+
+// interpretLockTime returns the kind of lock time the provided value pushed
+// prior to the given opcode represents.
+func interpretLockTime(op byte, value ScriptNum) LockTimeKind {
+	switch {
+	case value < 0:
+		return LockTimeNegative
+
+	case op == OP_CHECKLOCKTIMEVERIFY && value < LockTimeThreshold:
+		return LockTimeHeight
+
+	case op == OP_CHECKLOCKTIMEVERIFY:
+		return LockTimeTimestamp
+
+	case int64(value)&wire.SequenceLockTimeDisabled != 0:
+		return LockTimeRelativeDisabled
+
+	case int64(value)&wire.SequenceLockTimeIsSeconds != 0:
+		return LockTimeRelativeSeconds
+	}
+	return LockTimeRelativeBlocks
+}
+
+// ExtractLockTimes returns the lock times enforced by every
+// OP_CHECKLOCKTIMEVERIFY and OP_CHECKSEQUENCEVERIFY in the provided script in
+// script order.  The lock time of each opcode is taken from the number pushed
+// immediately prior to it and its kind is LockTimeUnknown when there is no
+// such push.
+//
+// NOTE: The lock times are extracted without regard to the conditional
+// branches the opcodes are in.
+func ExtractLockTimes(scriptVersion uint16, script []byte) ([]LockTimeRequirement, error) {
+	if scriptVersion != 0 {
+		str := fmt.Sprintf("unsupported script version %d", scriptVersion)
+		return nil, scriptError(ErrUnsupportedScriptVersion, str)
+	}
+
+	var reqs []LockTimeRequirement
+	var prevOp byte = OP_INVALIDOPCODE
+	var prevData []byte
+	tokenizer := MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		if op == OP_CHECKLOCKTIMEVERIFY || op == OP_CHECKSEQUENCEVERIFY {
+			req := LockTimeRequirement{
+				Opcode: op,
+				Offset: int(tokenizer.ByteIndex()) - 1,
+			}
+			switch {
+			case prevOp == OP_1NEGATE:
+				req.Value = -1
+				req.Kind = interpretLockTime(op, req.Value)
+
+			case IsSmallInt(prevOp):
+				req.Value = ScriptNum(AsSmallInt(prevOp))
+				req.Kind = interpretLockTime(op, req.Value)
+
+			case prevOp <= OP_PUSHDATA4:
+				value, err := MakeScriptNum(prevData, CltvMaxScriptNumLen)
+				if err == nil {
+					req.Value = value
+					req.Kind = interpretLockTime(op, value)
+				}
+			}
+			reqs = append(reqs, req)
+		}
+		prevOp, prevData = op, tokenizer.Data()
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+// Check returns nil when the provided transaction input satisfies the lock
+// time requirement as it would be enforced by the script engine or an error
+// with kind ErrUnsatisfiedLockTime which describes why not otherwise.  An
+// error with kind ErrNegativeLockTime is returned when the requirement kind is
+// LockTimeNegative and one with kind ErrUnknownLockTime when it is
+// LockTimeUnknown.
+//
+// NOTE: This only checks the transaction fields the opcodes inspect.  The
+// transaction itself is not final until the chain reaches its lock time and
+// the spent outputs reach the age required by its sequence numbers.
+func (r *LockTimeRequirement) Check(tx *wire.MsgTx, txIdx int) error {
+	if txIdx < 0 || txIdx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", txIdx, len(tx.TxIn))
+		return scriptError(ErrInvalidIndex, str)
+	}
+	txIn := tx.TxIn[txIdx]
+
+	switch r.Kind {
+	case LockTimeUnknown:
+		return scriptError(ErrUnknownLockTime, "lock time is not a "+
+			"number pushed by the script")
+
+	case LockTimeNegative:
+		str := fmt.Sprintf("negative lock time: %d", r.Value)
+		if r.Opcode == OP_CHECKSEQUENCEVERIFY {
+			str = fmt.Sprintf("negative sequence: %d", r.Value)
+		}
+		return scriptError(ErrNegativeLockTime, str)
+
+	case LockTimeHeight, LockTimeTimestamp:
+		err := verifyLockTime(int64(tx.LockTime), LockTimeThreshold,
+			int64(r.Value))
+		if err != nil {
+			return err
+		}
+		if txIn.Sequence == wire.MaxTxInSequenceNum {
+			return scriptError(ErrUnsatisfiedLockTime,
+				"transaction input is finalized")
+		}
+		return nil
+
+	case LockTimeRelativeDisabled:
+		return nil
+	}
+
+	if tx.Version < 2 {
+		str := fmt.Sprintf("invalid transaction version: %d", tx.Version)
+		return scriptError(ErrUnsatisfiedLockTime, str)
+	}
+	txSequence := int64(txIn.Sequence)
+	if txSequence&wire.SequenceLockTimeDisabled != 0 {
+		str := fmt.Sprintf("transaction sequence has sequence locktime "+
+			"disabled bit set: 0x%x", txSequence)
+		return scriptError(ErrUnsatisfiedLockTime, str)
+	}
+	return verifyLockTime(txSequence&relativeLockTimeMask,
+		wire.SequenceLockTimeIsSeconds, int64(r.Value)&relativeLockTimeMask)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// TestExtractLockTimes ensures lock times are extracted from scripts and
+// interpreted according to the opcode that enforces them.
+func TestExtractLockTimes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script string
+		want   []LockTimeRequirement
+		err    error
+	}{{
+		name:   "absolute height",
+		script: "500000 CHECKLOCKTIMEVERIFY DROP",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKLOCKTIMEVERIFY,
+			Offset: 4,
+			Value:  500000,
+			Kind:   LockTimeHeight,
+		}},
+	}, {
+		name:   "absolute timestamp",
+		script: "1600000000 CHECKLOCKTIMEVERIFY DROP",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKLOCKTIMEVERIFY,
+			Offset: 5,
+			Value:  1600000000,
+			Kind:   LockTimeTimestamp,
+		}},
+	}, {
+		name:   "relative blocks and seconds in branches",
+		script: "IF 16 CHECKSEQUENCEVERIFY ELSE 4194305 CHECKSEQUENCEVERIFY ENDIF",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKSEQUENCEVERIFY,
+			Offset: 2,
+			Value:  16,
+			Kind:   LockTimeRelativeBlocks,
+		}, {
+			Opcode: OP_CHECKSEQUENCEVERIFY,
+			Offset: 8,
+			Value:  4194305,
+			Kind:   LockTimeRelativeSeconds,
+		}},
+	}, {
+		name:   "relative disabled",
+		script: "2147483648 CHECKSEQUENCEVERIFY",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKSEQUENCEVERIFY,
+			Offset: 6,
+			Value:  2147483648,
+			Kind:   LockTimeRelativeDisabled,
+		}},
+	}, {
+		name:   "computed lock time",
+		script: "ADD CHECKLOCKTIMEVERIFY",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKLOCKTIMEVERIFY,
+			Offset: 1,
+		}},
+	}, {
+		name:   "negative lock time",
+		script: "-1 CHECKLOCKTIMEVERIFY",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKLOCKTIMEVERIFY,
+			Offset: 1,
+			Value:  -1,
+			Kind:   LockTimeNegative,
+		}},
+	}, {
+		name:   "negative pushed sequence",
+		script: "DATA_1 0x81 CHECKSEQUENCEVERIFY",
+		want: []LockTimeRequirement{{
+			Opcode: OP_CHECKSEQUENCEVERIFY,
+			Offset: 2,
+			Value:  -1,
+			Kind:   LockTimeNegative,
+		}},
+	}, {
+		name:   "no lock times",
+		script: "DUP HASH160 DATA_20 0x01{20} EQUALVERIFY CHECKSIG",
+	}, {
+		name:   "unparsable script",
+		script: "CHECKLOCKTIMEVERIFY 0x4c",
+		err:    ErrMalformedPush,
+	}}
+
+	for _, test := range tests {
+		script := mustParseShortForm(test.script)
+		got, err := ExtractLockTimes(0, script)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%q: unexpected number of lock times -- got %d, want %d",
+				test.name, len(got), len(test.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%q: lock time #%d: unexpected result -- got %+v, "+
+					"want %+v", test.name, i, got[i], test.want[i])
+			}
+		}
+	}
+}
+
+// TestLockTimeRequirementCheck ensures checking lock time requirements against
+// transactions matches the rules enforced by the script engine.
+func TestLockTimeRequirementCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		req      LockTimeRequirement
+		version  uint16
+		lockTime uint32
+		sequence uint32
+		err      error
+	}{{
+		name:     "height satisfied",
+		req:      LockTimeRequirement{Value: 500000, Kind: LockTimeHeight},
+		lockTime: 500000,
+	}, {
+		name:     "height not reached",
+		req:      LockTimeRequirement{Value: 500000, Kind: LockTimeHeight},
+		lockTime: 499999,
+		err:      ErrUnsatisfiedLockTime,
+	}, {
+		name:     "height against timestamp",
+		req:      LockTimeRequirement{Value: 500000, Kind: LockTimeHeight},
+		lockTime: 1600000000,
+		err:      ErrUnsatisfiedLockTime,
+	}, {
+		name: "timestamp satisfied",
+		req: LockTimeRequirement{
+			Value: 1600000000,
+			Kind:  LockTimeTimestamp,
+		},
+		lockTime: 1600000001,
+	}, {
+		name:     "finalized input",
+		req:      LockTimeRequirement{Value: 500000, Kind: LockTimeHeight},
+		lockTime: 500000,
+		sequence: wire.MaxTxInSequenceNum,
+		err:      ErrUnsatisfiedLockTime,
+	}, {
+		name:     "relative blocks satisfied",
+		req:      LockTimeRequirement{Value: 16, Kind: LockTimeRelativeBlocks},
+		version:  2,
+		sequence: 16,
+	}, {
+		name:     "relative blocks with old tx version",
+		req:      LockTimeRequirement{Value: 16, Kind: LockTimeRelativeBlocks},
+		version:  1,
+		sequence: 16,
+		err:      ErrUnsatisfiedLockTime,
+	}, {
+		name:     "relative blocks against seconds",
+		req:      LockTimeRequirement{Value: 16, Kind: LockTimeRelativeBlocks},
+		version:  2,
+		sequence: wire.SequenceLockTimeIsSeconds | 16,
+		err:      ErrUnsatisfiedLockTime,
+	}, {
+		name:     "relative lock disabled by the transaction",
+		req:      LockTimeRequirement{Value: 16, Kind: LockTimeRelativeBlocks},
+		version:  2,
+		sequence: wire.SequenceLockTimeDisabled | 16,
+		err:      ErrUnsatisfiedLockTime,
+	}, {
+		name: "relative lock disabled by the script",
+		req: LockTimeRequirement{
+			Value: wire.SequenceLockTimeDisabled,
+			Kind:  LockTimeRelativeDisabled,
+		},
+		version: 1,
+	}, {
+		name: "negative lock time",
+		req: LockTimeRequirement{Opcode: OP_CHECKSEQUENCEVERIFY, Value: -1,
+			Kind: LockTimeNegative},
+		version:  2,
+		sequence: 16,
+		err:      ErrNegativeLockTime,
+	}, {
+		name:     "unknown lock time",
+		req:      LockTimeRequirement{},
+		version:  2,
+		lockTime: 500000,
+		err:      ErrUnknownLockTime,
+	}}
+
+	for _, test := range tests {
+		tx := wire.NewMsgTx()
+		tx.Version = test.version
+		tx.LockTime = test.lockTime
+		tx.AddTxIn(&wire.TxIn{Sequence: test.sequence})
+		err := test.req.Check(tx, 0)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+}
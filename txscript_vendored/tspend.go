@@ -0,0 +1,244 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/schnorr"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TSpendTxVersion is the transaction version required for treasury spends by
+// the treasury agenda.
+const TSpendTxVersion = 3
+
+// TSpendPayout is a payment made by a treasury spend.
+type TSpendPayout struct {
+	Address dcrutil.Address
+	Amount  dcrutil.Amount
+}
+
+// PayToTreasuryGen creates a new script to pay a treasury spend output to the
+// specified address.  Only pay-to-pubkey-hash and pay-to-script-hash addresses
+// are supported.
+func PayToTreasuryGen(addr dcrutil.Address) ([]byte, error) {
+	switch addr := addr.(type) {
+	case *dcrutil.AddressPubKeyHash, *dcrutil.AddressScriptHash:
+		if addr == nil {
+			return nil, scriptError(ErrUnsupportedAddress, nilAddrErrStr)
+		}
+
+	default:
+		str := fmt.Sprintf("unable to generate payment script for "+
+			"unsupported address type %T", addr)
+		return nil, scriptError(ErrUnsupportedAddress, str)
+	}
+
+	script, err := PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !isPubKeyHashScript(script) && !isScriptHashScript(script) {
+		str := "unable to generate payment script for unsupported " +
+			"digital signature algorithm"
+		return nil, scriptError(ErrUnsupportedAddress, str)
+	}
+	return append([]byte{OP_TGEN}, script...), nil
+}
+
+// NewTSpendTx creates an unsigned treasury spend transaction which pays the
+// provided payouts and the fee from the treasury and expires at the given
+// height.  The transaction must be signed with TSpendSignatureScript by one of
+// the Pi keys recognized by consensus prior to being broadcast.
+//
+// The first output commits to the total value spent from the treasury followed
+// by random bytes that make the transaction hash unique.
+func NewTSpendTx(payouts []TSpendPayout, fee dcrutil.Amount, expiry uint32) (*wire.MsgTx, error) {
+	if len(payouts) == 0 {
+		return nil, scriptError(ErrInvalidStakeLayout,
+			"treasury spend requires at least one payout")
+	}
+
+	tx := wire.NewMsgTx()
+	tx.Version = TSpendTxVersion
+	tx.Expiry = expiry
+
+	var valueIn dcrutil.Amount
+	payoutOuts := make([]*wire.TxOut, 0, len(payouts))
+	for _, payout := range payouts {
+		if payout.Amount <= 0 {
+			str := fmt.Sprintf("invalid payout amount %v", payout.Amount)
+			return nil, scriptError(ErrInvalidStakeLayout, str)
+		}
+		script, err := PayToTreasuryGen(payout.Address)
+		if err != nil {
+			return nil, err
+		}
+		valueIn += payout.Amount
+		payoutOuts = append(payoutOuts, wire.NewTxOut(int64(payout.Amount),
+			script))
+	}
+	valueIn += fee
+
+	var data [tspendDataLen]byte
+	binary.LittleEndian.PutUint64(data[:8], uint64(valueIn))
+	if _, err := rand.Read(data[8:]); err != nil {
+		return nil, err
+	}
+	script, err := NewScriptBuilder().AddOp(OP_RETURN).AddData(data[:]).
+		Script()
+	if err != nil {
+		return nil, err
+	}
+
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular),
+		Sequence:    wire.MaxTxInSequenceNum,
+		ValueIn:     int64(valueIn),
+		BlockHeight: wire.NullBlockHeight,
+		BlockIndex:  wire.NullBlockIndex,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, script))
+	for _, txOut := range payoutOuts {
+		tx.AddTxOut(txOut)
+	}
+	return tx, nil
+}
+
+// TSpendDetails houses the details of a treasury spend transaction.
+type TSpendDetails struct {
+	// Value is the total value spent from the treasury as committed to by
+	// the first output.
+	Value dcrutil.Amount
+
+	// Payouts are the payments made by the treasury spend.
+	Payouts []TSpendPayout
+
+	// PubKey and Signature are the public key of the signer and the
+	// signature provided by the signature script.  They are nil when the
+	// transaction is not signed.
+	PubKey    []byte
+	Signature []byte
+}
+
+// Fee returns the fee paid by the treasury spend.
+func (d *TSpendDetails) Fee() dcrutil.Amount {
+	fee := d.Value
+	for _, payout := range d.Payouts {
+		fee -= payout.Amount
+	}
+	return fee
+}
+
+// ExtractTSpendDetails returns the details of the provided treasury spend
+// transaction.  An error with kind ErrInvalidStakeLayout is returned when the
+// transaction does not have the layout of a treasury spend.  Unsigned
+// transactions, such as those created by NewTSpendTx, are accepted.
+func ExtractTSpendDetails(tx *wire.MsgTx, params dcrutil.AddressParams) (*TSpendDetails, error) {
+	if len(tx.TxIn) != 1 || len(tx.TxOut) < 2 {
+		return nil, scriptError(ErrInvalidStakeLayout, "treasury spend "+
+			"must have one input and at least two outputs")
+	}
+
+	// Analyze the transaction as a signed treasury spend so the layout is
+	// validated even when it is not signed yet.
+	sigScript := tx.TxIn[0].SignatureScript
+	var pushes [][]byte
+	if len(sigScript) > 0 {
+		if !isTSpendSigScript(sigScript) {
+			return nil, scriptError(ErrInvalidStakeLayout, "signature "+
+				"script is not a treasury spend signature script")
+		}
+		var err error
+		pushes, err = PushedData(sigScript[:len(sigScript)-1])
+		if err != nil {
+			return nil, err
+		}
+		if len(pushes) != 2 {
+			str := fmt.Sprintf("treasury spend signature script pushes "+
+				"%d items instead of 2", len(pushes))
+			return nil, scriptError(ErrInvalidStakeLayout, str)
+		}
+	}
+	analyzed := tx
+	if len(sigScript) == 0 {
+		analyzed = tx.Copy()
+		analyzed.TxIn[0].SignatureScript = []byte{OP_TSPEND}
+	}
+	info := AnalyzeStakeTx(analyzed, params, true)
+	if info.Type != TxTypeTreasurySpend {
+		return nil, scriptError(ErrInvalidStakeLayout,
+			"transaction is not a treasury spend")
+	}
+	if info.Err != nil {
+		return nil, info.Err
+	}
+
+	details := &TSpendDetails{
+		Value: dcrutil.Amount(binary.LittleEndian.Uint64(
+			tx.TxOut[0].PkScript[2:10])),
+	}
+	for _, out := range info.Outputs[1:] {
+		details.Payouts = append(details.Payouts, TSpendPayout{
+			Address: out.Addresses[0],
+			Amount:  out.Amount,
+		})
+	}
+	if pushes != nil {
+		details.Signature, details.PubKey = pushes[0], pushes[1]
+	}
+	return details, nil
+}
+
+// VerifyTSpendSignature ensures the signature script of the provided treasury
+// spend transaction provides a valid Schnorr signature of the transaction by
+// the public key it also provides.  An error with kind
+// ErrInvalidTSpendSignature is returned otherwise.
+//
+// NOTE: Consensus additionally requires the public key to be one of the Pi
+// keys of the network, which must be checked by the caller.
+func VerifyTSpendSignature(tx *wire.MsgTx) error {
+	if len(tx.TxIn) != 1 || !isTSpendSigScript(tx.TxIn[0].SignatureScript) {
+		return scriptError(ErrInvalidTSpendSignature,
+			"transaction is not a signed treasury spend")
+	}
+	sigScript := tx.TxIn[0].SignatureScript
+	pushes, err := PushedData(sigScript[:len(sigScript)-1])
+	if err != nil {
+		return err
+	}
+	if len(pushes) != 2 {
+		str := fmt.Sprintf("treasury spend signature script pushes %d "+
+			"items instead of 2", len(pushes))
+		return scriptError(ErrInvalidTSpendSignature, str)
+	}
+
+	sig, err := schnorr.ParseSignature(pushes[0])
+	if err != nil {
+		str := fmt.Sprintf("invalid signature: %v", err)
+		return scriptError(ErrInvalidTSpendSignature, str)
+	}
+	pubKey, err := secp256k1.ParsePubKey(pushes[1])
+	if err != nil {
+		str := fmt.Sprintf("invalid public key: %v", err)
+		return scriptError(ErrInvalidTSpendSignature, str)
+	}
+	hash, err := CalcSignatureHash(nil, SigHashAll, tx, 0, nil)
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(hash, pubKey) {
+		return scriptError(ErrInvalidTSpendSignature,
+			"signature does not verify")
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// TestTSpendRoundTrip ensures treasury spends created by NewTSpendTx and signed
+// with TSpendSignatureScript are decoded and verified.
+func TestTSpendRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	p2pkh, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x01}, 20),
+		mainNetParams, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p2sh, err := dcrutil.NewAddressScriptHashFromHash(bytes.Repeat(
+		[]byte{0x02}, 20), mainNetParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payouts := []TSpendPayout{
+		{Address: p2pkh, Amount: 1000},
+		{Address: p2sh, Amount: 2000},
+	}
+
+	tx, err := NewTSpendTx(payouts, 300, 1234)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Version != TSpendTxVersion || tx.Expiry != 1234 {
+		t.Fatalf("unexpected version %d or expiry %d", tx.Version, tx.Expiry)
+	}
+
+	// Ensure the unsigned transaction is decoded.
+	details, err := ExtractTSpendDetails(tx, mainNetParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if details.Value != 3300 || details.Fee() != 300 {
+		t.Fatalf("unexpected value %v or fee %v", details.Value,
+			details.Fee())
+	}
+	if len(details.Payouts) != len(payouts) {
+		t.Fatalf("unexpected number of payouts %d", len(details.Payouts))
+	}
+	for i, payout := range details.Payouts {
+		if payout.Address.String() != payouts[i].Address.String() ||
+			payout.Amount != payouts[i].Amount {
+
+			t.Fatalf("unexpected payout %d -- got %v, want %v", i, payout,
+				payouts[i])
+		}
+	}
+	if details.PubKey != nil || details.Signature != nil {
+		t.Fatal("unexpected signature of unsigned transaction")
+	}
+	err = VerifyTSpendSignature(tx)
+	if !errors.Is(err, ErrInvalidTSpendSignature) {
+		t.Fatalf("unexpected error for unsigned transaction -- got %v, "+
+			"want %v", err, ErrInvalidTSpendSignature)
+	}
+
+	// Sign the transaction and ensure the signature verifies.
+	privKey := bytes.Repeat([]byte{0x03}, 32)
+	sigScript, err := TSpendSignatureScript(tx, privKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+	details, err = ExtractTSpendDetails(tx, mainNetParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pubKey := secp256k1.PrivKeyFromBytes(privKey).PubKey().SerializeCompressed()
+	if !bytes.Equal(details.PubKey, pubKey) {
+		t.Fatalf("unexpected pubkey -- got %x, want %x", details.PubKey,
+			pubKey)
+	}
+	if err := VerifyTSpendSignature(tx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Ensure changing the transaction invalidates the signature.
+	tx.Expiry++
+	err = VerifyTSpendSignature(tx)
+	if !errors.Is(err, ErrInvalidTSpendSignature) {
+		t.Fatalf("unexpected error for modified transaction -- got %v, "+
+			"want %v", err, ErrInvalidTSpendSignature)
+	}
+}
+
+// TestNewTSpendTxErrors ensures invalid treasury spends are rejected.
+func TestNewTSpendTxErrors(t *testing.T) {
+	t.Parallel()
+
+	pubKey := secp256k1.PrivKeyFromBytes(bytes.Repeat([]byte{0x03}, 32)).
+		PubKey().SerializeCompressed()
+	p2pk, err := dcrutil.NewAddressSecpPubKey(pubKey, mainNetParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p2pkh, err := dcrutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x01}, 20),
+		mainNetParams, dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		payouts []TSpendPayout
+		err     error
+	}{{
+		name: "no payouts",
+		err:  ErrInvalidStakeLayout,
+	}, {
+		name:    "zero amount",
+		payouts: []TSpendPayout{{Address: p2pkh}},
+		err:     ErrInvalidStakeLayout,
+	}, {
+		name:    "pay to pubkey",
+		payouts: []TSpendPayout{{Address: p2pk, Amount: 1}},
+		err:     ErrUnsupportedAddress,
+	}}
+
+	for _, test := range tests {
+		_, err := NewTSpendTx(test.payouts, 0, 0)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+}
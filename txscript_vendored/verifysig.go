@@ -0,0 +1,125 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/ecdsa"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3/schnorr"
+	"github.com/decred/dcrd/wire"
+)
+
+// CalcCheckSigHash returns the subscript and signature hash a signature
+// checking opcode executing the provided subscript for the input idx of the
+// given transaction checks the provided signature against.  As done by the
+// opcodes, the hash type is the final byte of the signature and the signature
+// is removed from the subscript since there is no way for a signature to sign
+// itself.
+//
+// NOTE: This function is only valid for version 0 scripts.
+func CalcCheckSigHash(tx *wire.MsgTx, idx int, subScript, sig []byte) ([]byte, []byte, error) {
+	if len(sig) == 0 {
+		str := "signature is empty"
+		return nil, nil, scriptError(ErrSigTooShort, str)
+	}
+	hashType := SigHashType(sig[len(sig)-1])
+	subScript = removeOpcodeByData(subScript, sig)
+	hash, err := CalcSignatureHash(subScript, hashType, tx, idx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return subScript, hash, nil
+}
+
+// VerifySignature returns whether the provided signature, which includes the
+// trailing hash type byte, is a valid signature of the hash by the public key
+// for the signature type.  The signature and public key are parsed and
+// verified as done by OP_CHECKSIG for ECDSA secp256k1 signatures and by
+// OP_CHECKSIGALT for Ed25519 and secp256k1 Schnorr signatures.
+//
+// An error is returned, along with false, describing why the signature or
+// public key is rejected before verifying the signature, such as an invalid
+// encoding or an unsupported signature type.  Note that the opcodes fail the
+// script for some of these errors and only push false for others.
+func VerifySignature(sigType dcrec.SignatureType, sig, pubKey, hash []byte) (bool, error) {
+	if len(sig) == 0 {
+		str := "signature is empty"
+		return false, scriptError(ErrSigTooShort, str)
+	}
+	hashType := SigHashType(sig[len(sig)-1])
+	sigBytes := sig[:len(sig)-1]
+
+	switch sigType {
+	case dcrec.STEcdsaSecp256k1:
+		if err := CheckHashTypeEncoding(hashType); err != nil {
+			return false, err
+		}
+		if err := CheckSignatureEncoding(sigBytes); err != nil {
+			return false, err
+		}
+		if err := CheckPubKeyEncoding(pubKey); err != nil {
+			return false, err
+		}
+		parsedPubKey, err := secp256k1.ParsePubKey(pubKey)
+		if err != nil {
+			return false, err
+		}
+		parsedSig, err := ecdsa.ParseDERSignature(sigBytes)
+		if err != nil {
+			return false, err
+		}
+		return parsedSig.Verify(hash, parsedPubKey), nil
+
+	case dcrec.STEd25519, dcrec.STSchnorrSecp256k1:
+		// Only 32-byte keys are allowed for Ed25519 signatures and
+		// 33-byte compressed keys for secp256k1 Schnorr signatures, while
+		// both signatures are 64 bytes followed by the hash type.
+		wantPubKeyLen := 32
+		if sigType == dcrec.STSchnorrSecp256k1 {
+			wantPubKeyLen = 33
+		}
+		if len(pubKey) != wantPubKeyLen {
+			str := fmt.Sprintf("public key length %d is not %d",
+				len(pubKey), wantPubKeyLen)
+			return false, scriptError(ErrPubKeyType, str)
+		}
+		if len(sig) != 65 {
+			str := fmt.Sprintf("signature length %d is not 65", len(sig))
+			return false, scriptError(ErrSigInvalidDataLen, str)
+		}
+		if err := CheckHashTypeEncoding(hashType); err != nil {
+			return false, err
+		}
+
+		if sigType == dcrec.STEd25519 {
+			parsedPubKey, err := edwards.ParsePubKey(pubKey)
+			if err != nil {
+				return false, err
+			}
+			parsedSig, err := edwards.ParseSignature(sigBytes)
+			if err != nil {
+				return false, err
+			}
+			return edwards.Verify(parsedPubKey, hash, parsedSig.GetR(),
+				parsedSig.GetS()), nil
+		}
+		parsedPubKey, err := schnorr.ParsePubKey(pubKey)
+		if err != nil {
+			return false, err
+		}
+		parsedSig, err := schnorr.ParseSignature(sigBytes)
+		if err != nil {
+			return false, err
+		}
+		return parsedSig.Verify(hash, parsedPubKey), nil
+	}
+
+	str := fmt.Sprintf("unsupported signature type %d", sigType)
+	return false, scriptError(ErrUnsupportedSigType, str)
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrec/edwards/v2"
+	"github.com/decred/dcrd/dcrec/secp256k1/v3"
+)
+
+// TestVerifySignature ensures signatures of all supported types are verified
+// against the hash computed by CalcCheckSigHash and invalid signatures and
+// public keys are rejected.
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	secret := bytes.Repeat([]byte{0x01}, 32)
+	secpPubKey := secp256k1.PrivKeyFromBytes(secret).PubKey().
+		SerializeCompressed()
+	edPrivKey, edPubKey := edwards.PrivKeyFromSecret(secret)
+	edKey := edPrivKey.SerializeSecret()
+
+	subScript := mustParseShortForm("DUP HASH160 DATA_20 0x" +
+		"0102030405060708090a0b0c0d0e0f1011121314 EQUALVERIFY CHECKSIG")
+	tx, err := CreateSpendingTx(nil, subScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sign returns a signature of the subscript of the specified type and
+	// hash type.
+	sign := func(key []byte, sigType dcrec.SignatureType, hashType SigHashType) []byte {
+		sig, err := RawTxInSignature(tx, 0, subScript, hashType, key,
+			sigType)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sig
+	}
+	ecdsaSig := sign(secret, dcrec.STEcdsaSecp256k1, SigHashAll)
+	schnorrSig := sign(secret, dcrec.STSchnorrSecp256k1, SigHashAll)
+	edSig := sign(edKey, dcrec.STEd25519, SigHashAll)
+	singleSig := sign(secret, dcrec.STEcdsaSecp256k1, SigHashSingle)
+	badHashTypeSig := append(ecdsaSig[:len(ecdsaSig)-1:len(ecdsaSig)-1],
+		0x1f)
+	otherSecret := bytes.Repeat([]byte{0x02}, 32)
+	otherSig := sign(otherSecret, dcrec.STEcdsaSecp256k1, SigHashAll)
+	otherEdPrivKey, _ := edwards.PrivKeyFromSecret(otherSecret)
+	otherEdSig := sign(otherEdPrivKey.SerializeSecret(), dcrec.STEd25519,
+		SigHashAll)
+
+	tests := []struct {
+		name    string
+		sigType dcrec.SignatureType
+		sig     []byte
+		pubKey  []byte
+		valid   bool
+		err     error
+	}{{
+		name:    "valid ecdsa",
+		sigType: dcrec.STEcdsaSecp256k1,
+		sig:     ecdsaSig,
+		pubKey:  secpPubKey,
+		valid:   true,
+	}, {
+		name:    "valid schnorr",
+		sigType: dcrec.STSchnorrSecp256k1,
+		sig:     schnorrSig,
+		pubKey:  secpPubKey,
+		valid:   true,
+	}, {
+		name:    "valid ed25519",
+		sigType: dcrec.STEd25519,
+		sig:     edSig,
+		pubKey:  edPubKey.Serialize(),
+		valid:   true,
+	}, {
+		name:    "valid ecdsa with single hash type",
+		sigType: dcrec.STEcdsaSecp256k1,
+		sig:     singleSig,
+		pubKey:  secpPubKey,
+		valid:   true,
+	}, {
+		name:    "ecdsa by another key",
+		sigType: dcrec.STEcdsaSecp256k1,
+		sig:     otherSig,
+		pubKey:  secpPubKey,
+	}, {
+		name:    "ed25519 by another key",
+		sigType: dcrec.STEd25519,
+		sig:     otherEdSig,
+		pubKey:  edPubKey.Serialize(),
+	}, {
+		name:    "ecdsa signature checked as schnorr",
+		sigType: dcrec.STSchnorrSecp256k1,
+		sig:     ecdsaSig,
+		pubKey:  secpPubKey,
+		err:     ErrSigInvalidDataLen,
+	}, {
+		name:    "schnorr with ed25519 public key",
+		sigType: dcrec.STSchnorrSecp256k1,
+		sig:     schnorrSig,
+		pubKey:  edPubKey.Serialize(),
+		err:     ErrPubKeyType,
+	}, {
+		name:    "invalid hash type",
+		sigType: dcrec.STEcdsaSecp256k1,
+		sig:     badHashTypeSig,
+		pubKey:  secpPubKey,
+		err:     ErrInvalidSigHashType,
+	}, {
+		name:    "empty signature",
+		sigType: dcrec.STEcdsaSecp256k1,
+		pubKey:  secpPubKey,
+		err:     ErrSigTooShort,
+	}, {
+		name:    "unsupported signature type",
+		sigType: 3,
+		sig:     schnorrSig,
+		pubKey:  secpPubKey,
+		err:     ErrUnsupportedSigType,
+	}}
+
+	for _, test := range tests {
+		// Use the signature hash of a valid signature for signatures
+		// without a valid hash type.
+		sig := test.sig
+		if len(sig) == 0 || sig[len(sig)-1] == 0x1f {
+			sig = ecdsaSig
+		}
+		_, hash, err := CalcCheckSigHash(tx, 0, subScript, sig)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+
+		valid, err := VerifySignature(test.sigType, test.sig, test.pubKey,
+			hash)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if valid != test.valid {
+			t.Errorf("%q: unexpected result -- got %v, want %v", test.name,
+				valid, test.valid)
+		}
+	}
+}
+
+// TestCalcCheckSigHash ensures the signature is removed from the subscript
+// and the hash type of the signature is used to compute the signature hash.
+func TestCalcCheckSigHash(t *testing.T) {
+	t.Parallel()
+
+	subScript := mustParseShortForm("DATA_2 0x0102 DROP 1")
+	tx, err := CreateSpendingTx(nil, subScript, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantScript := mustParseShortForm("DROP 1")
+	wantHash, err := CalcSignatureHash(wantScript, 0x02, tx, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script, hash, err := CalcCheckSigHash(tx, 0, subScript, []byte{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(script, wantScript) || !bytes.Equal(hash, wantHash) {
+		t.Fatalf("unexpected subscript %x and hash %x -- want %x and %x",
+			script, hash, wantScript, wantHash)
+	}
+
+	_, _, err = CalcCheckSigHash(tx, 0, subScript, nil)
+	if !errors.Is(err, ErrSigTooShort) {
+		t.Fatalf("unexpected error -- got %v, want %v", err, ErrSigTooShort)
+	}
+}
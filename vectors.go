@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// Kinds of reference test vector files.
+const (
+	vectorsScript    = "script"
+	vectorsTxValid   = "tx-valid"
+	vectorsTxInvalid = "tx-invalid"
+	vectorsSigHash   = "sighash"
+)
+
+// vectorsKind returns the kind of reference test vectors of the file at the
+// provided path according to the name of the reference files.
+func vectorsKind(path string) (string, error) {
+	name := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(name, "script_tests"):
+		return vectorsScript, nil
+	case strings.HasPrefix(name, "tx_valid"):
+		return vectorsTxValid, nil
+	case strings.HasPrefix(name, "tx_invalid"):
+		return vectorsTxInvalid, nil
+	case strings.HasPrefix(name, "sighash"):
+		return vectorsSigHash, nil
+	}
+	return "", fmt.Errorf("unable to determine the kind of vectors in %s "+
+		"(use -kind)", path)
+}
+
+// vectorF64ToUint32 converts numbers read from the vectors to unsigned 32-bit
+// integers.  The vectors use -1 to mean the maximum value.
+func vectorF64ToUint32(f float64) uint32 {
+	return uint32(int32(f))
+}
+
+// describeResult returns the result of a script execution in the format of
+// the reference script tests along with the error.
+func describeResult(err error) string {
+	result, ferr := txscript.FormatVectorResult(err)
+	switch {
+	case err == nil:
+		return result
+	case ferr != nil:
+		return err.Error()
+	}
+	return fmt.Sprintf("%s (%v)", result, err)
+}
+
+// runScriptVector runs a vector of the reference script tests.  It returns a
+// description of the failure or an empty string when it passes.
+func runScriptVector(test []interface{}) (string, error) {
+	if len(test) < 4 || len(test) > 5 {
+		return "", fmt.Errorf("invalid vector length %d", len(test))
+	}
+	fields := make([]string, len(test))
+	for i, field := range test {
+		s, ok := field.(string)
+		if !ok {
+			return "", fmt.Errorf("field %d is not a string", i)
+		}
+		fields[i] = s
+	}
+	sigScript, err := txscript.ParseShortFormScript(fields[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid signature script: %v", err)
+	}
+	pkScript, err := txscript.ParseShortFormScript(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid public key script: %v", err)
+	}
+	flags, err := txscript.ParseVectorFlags(fields[2])
+	if err != nil {
+		return "", err
+	}
+	wantKinds, err := txscript.ParseVectorResult(fields[3])
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := txscript.CreateSpendingTx(sigScript, pkScript, nil)
+	if err != nil {
+		return "", err
+	}
+	vm, err := txscript.NewEngine(pkScript, tx, 0, flags, 0, nil)
+	if err == nil {
+		err = vm.Execute()
+	}
+
+	pass := err == nil && len(wantKinds) == 0
+	for _, kind := range wantKinds {
+		if errors.Is(err, kind) {
+			pass = true
+		}
+	}
+	if pass {
+		return "", nil
+	}
+	return fmt.Sprintf("  sigScript: %s\n  pkScript:  %s\n  flags:     %s\n"+
+		"  want:      %s\n  got:       %s\n", disasm(sigScript),
+		disasm(pkScript), fields[2], fields[3], describeResult(err)), nil
+}
+
+// runTxVector runs a vector of the reference valid or invalid transaction
+// tests.  It returns a description of the failure or an empty string when it
+// passes.
+func runTxVector(test []interface{}, wantValid bool) (string, error) {
+	inputs, ok := test[0].([]interface{})
+	if !ok || len(test) != 3 {
+		return "", errors.New("vector is not of the form [[inputs...], " +
+			"tx, flags]")
+	}
+	txHex, ok := test[1].(string)
+	if !ok {
+		return "", errors.New("transaction is not a string")
+	}
+	tx, err := decodeTx(txHex)
+	if err != nil {
+		return "", err
+	}
+	flagStr, ok := test[2].(string)
+	if !ok {
+		return "", errors.New("flags are not a string")
+	}
+	flags, err := txscript.ParseVectorFlags(flagStr)
+	if err != nil {
+		return "", err
+	}
+
+	prevOuts := make(map[wire.OutPoint][]byte)
+	for j, iinput := range inputs {
+		input, ok := iinput.([]interface{})
+		if !ok || len(input) != 3 {
+			return "", fmt.Errorf("input %d is not of the form [hash, "+
+				"index, script]", j)
+		}
+		hashStr, ok1 := input[0].(string)
+		idx, ok2 := input[1].(float64)
+		scriptStr, ok3 := input[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return "", fmt.Errorf("input %d has invalid fields", j)
+		}
+		hash, err := chainhash.NewHashFromStr(hashStr)
+		if err != nil {
+			return "", fmt.Errorf("input %d: %v", j, err)
+		}
+		script, err := txscript.ParseShortFormScript(scriptStr)
+		if err != nil {
+			return "", fmt.Errorf("input %d: %v", j, err)
+		}
+		outPoint := wire.NewOutPoint(hash, vectorF64ToUint32(idx),
+			wire.TxTreeRegular)
+		prevOuts[*outPoint] = script
+	}
+
+	var report strings.Builder
+	for k, txIn := range tx.TxIn {
+		pkScript, ok := prevOuts[txIn.PreviousOutPoint]
+		if !ok {
+			return "", fmt.Errorf("missing previous output of input %d", k)
+		}
+		vm, err := txscript.NewEngine(pkScript, tx, k, flags, 0, nil)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if !wantValid && err != nil {
+			// Invalid transactions pass as soon as an input fails.
+			return "", nil
+		}
+		fmt.Fprintf(&report, "  input %d: %s\n    sigScript: %s\n"+
+			"    pkScript:  %s\n", k, describeResult(err),
+			disasm(txIn.SignatureScript), disasm(pkScript))
+		if err != nil {
+			return fmt.Sprintf("  flags: %s\n  want: all inputs valid\n%s",
+				flagStr, report.String()), nil
+		}
+	}
+	if wantValid {
+		return "", nil
+	}
+	return fmt.Sprintf("  flags: %s\n  want: an invalid input\n%s", flagStr,
+		report.String()), nil
+}
+
+// runSigHashVector runs a vector of the reference signature hash tests.  It
+// returns a description of the failure or an empty string when it passes.
+func runSigHashVector(test []interface{}) (string, error) {
+	if len(test) < 6 || len(test) > 7 {
+		return "", fmt.Errorf("invalid vector length %d", len(test))
+	}
+	txHex, ok1 := test[0].(string)
+	scriptHex, ok2 := test[1].(string)
+	idx, ok3 := test[2].(float64)
+	hashTypeF64, ok4 := test[3].(float64)
+	wantHashStr, ok5 := test[4].(string)
+	resultStr, ok6 := test[5].(string)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 {
+		return "", errors.New("vector has invalid fields")
+	}
+	tx, err := decodeTx(txHex)
+	if err != nil {
+		return "", err
+	}
+	subScript, err := decodeScript(scriptHex)
+	if err != nil {
+		return "", err
+	}
+	wantHash, err := hex.DecodeString(wantHashStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature hash: %v", err)
+	}
+	wantErr, err := txscript.ParseSigHashVectorResult(resultStr)
+	if err != nil {
+		return "", err
+	}
+
+	hashType := txscript.SigHashType(vectorF64ToUint32(hashTypeF64))
+	hash, err := txscript.CalcSignatureHash(subScript, hashType, tx,
+		int(idx), nil)
+	switch {
+	case wantErr != nil && errors.Is(err, wantErr):
+		return "", nil
+	case wantErr == nil && err == nil && bytes.Equal(hash, wantHash):
+		return "", nil
+	}
+
+	want := resultStr
+	if wantErr == nil {
+		want = hex.EncodeToString(wantHash)
+	}
+	got := hex.EncodeToString(hash)
+	if err != nil {
+		got = err.Error()
+	}
+	return fmt.Sprintf("  input: %d\n  hash type: %#x\n  subscript: %s\n"+
+		"  want: %s\n  got:  %s\n", int(idx), hashType, disasm(subScript),
+		want, got), nil
+}
+
+// runVectorsFile runs the vectors of the file at the provided path that match
+// the filter and returns the number of passed and failed vectors.
+func runVectorsFile(path, kind string, filter *regexp.Regexp, verbose bool) (int, int, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var tests [][]interface{}
+	if err := json.Unmarshal(b, &tests); err != nil {
+		return 0, 0, fmt.Errorf("%s: %v", path, err)
+	}
+
+	var passed, failed int
+	var comment string
+	for i, test := range tests {
+		// Single strings are comments that describe the following
+		// vectors in the transaction files and are skipped elsewhere.
+		if len(test) == 1 {
+			if s, ok := test[0].(string); ok {
+				comment = s
+			}
+			continue
+		}
+		if kind == vectorsTxValid || kind == vectorsTxInvalid {
+			if _, ok := test[0].([]interface{}); !ok {
+				continue
+			}
+		}
+
+		entry, _ := json.Marshal(test)
+		if filter != nil && !filter.Match(entry) &&
+			!filter.MatchString(comment) {
+
+			continue
+		}
+
+		var failure string
+		switch kind {
+		case vectorsScript:
+			failure, err = runScriptVector(test)
+		case vectorsTxValid:
+			failure, err = runTxVector(test, true)
+		case vectorsTxInvalid:
+			failure, err = runTxVector(test, false)
+		case vectorsSigHash:
+			failure, err = runSigHashVector(test)
+		}
+		name := fmt.Sprintf("%s #%d", path, i)
+		if kind != vectorsScript && kind != vectorsSigHash &&
+			comment != "" {
+
+			name += fmt.Sprintf(" (%s)", comment)
+		} else if kind == vectorsScript && len(test) == 5 {
+			name += fmt.Sprintf(" (%v)", test[4])
+		}
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("FAIL %s: malformed vector: %v\n", name, err)
+		case failure != "":
+			failed++
+			fmt.Printf("FAIL %s\n%s", name, failure)
+		default:
+			passed++
+			if verbose {
+				fmt.Printf("PASS %s\n", name)
+			}
+		}
+	}
+	return passed, failed, nil
+}
+
+// cmdVectors runs reference test vector files, or user-supplied files in the
+// same formats, and reports the failing vectors.
+func cmdVectors(args []string) error {
+	fs := flag.NewFlagSet("vectors", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	kind := fs.String("kind", "", "kind of vectors (script, tx-valid, "+
+		"tx-invalid or sighash)")
+	filterStr := fs.String("filter", "", "only run vectors whose JSON or "+
+		"comment matches the regular expression")
+	verbose := fs.Bool("v", false, "report passing vectors as well")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	switch *kind {
+	case "", vectorsScript, vectorsTxValid, vectorsTxInvalid, vectorsSigHash:
+	default:
+		return fmt.Errorf("unknown kind of vectors %q", *kind)
+	}
+	var filter *regexp.Regexp
+	if *filterStr != "" {
+		var err error
+		filter, err = regexp.Compile(*filterStr)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %v", err)
+		}
+	}
+
+	var passed, failed int
+	for _, path := range fs.Args() {
+		fileKind := *kind
+		if fileKind == "" {
+			var err error
+			fileKind, err = vectorsKind(path)
+			if err != nil {
+				return err
+			}
+		}
+		p, f, err := runVectorsFile(path, fileKind, filter, *verbose)
+		if err != nil {
+			return err
+		}
+		passed += p
+		failed += f
+	}
+	fmt.Printf("%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d vectors failed", failed)
+	}
+	return nil
+}
+
+// cmdVectorRecord executes the provided signature and public key scripts and
+// prints the execution as a new vector in the format of the reference script
+// tests.
+func cmdVectorRecord(args []string) error {
+	fs := flag.NewFlagSet("vector-record", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	flagStr := fs.String("flags", "", "script flags in the vector format "+
+		"(defaults to the consensus flags)")
+	comment := fs.String("comment", "", "comment describing the vector")
+	shortForm := fs.Bool("shortform", false, "scripts are in the short "+
+		"form of the vectors instead of hex")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+
+	parse := decodeScript
+	if *shortForm {
+		parse = txscript.ParseShortFormScript
+	}
+	sigScript, err := parse(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	pkScript, err := parse(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	flags := txscript.ConsensusScriptFlags
+	if *flagStr != "" {
+		flags, err = txscript.ParseVectorFlags(*flagStr)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := txscript.CreateSpendingTx(sigScript, pkScript, nil)
+	if err != nil {
+		return err
+	}
+	vm, err := txscript.NewEngine(pkScript, tx, 0, flags, 0, nil)
+	if err == nil {
+		err = vm.Execute()
+	}
+	result, err := txscript.FormatVectorResult(err)
+	if err != nil {
+		return err
+	}
+
+	vector := []string{
+		txscript.FormatShortFormScript(sigScript),
+		txscript.FormatShortFormScript(pkScript),
+		txscript.FormatVectorFlags(flags),
+		result,
+	}
+	if *comment != "" {
+		vector = append(vector, *comment)
+	}
+	b, err := json.Marshal(vector)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s,\n", b)
+	return nil
+}
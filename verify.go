@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/slog"
+)
+
+// enableTrace logs the execution of scripts by the engine, including the
+// diagnostics of signature checks, to stderr.
+func enableTrace() {
+	logger := slog.NewBackend(os.Stderr).Logger("TXSC")
+	logger.SetLevel(slog.LevelTrace)
+	txscript.UseLogger(logger)
+}
+
+// printSigChecks prints the diagnostics of the signature checks recorded by
+// rec in the order they were performed, skipping the number of checks of each
+// kind recorded by prev when it is not nil.
+func printSigChecks(rec, prev *txscript.SigCheckRecorder) {
+	multiSigs, checkSigs := rec.MultiSigs, rec.CheckSigs
+	if prev != nil {
+		multiSigs = multiSigs[len(prev.MultiSigs):]
+		checkSigs = checkSigs[len(prev.CheckSigs):]
+	}
+
+	// Scripts can not loop, so the checks are performed in the order of
+	// the opcodes that perform them.
+	for len(multiSigs) != 0 || len(checkSigs) != 0 {
+		if len(checkSigs) == 0 || (len(multiSigs) != 0 &&
+			(multiSigs[0].ScriptIdx < checkSigs[0].ScriptIdx ||
+				(multiSigs[0].ScriptIdx == checkSigs[0].ScriptIdx &&
+					multiSigs[0].OpcodeIdx < checkSigs[0].OpcodeIdx))) {
+
+			fmt.Println(multiSigs[0])
+			multiSigs = multiSigs[1:]
+			continue
+		}
+		fmt.Println(checkSigs[0])
+		checkSigs = checkSigs[1:]
+	}
+}
+
+// cmdVerify executes the scripts of an input of a transaction spending an
+// output with the provided public key script and prints the result along with
+// the diagnostics of the signature checks.
+func cmdVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	version := fs.Uint("version", 0, "script version")
+	flagStr := fs.String("flags", "consensus-current", "script flags or "+
+		"presets separated by commas")
+	trace := fs.Bool("trace", false, "log the execution to stderr")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 3 {
+		return errUsage
+	}
+	flags, err := txscript.ParseScriptFlags(*flagStr)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeTx(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	idx, err := strconv.Atoi(fs.Arg(1))
+	if err != nil || idx < 0 || idx >= len(tx.TxIn) {
+		return fmt.Errorf("invalid input index %q", fs.Arg(1))
+	}
+	pkScript, err := decodeScript(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	if *trace {
+		enableTrace()
+	}
+
+	vm, err := txscript.NewEngine(pkScript, tx, idx, flags,
+		uint16(*version), nil)
+	if err != nil {
+		return err
+	}
+	var rec txscript.SigCheckRecorder
+	vm.SetSigCheckObserver(&rec)
+	err = vm.Execute()
+	printSigChecks(&rec, nil)
+	if err != nil {
+		fmt.Printf("Result: %v\n", err)
+		return nil
+	}
+	fmt.Printf("Result: OK\n")
+	return nil
+}